@@ -0,0 +1,10 @@
+// Package shellprofiles embeds the shell profiles metadata, so commands that
+// read it work from an installed binary without the data directory.
+package shellprofiles
+
+import "embed"
+
+// FS holds core.yaml, contexts.yaml, dev.yaml and automation.yaml at its root.
+//
+//go:embed *.yaml
+var FS embed.FS
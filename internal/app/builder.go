@@ -1,6 +1,8 @@
 package app
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"sort"
@@ -26,6 +28,12 @@ type FileWriter interface {
 	WriteFile(path string, content string) error
 }
 
+// FileRemover defines the interface for deleting files. A FileWriter that
+// also implements it lets the builder remove stale directory target files.
+type FileRemover interface {
+	Remove(path string) error
+}
+
 // BackupCreator defines the interface for creating backups.
 type BackupCreator interface {
 	CreateBackup(path string) (string, error)
@@ -66,6 +74,38 @@ type BuildOptions struct {
 	Shell     string   // Shell type override (zsh, bash, fish)
 	Targets   []string // Specific targets to build (empty = all)
 	HomeDir   string   // Home directory for path resolution
+	Profile   string   // Manifest profile to apply (empty = select by Hostname)
+	Hostname  string   // Hostname used for automatic profile selection
+
+	// Tags narrows the build to tagged modules (and their dependencies).
+	Tags domain.ModuleSelector
+
+	// Vars override manifest variables (--var name=value). After resolution
+	// Build replaces it with the full set of variables in effect.
+	Vars map[string]string
+
+	// Host carries the facts module 'when:' conditions are evaluated against.
+	// OS and Shell are taken from the build itself; Hostname defaults to the
+	// field above.
+	Host domain.HostFacts
+
+	// AllowMissing keeps building when module files are missing, unreadable
+	// or fail to render: they are replaced by a placeholder comment and
+	// reported in BuildResult.Errors instead of failing the build.
+	AllowMissing bool
+
+	// ProfileStartup wraps every module in timing probes that append its
+	// load time to $SHELLFORGE_PROFILE_LOG (see 'startup-report').
+	ProfileStartup bool
+
+	// NoCache ignores the build cache in the output directory: every file
+	// is regenerated. The cache is still rewritten for the next build.
+	NoCache bool
+
+	// BuildTime is stamped into generated headers and build metadata.
+	// Zero means time.Now(); set it (e.g. from SOURCE_DATE_EPOCH) for
+	// byte-identical, reproducible output.
+	BuildTime time.Time
 }
 
 // TargetResult contains the result for a single target file.
@@ -76,6 +116,18 @@ type TargetResult struct {
 	ModuleCount int      // Number of modules in this target
 	ModuleNames []string // Module names in order
 	BackupPath  string   // Path to backup file (if created)
+
+	// Sources maps output lines back to the module each came from.
+	Sources domain.SourceMap
+
+	// Unchanged is set when the file already in the output directory is
+	// up to date: its inputs match the build cache, or the regenerated
+	// content is identical. Unchanged files are not rewritten.
+	Unchanged bool
+
+	// NotSourced is set for custom targets the shell does not read (no
+	// 'sourced_by'), such as an inputrc; they are not syntax checked.
+	NotSourced bool
 }
 
 // BuildResult contains the result of a build operation.
@@ -85,6 +137,69 @@ type BuildResult struct {
 	GeneratedAt      time.Time
 	ShellType        string
 	TargetOS         string
+	Profile          string        // Applied profile, empty if none
+	Errors           []ModuleError // Modules left out (only with AllowMissing)
+	Removed          []string      // Stale directory target files deleted from the output directory
+}
+
+// Changed returns the files that were (or, in a dry run, would be) written.
+func (r *BuildResult) Changed() []TargetResult {
+	var changed []TargetResult
+	for _, t := range r.Targets {
+		if !t.Unchanged {
+			changed = append(changed, t)
+		}
+	}
+	return changed
+}
+
+// Unchanged returns the files that were already up to date.
+func (r *BuildResult) Unchanged() []TargetResult {
+	var unchanged []TargetResult
+	for _, t := range r.Targets {
+		if t.Unchanged {
+			unchanged = append(unchanged, t)
+		}
+	}
+	return unchanged
+}
+
+// ErrModuleFileNotFound is the cause of a ModuleError for a missing file.
+var ErrModuleFileNotFound = errors.New("file not found")
+
+// ModuleError describes a module whose file could not be included in the
+// output: missing, unreadable or failing to render.
+type ModuleError struct {
+	Module string // module name
+	Path   string // module file path
+	Err    error  // cause
+}
+
+func (e ModuleError) Error() string {
+	return fmt.Sprintf("module '%s' (%s): %v", e.Module, e.Path, e.Err)
+}
+
+func (e ModuleError) Unwrap() error { return e.Err }
+
+// placeholder is the comment written in place of the module's content.
+func (e ModuleError) placeholder(comment string) string {
+	if errors.Is(e.Err, ErrModuleFileNotFound) {
+		return fmt.Sprintf("%s --- %s --- (FILE NOT FOUND: %s)", comment, e.Module, e.Path)
+	}
+	return fmt.Sprintf("%s --- %s --- (ERROR: %v)", comment, e.Module, e.Err)
+}
+
+// ModuleErrors is returned by Build when modules could not be included and
+// AllowMissing is not set. It lists every broken module, not just the first.
+type ModuleErrors []ModuleError
+
+func (e ModuleErrors) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("%d module(s) could not be included:", len(e)))
+	for _, me := range e {
+		lines = append(lines, "  - "+me.Error())
+	}
+	return strings.Join(lines, "\n")
 }
 
 // Build generates shell configuration from modules.
@@ -95,23 +210,57 @@ func (s *BuilderService) Build(opts BuildOptions) (*BuildResult, error) {
 		return nil, fmt.Errorf("failed to parse manifest: %w", err)
 	}
 
-	// 2. Determine shell type
+	// 2. Apply profile (explicit or matched by hostname) and tag selection
+	manifest, profile, err := SelectModules(manifest, Selection{
+		Profile:  opts.Profile,
+		Hostname: opts.Hostname,
+		Tags:     opts.Tags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to select modules: %w", err)
+	}
+	opts.Profile = profile
+
+	// 3. Resolve variables; they are interpolated into the selected modules
+	// once dependencies are resolved
+	opts.Vars = manifest.ResolveVars(opts.OS, profile, opts.Vars)
+
+	// 4. Determine shell type
 	shellType := s.determineShellType(opts, manifest)
 
-	// 3. Build dependency graph and resolve
+	// 5. Build dependency graph and resolve
 	graph, err := s.resolver.BuildGraph(manifest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build dependency graph: %w", err)
 	}
 
-	modules, err := s.resolver.TopologicalSort(graph, opts.OS)
+	facts := opts.Host
+	facts.OS = opts.OS
+	facts.Shell = shellType
+	if facts.Hostname == "" {
+		facts.Hostname = opts.Hostname
+	}
+
+	modules, err := s.resolver.TopologicalSortFor(graph, facts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve dependencies: %w", err)
 	}
 
-	now := time.Now()
+	// Only the modules built for this host are expanded: a module for
+	// another OS may use variables defined only in that OS's os_vars
+	for i := range modules {
+		if modules[i], err = modules[i].ExpandVars(opts.Vars); err != nil {
+			return nil, fmt.Errorf("failed to expand variables: %w", err)
+		}
+		manifest.Defaults.Apply(&modules[i])
+	}
+
+	now := opts.BuildTime
+	if now.IsZero() {
+		now = time.Now()
+	}
 
-	// 4. Build multi-target output
+	// 6. Build multi-target output
 	return s.buildMultiTarget(opts, manifest, modules, shellType, now)
 }
 
@@ -150,22 +299,53 @@ func (s *BuilderService) buildMultiTarget(opts BuildOptions, manifest *domain.Ma
 	// Create target resolver
 	resolver := domain.NewTargetResolver(shellType, outputDir)
 
-	// Validate targets
-	if err := resolver.ValidateTargets(modules); err != nil {
+	// Validate targets, including the manifest's custom ones
+	if err := resolver.AddCustomTargets(manifest.Targets); err != nil {
+		return nil, err
+	}
+	// Modules without a target go to the shell's main rc file, so one
+	// manifest builds every shell
+	for i := range modules {
+		if modules[i].Target == "" {
+			modules[i].Target = s.getDefaultTarget(shellType)
+		}
+	}
+
+	// Modules that only declare 'path' have no body to place in a target
+	bodies := make([]domain.Module, 0, len(modules))
+	for _, mod := range modules {
+		if mod.HasBody() {
+			bodies = append(bodies, mod)
+		}
+	}
+	if err := resolver.ValidateTargets(bodies); err != nil {
 		return nil, err
 	}
 
 	// Group modules by target
-	targetGroups := s.groupModulesByTarget(modules)
+	targetGroups := s.groupModulesByTarget(bodies)
 
 	// Filter targets if specific ones requested
 	if len(opts.Targets) > 0 {
 		targetGroups = s.filterTargets(targetGroups, opts.Targets)
 	}
 
-	// Sort modules within each target by priority
+	// Order modules within each target: dependencies first, then priority
 	for target := range targetGroups {
-		s.sortByPriority(targetGroups[target])
+		targetGroups[target] = domain.OrderTarget(targetGroups[target], modules)
+	}
+
+	loaders, err := s.sourceLoaders(manifest, opts, resolver, targetGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	pathTarget, pathLines, err := s.pathBlock(manifest, opts, resolver, modules)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := targetGroups[pathTarget]; pathTarget != "" && !ok && targetSelected(opts.Targets, pathTarget) {
+		targetGroups[pathTarget] = nil
 	}
 
 	// Generate content for each target
@@ -179,22 +359,36 @@ func (s *BuilderService) buildMultiTarget(opts BuildOptions, manifest *domain.Ma
 	}
 	sort.Strings(targetNames)
 
-	// Collect metadata for deploy
+	// Collect metadata for deploy and modules that could not be included
 	var metaFiles []domain.BuildFileInfo
+	var moduleErrs ModuleErrors
+
+	cache := s.loadBuildCache(opts, outputDir, manifestHash(opts, shellType, manifest.Output, modules))
 
 	for _, target := range targetNames {
 		mods := targetGroups[target]
-		if len(mods) == 0 {
+		if len(mods) == 0 && len(loaders[target]) == 0 && target != pathTarget {
 			continue
 		}
 
+		format := outputFormat{
+			shell:   shellType,
+			target:  target,
+			comment: resolver.CommentPrefix(target),
+			profile: opts.ProfileStartup && resolver.IsShellSourced(target),
+		}
+		if target == pathTarget {
+			format.prelude = pathLines
+		}
+
 		// Check if this is a directory target (e.g., conf.d)
 		if resolver.IsDirectoryTarget(target) {
 			// Handle directory target: one file per module
-			dirResults, dirMetaFiles, err := s.buildDirectoryTarget(opts, mods, resolver, target, shellType, now)
+			dirResults, dirMetaFiles, dirErrs, err := s.buildDirectoryTarget(opts, cache, mods, resolver, format, now)
 			if err != nil {
 				return nil, err
 			}
+			moduleErrs = append(moduleErrs, dirErrs...)
 			results = append(results, dirResults...)
 			metaFiles = append(metaFiles, dirMetaFiles...)
 			totalModuleCount += len(mods)
@@ -212,7 +406,22 @@ func (s *BuilderService) buildMultiTarget(opts BuildOptions, manifest *domain.Ma
 			return nil, err
 		}
 
-		content, moduleNames := s.generateContent(mods, opts, shellType, target, now)
+		// Home files are built at their home-relative path in the output
+		// directory; system files at their base name
+		source := destPath
+		if filepath.IsAbs(destPath) {
+			source = filepath.Base(filePath)
+		}
+
+		inputs := s.readModules(mods, opts, format.shell)
+		content, sources, unchanged, errs := s.buildFile(cache, source, filePath, inputs, func() (string, domain.SourceMap, []ModuleError) {
+			content, sources, errs := s.generateContent(inputs, opts, format, now)
+			if loader := loaders[target]; len(loader) > 0 {
+				content += "\n" + strings.Join(loader, "\n") + "\n"
+			}
+			return content, sources, errs
+		})
+		moduleErrs = append(moduleErrs, errs...)
 		totalModuleCount += len(mods)
 
 		result := TargetResult{
@@ -220,32 +429,53 @@ func (s *BuilderService) buildMultiTarget(opts BuildOptions, manifest *domain.Ma
 			FilePath:    filePath,
 			Content:     content,
 			ModuleCount: len(mods),
-			ModuleNames: moduleNames,
-		}
-
-		// Write file (unless dry-run)
-		if !opts.DryRun {
-			if err := s.fileWriter.WriteFile(filePath, content); err != nil {
-				return nil, fmt.Errorf("failed to write %s: %w", filePath, err)
-			}
+			ModuleNames: moduleNames(mods),
+			Sources:     sources,
+			Unchanged:   unchanged,
+			NotSourced:  !resolver.IsShellSourced(target),
 		}
 
 		results = append(results, result)
 
 		// Add to metadata
 		metaFiles = append(metaFiles, domain.BuildFileInfo{
-			Source:   filepath.Base(filePath),
+			Source:   source,
 			Target:   target,
 			DestPath: destPath,
+			Sources:  sources,
 		})
 	}
 
-	// Write metadata file (unless dry-run)
+	// Nothing is written when a module is broken, so CI catches renamed
+	// files before they are deployed
+	if len(moduleErrs) > 0 && !opts.AllowMissing {
+		return nil, moduleErrs
+	}
+
+	// Write files and metadata (unless dry-run)
+	var removed []string
 	if !opts.DryRun {
+		for _, result := range results {
+			if result.Unchanged {
+				continue
+			}
+			if err := s.fileWriter.WriteFile(result.FilePath, result.Content); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", result.FilePath, err)
+			}
+		}
+
+		removed, err = s.removeStaleFiles(opts, cache, resolver, outputDir, metaFiles)
+		if err != nil {
+			return nil, err
+		}
+
 		metadata := &domain.BuildMetadata{
 			Shell:       shellType,
 			OS:          opts.OS,
+			Profile:     opts.Profile,
+			ConfigDir:   opts.ConfigDir,
 			GeneratedAt: now,
+			Profiling:   opts.ProfileStartup,
 			Files:       metaFiles,
 		}
 		metaJSON, err := metadata.ToJSON()
@@ -256,6 +486,15 @@ func (s *BuilderService) buildMultiTarget(opts BuildOptions, manifest *domain.Ma
 		if err := s.fileWriter.WriteFile(metaPath, string(metaJSON)); err != nil {
 			return nil, fmt.Errorf("failed to write metadata: %w", err)
 		}
+
+		cacheJSON, err := cache.next.ToJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize build cache: %w", err)
+		}
+		cachePath := filepath.Join(outputDir, domain.CacheFileName)
+		if err := s.fileWriter.WriteFile(cachePath, string(cacheJSON)); err != nil {
+			return nil, fmt.Errorf("failed to write build cache: %w", err)
+		}
 	}
 
 	return &BuildResult{
@@ -264,6 +503,9 @@ func (s *BuilderService) buildMultiTarget(opts BuildOptions, manifest *domain.Ma
 		GeneratedAt:      now,
 		ShellType:        shellType,
 		TargetOS:         opts.OS,
+		Profile:          opts.Profile,
+		Errors:           moduleErrs,
+		Removed:          removed,
 	}, nil
 }
 
@@ -289,66 +531,320 @@ func (s *BuilderService) filterTargets(groups map[string][]domain.Module, target
 	return filtered
 }
 
-// sortByPriority sorts modules by priority (lower = earlier).
-func (s *BuilderService) sortByPriority(modules []domain.Module) {
-	sort.SliceStable(modules, func(i, j int) bool {
-		return modules[i].GetPriority() < modules[j].GetPriority()
-	})
+// targetSelected reports whether target is built when only targets were
+// requested (all targets when targets is empty).
+func targetSelected(targets []string, target string) bool {
+	if len(targets) == 0 {
+		return true
+	}
+	for _, t := range targets {
+		if strings.EqualFold(t, target) {
+			return true
+		}
+	}
+	return false
 }
 
-// generateContent generates the shell configuration content for a list of modules.
-func (s *BuilderService) generateContent(modules []domain.Module, opts BuildOptions, shellType, target string, now time.Time) (string, []string) {
-	var lines []string
+// sourceLoaders returns, by target, the loader lines to append to rc files
+// that source another target: zshrc.d and bashrc.d when output.dir_loader
+// is set, and custom targets with sourced_by. An rc file with no modules of
+// its own is added to groups so that it is still built.
+func (s *BuilderService) sourceLoaders(manifest *domain.Manifest, opts BuildOptions, resolver *domain.TargetResolver, groups map[string][]domain.Module) (map[string][]string, error) {
+	targets := make([]string, 0, len(groups))
+	for target := range groups {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
 
-	// Header
-	lines = append(lines, "# Generated by shellforge")
-	lines = append(lines, fmt.Sprintf("# Shell: %s", shellType))
-	if target != "" {
-		lines = append(lines, fmt.Sprintf("# Target: %s", target))
-	}
-	lines = append(lines, fmt.Sprintf("# OS: %s", opts.OS))
-	lines = append(lines, fmt.Sprintf("# Modules: %d", len(modules)))
-	lines = append(lines, fmt.Sprintf("# Generated at: %s", now.Format(time.RFC3339)))
-	lines = append(lines, "")
+	loaders := make(map[string][]string)
+	for _, target := range targets {
+		rc := resolver.SourcedBy(target)
+		if rc == "" && manifest.Output.LoadsDirTargets() {
+			rc = domain.DirectorySourcedBy(target)
+		}
+		if rc == "" || len(groups[target]) == 0 || !resolver.IsValidTarget(rc) || !targetSelected(opts.Targets, rc) {
+			continue
+		}
 
-	moduleNames := make([]string, 0, len(modules))
+		rel, err := resolver.GetRelativePath(target)
+		if err != nil {
+			return nil, err
+		}
+		if resolver.IsDirectoryTarget(target) {
+			loaders[rc] = append(loaders[rc], domain.DirectoryLoader(resolver.GetShellType(), target, rel, resolver.DirectoryFileExt(target))...)
+		} else {
+			loaders[rc] = append(loaders[rc], domain.FileLoader(resolver.GetShellType(), target, rel)...)
+		}
+		if _, ok := groups[rc]; !ok {
+			groups[rc] = nil
+		}
+	}
+	return loaders, nil
+}
 
-	for _, module := range modules {
-		moduleNames = append(moduleNames, module.Name)
+// pathBlock returns the target that gets the PATH block merged from the
+// modules' path declarations (output.path_target, or the shell's main rc
+// file), and the block's lines. The target is empty when no module adds to
+// PATH on the build's OS.
+func (s *BuilderService) pathBlock(manifest *domain.Manifest, opts BuildOptions, resolver *domain.TargetResolver, modules []domain.Module) (string, []string, error) {
+	entries := domain.MergePath(modules, opts.OS)
+	if len(entries) == 0 {
+		return "", nil, nil
+	}
 
-		// Construct full path
-		filePath := filepath.Join(opts.ConfigDir, module.File)
+	target := manifest.Output.PathTarget
+	if target == "" {
+		target = resolver.GetDefaultTarget()
+	}
+	if !resolver.IsValidTarget(target) || resolver.IsDirectoryTarget(target) || !resolver.IsShellSourced(target) {
+		return "", nil, domain.NewValidationError(
+			"PATH block target '%s' is not a shell file target for shell type '%s'; set output.path_target",
+			target, resolver.GetShellType(),
+		)
+	}
 
-		// Check if file exists
-		if !s.fileReader.FileExists(filePath) {
-			// Skip missing files in content generation (error will be caught elsewhere)
-			lines = append(lines, fmt.Sprintf("\n# --- %s --- (FILE NOT FOUND: %s)", module.Name, filePath))
+	lines, err := domain.PathBlock(resolver.GetShellType(), entries)
+	if err != nil {
+		return "", nil, err
+	}
+	return target, lines, nil
+}
+
+// removeStaleFiles deletes the directory target files the previous build
+// wrote that this build no longer produces, e.g. after a module was removed
+// or renumbered. Only targets selected by this build are considered, and
+// only files that still carry the shellforge header are deleted.
+func (s *BuilderService) removeStaleFiles(opts BuildOptions, cache *buildCacheState, resolver *domain.TargetResolver, outputDir string, metaFiles []domain.BuildFileInfo) ([]string, error) {
+	remover, ok := s.fileWriter.(FileRemover)
+	if !ok {
+		return nil, nil
+	}
+	data, err := s.fileReader.ReadFile(filepath.Join(outputDir, domain.MetadataFileName))
+	if err != nil {
+		return nil, nil
+	}
+	prev, err := domain.ParseBuildMetadata([]byte(data))
+	if err != nil {
+		return nil, nil
+	}
+
+	current := make(map[string]bool, len(metaFiles))
+	for _, f := range metaFiles {
+		current[f.Source] = true
+	}
+
+	var removed []string
+	for _, f := range prev.Files {
+		isDir := f.Directory || resolver.IsDirectoryTarget(f.Target)
+		if current[f.Source] || !isDir || !targetSelected(opts.Targets, f.Target) {
+			continue
+		}
+		delete(cache.next.Files, f.Source)
+		path := filepath.Join(outputDir, f.Source)
+		if content, ok := s.readExisting(path); !ok || !domain.IsGeneratedFile(content) {
 			continue
 		}
+		if err := remover.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale file %s: %w", path, err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// readModule returns a module's content, from its file, its inline content
+// or its generators rendered for shell, rendered against the build's
+// variables when the module asks for interpolation. A file written for
+// another shell is an error.
+func (s *BuilderService) readModule(mod domain.Module, opts BuildOptions, shell string) (string, *ModuleError) {
+	var content string
+	var err error
+	filePath := filepath.Join(opts.ConfigDir, mod.File)
+	switch {
+	case mod.Generate != nil:
+		filePath = mod.BodyLocation.String()
+		content, err = mod.Generate.Render(shell)
+		if err != nil {
+			return "", &ModuleError{Module: mod.Name, Path: filePath, Err: err}
+		}
+	case mod.Content != "":
+		filePath = mod.BodyLocation.String()
+		content = mod.Content
+	default:
+		// A module restricted with when: {shell: ...} vouches for its file
+		declared := mod.When != nil && len(mod.When.Shell) > 0
+		if err := domain.CheckSourceable(shell, mod.File, declared); err != nil {
+			err = fmt.Errorf("%w; use a portable 'generate' body or a shell-specific module (when: {shell: ...})", err)
+			return "", &ModuleError{Module: mod.Name, Path: filePath, Err: err}
+		}
+		if !s.fileReader.FileExists(filePath) {
+			return "", &ModuleError{Module: mod.Name, Path: filePath, Err: ErrModuleFileNotFound}
+		}
+		content, err = s.fileReader.ReadFile(filePath)
+		if err != nil {
+			return "", &ModuleError{Module: mod.Name, Path: filePath, Err: err}
+		}
+	}
 
-		// Read module content
-		content, err := s.fileReader.ReadFile(filePath)
+	if mod.IsInterpolated() {
+		content, err = domain.Interpolate(content, opts.Vars)
 		if err != nil {
-			lines = append(lines, fmt.Sprintf("\n# --- %s --- (READ ERROR: %v)", module.Name, err))
+			return "", &ModuleError{Module: mod.Name, Path: filePath, Err: err}
+		}
+	}
+	return content, nil
+}
+
+// moduleInput is a module together with its content as read for one build.
+type moduleInput struct {
+	module  domain.Module
+	content string
+	err     *ModuleError // set when the content could not be read or rendered
+}
+
+// readModules reads the content of every module of one output file.
+func (s *BuilderService) readModules(mods []domain.Module, opts BuildOptions, shell string) []moduleInput {
+	inputs := make([]moduleInput, len(mods))
+	for i, mod := range mods {
+		content, modErr := s.readModule(mod, opts, shell)
+		inputs[i] = moduleInput{module: mod, content: content, err: modErr}
+	}
+	return inputs
+}
+
+// moduleNames returns the names of modules in order.
+func moduleNames(mods []domain.Module) []string {
+	names := make([]string, 0, len(mods))
+	for _, mod := range mods {
+		names = append(names, mod.Name)
+	}
+	return names
+}
+
+// generateContent generates the shell configuration content for a list of
+// modules. Modules that cannot be read get a placeholder comment and are
+// returned as errors.
+func (s *BuilderService) generateContent(inputs []moduleInput, opts BuildOptions, format outputFormat, now time.Time) (string, domain.SourceMap, []ModuleError) {
+	var lines []string
+	c := format.comment
+
+	// Header
+	lines = append(lines, domain.GeneratedHeaderLine(c))
+	lines = append(lines, fmt.Sprintf("%s Shell: %s", c, format.shell))
+	if format.target != "" {
+		lines = append(lines, fmt.Sprintf("%s Target: %s", c, format.target))
+	}
+	lines = append(lines, fmt.Sprintf("%s OS: %s", c, opts.OS))
+	if opts.Profile != "" {
+		lines = append(lines, fmt.Sprintf("%s Profile: %s", c, opts.Profile))
+	}
+	lines = append(lines, fmt.Sprintf("%s Modules: %d", c, len(inputs)))
+	lines = append(lines, fmt.Sprintf("%s Generated at: %s", c, now.Format(time.RFC3339)))
+	lines = appendProfilingHeader(lines, format)
+	lines = append(lines, "")
+	if len(format.prelude) > 0 {
+		lines = append(lines, format.prelude...)
+		lines = append(lines, "")
+	}
+
+	var sources domain.SourceMap
+	var errs []ModuleError
+
+	for _, in := range inputs {
+		module, content := in.module, in.content
+		if in.err != nil {
+			errs = append(errs, *in.err)
+			lines = append(lines, "\n"+in.err.placeholder(c))
 			continue
 		}
 
 		// Add module header
 		lines = append(lines, "")
-		lines = append(lines, fmt.Sprintf("# --- %s ---", module.Name))
+		lines = append(lines, fmt.Sprintf("%s --- %s ---", c, module.Name))
 		if module.Description != "" {
-			lines = append(lines, fmt.Sprintf("# %s", module.Description))
+			lines = append(lines, fmt.Sprintf("%s %s", c, module.Description))
 		}
 		if module.Priority != 0 {
-			lines = append(lines, fmt.Sprintf("# Priority: %d", module.Priority))
+			lines = append(lines, fmt.Sprintf("%s Priority: %d", c, module.Priority))
+		}
+		if module.IsLazy() {
+			lines = append(lines, fmt.Sprintf("%s Lazy: loaded on first use of %s", c, strings.Join(module.Lazy, ", ")))
 		}
 
 		// Add module content (trim trailing whitespace)
-		lines = append(lines, strings.TrimRight(content, " \t\n"))
+		var span domain.SourceSpan
+		lines, span = appendModuleBody(lines, module, content, format)
+		sources = append(sources, span)
 		lines = append(lines, "")
 	}
 
-	return strings.Join(lines, "\n"), moduleNames
+	return strings.Join(lines, "\n"), sources, errs
+}
+
+// appendProfilingHeader notes startup profiling in the header and loads
+// what the probes need.
+func appendProfilingHeader(lines []string, format outputFormat) []string {
+	if !format.profile {
+		return lines
+	}
+	lines = append(lines, fmt.Sprintf("%s Startup profiling: module load times are appended to $%s", format.comment, domain.StartupProfileEnv))
+	return append(lines, domain.StartupPreamble(format.shell)...)
+}
+
+// outputFormat selects how the files of one target are generated: their
+// header comments and the code around module bodies.
+type outputFormat struct {
+	shell   string
+	target  string // target name, also recorded by profiling probes
+	comment string // header comment prefix
+	profile bool   // add startup timing probes
+
+	// prelude is code placed after the header, before any module: the
+	// merged PATH block in its target.
+	prelude []string
+}
+
+// appendModuleBody appends a module's content, inside its isolation guard,
+// lazy loader and timing probes if it has them, to the output lines and
+// returns the span the content occupies once the lines are joined with
+// newlines.
+func appendModuleBody(lines []string, mod domain.Module, content string, format outputFormat) ([]string, domain.SourceSpan) {
+	var guard, lazy domain.ModuleGuard
+	if mod.IsIsolated() {
+		guard = domain.IsolationGuard(format.shell, mod.Name)
+	}
+	if mod.IsLazy() {
+		lazy = domain.LazyLoader(format.shell, mod.Name, mod.Lazy)
+	}
+	var probeAfter []string
+	if format.profile {
+		var probeBefore []string
+		probeBefore, probeAfter = domain.StartupProbe(format.shell, mod.Name, format.target)
+		lines = append(lines, probeBefore...)
+	}
+	lines = append(lines, lazy.Before...)
+	lines = append(lines, guard.Before...)
+
+	start := 1
+	for _, line := range lines {
+		start += strings.Count(line, "\n") + 1
+	}
+	body := strings.TrimRight(content, " \t\n")
+	span := domain.SourceSpan{
+		Module:     mod.Name,
+		File:       mod.File,
+		StartLine:  start,
+		Lines:      strings.Count(body, "\n") + 1,
+		SourceLine: 1,
+	}
+	if mod.IsInline() {
+		// Point into the manifest that declares the body
+		span.File, span.SourceLine, span.Inline = mod.BodyLocation.File, mod.BodyLocation.Line, true
+	}
+	lines = append(lines, body)
+	lines = append(lines, guard.After...)
+	lines = append(lines, lazy.After...)
+	return append(lines, probeAfter...), span
 }
 
 // getDefaultTarget returns the default target for a shell type.
@@ -365,31 +861,39 @@ func (s *BuilderService) getDefaultTarget(shellType string) string {
 	}
 }
 
-// buildDirectoryTarget handles directory targets like conf.d where each module
-// gets its own file instead of being merged into a single file.
-func (s *BuilderService) buildDirectoryTarget(opts BuildOptions, mods []domain.Module, resolver *domain.TargetResolver, target, shellType string, now time.Time) ([]TargetResult, []domain.BuildFileInfo, error) {
+// buildDirectoryTarget handles directory targets like conf.d or zshrc.d where
+// each module gets its own file instead of being merged into a single file.
+func (s *BuilderService) buildDirectoryTarget(opts BuildOptions, cache *buildCacheState, mods []domain.Module, resolver *domain.TargetResolver, format outputFormat, now time.Time) ([]TargetResult, []domain.BuildFileInfo, []ModuleError, error) {
+	target := format.target
 	// Get the directory path
 	dirPath, err := resolver.Resolve(target)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	// Get relative path for deploy metadata (e.g., ".config/fish/conf.d")
+	// Get relative path for deploy metadata and the build directory
+	// (e.g., ".config/fish/conf.d")
 	relDirPath, err := resolver.GetRelativePath(target)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	var results []TargetResult
 	var metaFiles []domain.BuildFileInfo
+	var errs []ModuleError
 
-	for _, mod := range mods {
-		// Generate filename from module name: {module-name}.fish
-		fileName := sanitizeModuleName(mod.Name) + ".fish"
+	for i, mod := range mods {
+		// Numbered so the shell sources the files in resolved order
+		fileName := domain.DirectoryFileName(resolver.DirectoryFileExt(target), i+1, len(mods), mod.Name)
 		filePath := filepath.Join(dirPath, fileName)
+		source := filepath.Join(relDirPath, fileName)
 
 		// Generate content for single module
-		content := s.generateSingleModuleContent(mod, opts, shellType, target, now)
+		inputs := s.readModules([]domain.Module{mod}, opts, format.shell)
+		content, sources, unchanged, modErrs := s.buildFile(cache, source, filePath, inputs, func() (string, domain.SourceMap, []ModuleError) {
+			return s.generateSingleModuleContent(inputs[0], opts, format, now)
+		})
+		errs = append(errs, modErrs...)
 
 		result := TargetResult{
 			Target:      target,
@@ -397,84 +901,186 @@ func (s *BuilderService) buildDirectoryTarget(opts BuildOptions, mods []domain.M
 			Content:     content,
 			ModuleCount: 1,
 			ModuleNames: []string{mod.Name},
-		}
-
-		// Write file (unless dry-run)
-		if !opts.DryRun {
-			if err := s.fileWriter.WriteFile(filePath, content); err != nil {
-				return nil, nil, fmt.Errorf("failed to write %s: %w", filePath, err)
-			}
+			Sources:     sources,
+			Unchanged:   unchanged,
+			NotSourced:  !resolver.IsShellSourced(target),
 		}
 
 		results = append(results, result)
 
 		// Add to metadata with full destination path
 		metaFiles = append(metaFiles, domain.BuildFileInfo{
-			Source:   filepath.Join("conf.d", fileName),
-			Target:   target,
-			DestPath: filepath.Join(relDirPath, fileName),
+			Source:    source,
+			Target:    target,
+			DestPath:  filepath.Join(relDirPath, fileName),
+			Directory: true,
+			Sources:   result.Sources,
 		})
 	}
 
-	return results, metaFiles, nil
+	return results, metaFiles, errs, nil
 }
 
 // generateSingleModuleContent generates shell configuration content for a single module.
 // Used for directory targets where each module gets its own file.
-func (s *BuilderService) generateSingleModuleContent(mod domain.Module, opts BuildOptions, shellType, target string, now time.Time) string {
+func (s *BuilderService) generateSingleModuleContent(in moduleInput, opts BuildOptions, format outputFormat, now time.Time) (string, domain.SourceMap, []ModuleError) {
+	mod := in.module
 	var lines []string
+	c := format.comment
 
 	// Header
-	lines = append(lines, "# Generated by shellforge")
-	lines = append(lines, fmt.Sprintf("# Shell: %s", shellType))
-	lines = append(lines, fmt.Sprintf("# Module: %s", mod.Name))
-	if target != "" {
-		lines = append(lines, fmt.Sprintf("# Target: %s", target))
-	}
-	lines = append(lines, fmt.Sprintf("# OS: %s", opts.OS))
-	lines = append(lines, fmt.Sprintf("# Generated at: %s", now.Format(time.RFC3339)))
-	lines = append(lines, "")
-
-	// Construct full path
-	filePath := filepath.Join(opts.ConfigDir, mod.File)
-
-	// Check if file exists
-	if !s.fileReader.FileExists(filePath) {
-		lines = append(lines, fmt.Sprintf("# --- %s --- (FILE NOT FOUND: %s)", mod.Name, filePath))
-		return strings.Join(lines, "\n")
+	lines = append(lines, domain.GeneratedHeaderLine(c))
+	lines = append(lines, fmt.Sprintf("%s Shell: %s", c, format.shell))
+	lines = append(lines, fmt.Sprintf("%s Module: %s", c, mod.Name))
+	if format.target != "" {
+		lines = append(lines, fmt.Sprintf("%s Target: %s", c, format.target))
 	}
+	lines = append(lines, fmt.Sprintf("%s OS: %s", c, opts.OS))
+	if opts.Profile != "" {
+		lines = append(lines, fmt.Sprintf("%s Profile: %s", c, opts.Profile))
+	}
+	lines = append(lines, fmt.Sprintf("%s Generated at: %s", c, now.Format(time.RFC3339)))
+	lines = appendProfilingHeader(lines, format)
+	lines = append(lines, "")
 
-	// Read module content
-	content, err := s.fileReader.ReadFile(filePath)
-	if err != nil {
-		lines = append(lines, fmt.Sprintf("# --- %s --- (READ ERROR: %v)", mod.Name, err))
-		return strings.Join(lines, "\n")
+	if in.err != nil {
+		lines = append(lines, in.err.placeholder(c))
+		return strings.Join(lines, "\n"), nil, []ModuleError{*in.err}
 	}
 
 	// Add module description as comment
 	if mod.Description != "" {
-		lines = append(lines, fmt.Sprintf("# %s", mod.Description))
+		lines = append(lines, fmt.Sprintf("%s %s", c, mod.Description))
 	}
 	if mod.Priority != 0 {
-		lines = append(lines, fmt.Sprintf("# Priority: %d", mod.Priority))
+		lines = append(lines, fmt.Sprintf("%s Priority: %d", c, mod.Priority))
+	}
+	if mod.IsLazy() {
+		lines = append(lines, fmt.Sprintf("%s Lazy: loaded on first use of %s", c, strings.Join(mod.Lazy, ", ")))
 	}
 	lines = append(lines, "")
 
 	// Add module content (trim trailing whitespace)
-	lines = append(lines, strings.TrimRight(content, " \t\n"))
+	lines, span := appendModuleBody(lines, mod, in.content, format)
 	lines = append(lines, "")
 
-	return strings.Join(lines, "\n")
+	return strings.Join(lines, "\n"), domain.SourceMap{span}, nil
+}
+
+// buildCacheVersion is mixed into the manifest hash so that a shellforge
+// release changing the output format invalidates existing caches.
+const buildCacheVersion = 1
+
+// buildCacheState holds the cache of the previous build (nil if there is
+// none, it is stale or NoCache is set) and the cache being built.
+type buildCacheState struct {
+	prev *domain.BuildCache
+	next *domain.BuildCache
 }
 
-// sanitizeModuleName converts a module name to a safe filename.
-// Replaces spaces and special characters with underscores.
-func sanitizeModuleName(name string) string {
-	// Replace common unsafe characters
-	name = strings.ReplaceAll(name, " ", "_")
-	name = strings.ReplaceAll(name, "/", "_")
-	name = strings.ReplaceAll(name, "\\", "_")
-	name = strings.ReplaceAll(name, ":", "_")
-	name = strings.ToLower(name)
-	return name
+// manifestHash hashes everything besides module file contents that shapes
+// the generated files.
+func manifestHash(opts BuildOptions, shellType string, output domain.OutputConfig, modules []domain.Module) string {
+	decls := make([]domain.Module, len(modules))
+	for i, mod := range modules {
+		// Where a module is declared does not affect the output; where an
+		// inline body is declared does, through the source map
+		mod.Location = domain.SourceLocation{}
+		mod.PatchedAt = nil
+		decls[i] = mod
+	}
+	data, err := json.Marshal(struct {
+		Version   int
+		Profiling bool
+		Output    domain.OutputConfig
+		Shell     string
+		OS        string
+		Profile   string
+		Vars      map[string]string
+		BuildTime time.Time
+		Modules   []domain.Module
+	}{buildCacheVersion, opts.ProfileStartup, output, shellType, opts.OS, opts.Profile, opts.Vars, opts.BuildTime, decls})
+	if err != nil {
+		// Unhashable input: use a hash no cache can match
+		return ""
+	}
+	return domain.HashContent(string(data))
+}
+
+// loadBuildCache reads the cache of the previous build from outputDir. A
+// missing, unreadable or stale cache starts an empty one.
+func (s *BuilderService) loadBuildCache(opts BuildOptions, outputDir, hash string) *buildCacheState {
+	state := &buildCacheState{next: domain.NewBuildCache(hash)}
+	if opts.NoCache || hash == "" {
+		return state
+	}
+
+	data, err := s.fileReader.ReadFile(filepath.Join(outputDir, domain.CacheFileName))
+	if err != nil {
+		return state
+	}
+	prev, err := domain.ParseBuildCache([]byte(data))
+	if err != nil || prev.ManifestHash != hash {
+		return state
+	}
+
+	// Keep entries of files not built this time (--target)
+	for file, entry := range prev.Files {
+		state.next.Files[file] = entry
+	}
+	state.prev = prev
+	return state
+}
+
+// buildFile produces the content of one output file. When the cache shows
+// the file was built from the same inputs and the file on disk is intact,
+// that content is reused instead of generated. unchanged reports whether the
+// file on disk is already up to date.
+func (s *BuilderService) buildFile(cache *buildCacheState, source, filePath string, inputs []moduleInput, generate func() (string, domain.SourceMap, []ModuleError)) (content string, sources domain.SourceMap, unchanged bool, errs []ModuleError) {
+	key, cacheable := cacheKey(inputs)
+	existing, exists := s.readExisting(filePath)
+
+	if cacheable && cache.prev != nil && exists {
+		if entry, ok := cache.prev.Lookup(source, key); ok && domain.HashContent(existing) == entry.ContentHash {
+			return existing, entry.Sources, true, nil
+		}
+	}
+
+	content, sources, errs = generate()
+	if cacheable {
+		cache.next.Files[source] = domain.CachedFile{
+			Modules:     key,
+			ContentHash: domain.HashContent(content),
+			Sources:     sources,
+		}
+	} else {
+		// Files with placeholders are never reused
+		delete(cache.next.Files, source)
+	}
+	return content, sources, exists && existing == content, errs
+}
+
+// cacheKey returns the cache inputs of a file. ok is false when a module
+// could not be read.
+func cacheKey(inputs []moduleInput) (key []domain.CachedModule, ok bool) {
+	key = make([]domain.CachedModule, 0, len(inputs))
+	for _, in := range inputs {
+		if in.err != nil {
+			return nil, false
+		}
+		key = append(key, domain.CachedModule{Name: in.module.Name, Hash: domain.HashContent(in.content)})
+	}
+	return key, true
+}
+
+// readExisting returns the content of a previously built file.
+func (s *BuilderService) readExisting(filePath string) (string, bool) {
+	if !s.fileReader.FileExists(filePath) {
+		return "", false
+	}
+	content, err := s.fileReader.ReadFile(filePath)
+	if err != nil {
+		return "", false
+	}
+	return content, true
 }
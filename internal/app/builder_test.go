@@ -1,12 +1,18 @@
 package app
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/filesystem"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/yamlparser"
 )
@@ -128,7 +134,7 @@ func TestBuilderService_Build(t *testing.T) {
 			},
 		},
 		{
-			name: "missing module file shows placeholder",
+			name: "missing module file shows placeholder with AllowMissing",
 			setup: func(fs afero.Fs) {
 				manifest := `modules:
   - name: missing
@@ -139,10 +145,11 @@ func TestBuilderService_Build(t *testing.T) {
 				// Don't create missing.sh
 			},
 			opts: BuildOptions{
-				ConfigDir: ".",
-				Manifest:  "manifest.yaml",
-				OutputDir: "./build",
-				OS:        "Mac",
+				ConfigDir:    ".",
+				Manifest:     "manifest.yaml",
+				OutputDir:    "./build",
+				OS:           "Mac",
+				AllowMissing: true,
 			},
 			wantErr: false,
 			validate: func(t *testing.T, result *BuildResult, fs afero.Fs) {
@@ -150,6 +157,9 @@ func TestBuilderService_Build(t *testing.T) {
 				require.Len(t, result.Targets, 1)
 				assert.Contains(t, result.Targets[0].Content, "FILE NOT FOUND")
 				assert.Contains(t, result.Targets[0].Content, "missing")
+				require.Len(t, result.Errors, 1)
+				assert.Equal(t, "missing", result.Errors[0].Module)
+				assert.ErrorIs(t, result.Errors[0], ErrModuleFileNotFound)
 			},
 		},
 		{
@@ -251,6 +261,327 @@ func TestBuilderService_Build(t *testing.T) {
 	}
 }
 
+func TestBuilderService_Build_Reproducible(t *testing.T) {
+	manifest := `modules:
+  - name: zeta
+    file: zeta.sh
+  - name: base
+    file: base.sh
+  - name: alpha
+    file: alpha.sh
+    requires: [base]
+  - name: env
+    file: env.sh
+    target: zshenv
+  - name: beta
+    file: beta.sh
+    requires: [base]
+`
+	fixed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	build := func() (*BuildResult, afero.Fs) {
+		fs := afero.NewMemMapFs()
+		afero.WriteFile(fs, "manifest.yaml", []byte(manifest), 0o644)
+		for _, name := range []string{"zeta", "base", "alpha", "env", "beta"} {
+			afero.WriteFile(fs, name+".sh", []byte("echo "+name), 0o644)
+		}
+
+		builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+		result, err := builder.Build(BuildOptions{
+			ConfigDir: ".",
+			Manifest:  "manifest.yaml",
+			OutputDir: "./build",
+			OS:        "Mac",
+			BuildTime: fixed,
+		})
+		require.NoError(t, err)
+		return result, fs
+	}
+
+	first, firstFs := build()
+	assert.Equal(t, fixed, first.GeneratedAt)
+	require.Len(t, first.Targets, 2)
+	assert.Contains(t, first.Targets[0].Content, "# Generated at: 2024-01-01T00:00:00Z")
+
+	firstMeta, err := afero.ReadFile(firstFs, "build/.shellforge-build.json")
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		again, againFs := build()
+		require.Len(t, again.Targets, len(first.Targets))
+		for j := range first.Targets {
+			assert.Equal(t, first.Targets[j].Content, again.Targets[j].Content, "run %d, target %s", i, first.Targets[j].Target)
+			assert.Equal(t, first.Targets[j].ModuleNames, again.Targets[j].ModuleNames)
+		}
+
+		meta, err := afero.ReadFile(againFs, "build/.shellforge-build.json")
+		require.NoError(t, err)
+		assert.Equal(t, string(firstMeta), string(meta))
+	}
+
+	// Unordered modules keep manifest order
+	for _, target := range first.Targets {
+		if target.Target == "zshrc" {
+			assert.Equal(t, []string{"zeta", "base", "alpha", "beta"}, target.ModuleNames)
+		}
+	}
+}
+
+func TestBuilderService_Build_Profiles(t *testing.T) {
+	manifest := `profiles:
+  work:
+    hosts: ["*-corp-*"]
+    include_tags: [work]
+  minimal:
+    exclude_tags: [heavy]
+modules:
+  - name: path
+    file: path.sh
+  - name: proxy
+    file: proxy.sh
+    tags: [work]
+    requires: [path]
+  - name: nvm
+    file: nvm.sh
+    tags: [heavy]
+`
+	newBuilder := func() *BuilderService {
+		fs := afero.NewMemMapFs()
+		afero.WriteFile(fs, "manifest.yaml", []byte(manifest), 0o644)
+		for _, name := range []string{"path", "proxy", "nvm"} {
+			afero.WriteFile(fs, name+".sh", []byte("echo "+name), 0o644)
+		}
+		return NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	}
+
+	tests := []struct {
+		name        string
+		profile     string
+		hostname    string
+		tags        domain.ModuleSelector
+		wantProfile string
+		wantModules []string
+		errMsg      string
+	}{
+		{name: "no profile", hostname: "desktop", wantModules: []string{"path", "proxy", "nvm"}},
+		{name: "explicit profile", profile: "minimal", wantProfile: "minimal", wantModules: []string{"path", "proxy"}},
+		{name: "auto-selected by hostname", hostname: "jane-corp-mbp", wantProfile: "work", wantModules: []string{"path", "proxy"}},
+		{name: "unknown profile", profile: "ghost", errMsg: "unknown profile 'ghost'"},
+		{name: "tags pull in requirements", tags: domain.ModuleSelector{IncludeTags: []string{"work"}}, wantModules: []string{"path", "proxy"}},
+		{name: "tags narrow a profile", profile: "minimal", tags: domain.ModuleSelector{ExcludeTags: []string{"work"}}, wantProfile: "minimal", wantModules: []string{"path"}},
+		{name: "excluded requirement", tags: domain.ModuleSelector{IncludeTags: []string{"work"}, Exclude: []string{"path"}}, errMsg: "module 'proxy' requires 'path', which is excluded by the selection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newBuilder().Build(BuildOptions{
+				ConfigDir: ".",
+				Manifest:  "manifest.yaml",
+				OS:        "Linux",
+				DryRun:    true,
+				Profile:   tt.profile,
+				Hostname:  tt.hostname,
+				Tags:      tt.tags,
+			})
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantProfile, result.Profile)
+			require.Len(t, result.Targets, 1)
+			assert.Equal(t, tt.wantModules, result.Targets[0].ModuleNames)
+			if tt.wantProfile != "" {
+				assert.Contains(t, result.Targets[0].Content, "# Profile: "+tt.wantProfile)
+			}
+		})
+	}
+}
+
+func TestBuilderService_Build_Vars(t *testing.T) {
+	manifest := `vars:
+  brew_flavor: local
+  proxy: ""
+os_vars:
+  Mac:
+    brew_flavor: homebrew
+profiles:
+  work:
+    vars:
+      proxy: http://proxy.corp:3128
+modules:
+  - name: brew
+    file: "brew/{{ .Vars.brew_flavor }}.sh"
+  - name: proxy
+    file: proxy.sh
+    interpolate: true
+  - name: raw
+    file: raw.sh
+`
+	newBuilder := func() *BuilderService {
+		fs := afero.NewMemMapFs()
+		afero.WriteFile(fs, "manifest.yaml", []byte(manifest), 0o644)
+		afero.WriteFile(fs, "brew/homebrew.sh", []byte("echo mac"), 0o644)
+		afero.WriteFile(fs, "brew/local.sh", []byte("echo linux"), 0o644)
+		afero.WriteFile(fs, "proxy.sh", []byte(`export HTTP_PROXY="{{ .Vars.proxy }}"`), 0o644)
+		afero.WriteFile(fs, "raw.sh", []byte(`echo "{{ .Vars.proxy }}"`), 0o644)
+		return NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	}
+
+	tests := []struct {
+		name    string
+		os      string
+		profile string
+		vars    map[string]string
+		want    []string
+	}{
+		{name: "base vars", os: "Linux", want: []string{"echo linux", `export HTTP_PROXY=""`}},
+		{name: "os_vars", os: "Mac", want: []string{"echo mac"}},
+		{name: "profile vars", os: "Linux", profile: "work", want: []string{`export HTTP_PROXY="http://proxy.corp:3128"`}},
+		{name: "cli override", os: "Linux", profile: "work", vars: map[string]string{"proxy": "http://cli:1"}, want: []string{`export HTTP_PROXY="http://cli:1"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newBuilder().Build(BuildOptions{
+				ConfigDir: ".",
+				Manifest:  "manifest.yaml",
+				OS:        tt.os,
+				DryRun:    true,
+				Profile:   tt.profile,
+				Vars:      tt.vars,
+			})
+			require.NoError(t, err)
+			require.Len(t, result.Targets, 1)
+			content := result.Targets[0].Content
+			for _, want := range tt.want {
+				assert.Contains(t, content, want)
+			}
+			// Modules without 'interpolate: true' are copied verbatim
+			assert.Contains(t, content, `echo "{{ .Vars.proxy }}"`)
+		})
+	}
+}
+
+func TestBuilderService_Build_UndefinedVar(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`modules:
+  - name: brew
+    file: "{{ .Vars.brew }}/env.sh"
+`), 0o644)
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+
+	_, err := builder.Build(BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OS: "Linux", DryRun: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to expand variables")
+	assert.Contains(t, err.Error(), "module 'brew' file")
+}
+
+func TestBuilderService_Build_OSVarsOfExcludedModules(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`os_vars:
+  Mac:
+    brew: opt/homebrew
+modules:
+  - name: brew
+    file: "{{ .Vars.brew }}.sh"
+    os: [Mac]
+    path:
+      - prepend: ["{{ .Vars.brew }}/bin"]
+  - name: git
+    file: git.sh
+`), 0o644)
+	afero.WriteFile(fs, "git.sh", []byte("alias g=git"), 0o644)
+	afero.WriteFile(fs, "opt/homebrew.sh", []byte("export HOMEBREW=1"), 0o644)
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+
+	// brew is only defined for Mac, where the module is built
+	result, err := builder.Build(BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OS: "Linux", DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git"}, result.Targets[0].ModuleNames)
+
+	result, err = builder.Build(BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OS: "Mac", DryRun: true})
+	require.NoError(t, err)
+	assert.Contains(t, result.Targets[0].Content, "export HOMEBREW=1")
+	assert.Contains(t, result.Targets[0].Content, `__shellforge_path prepend "opt/homebrew/bin"`)
+}
+
+func TestBuilderService_Build_BrokenModules(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+	}{
+		{
+			name: "single-file target",
+			manifest: `modules:
+  - name: present
+    file: present.sh
+  - name: gone
+    file: gone.sh
+  - name: broken
+    file: broken.sh
+    interpolate: true
+`,
+		},
+		{
+			name: "directory target",
+			manifest: `shell:
+  type: fish
+modules:
+  - name: present
+    file: present.fish
+    target: conf.d
+  - name: gone
+    file: gone.fish
+    target: conf.d
+  - name: broken
+    file: broken.fish
+    target: conf.d
+    interpolate: true
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afero.WriteFile(fs, "manifest.yaml", []byte(tt.manifest), 0o644)
+			for _, ext := range []string{".sh", ".fish"} {
+				afero.WriteFile(fs, "present"+ext, []byte("echo present"), 0o644)
+				afero.WriteFile(fs, "broken"+ext, []byte("echo {{ .Vars.nope }}"), 0o644)
+			}
+			builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+			opts := BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OutputDir: "build", OS: "Linux"}
+
+			// Strict by default: every broken module is reported, nothing is written
+			_, err := builder.Build(opts)
+			var modErrs ModuleErrors
+			require.ErrorAs(t, err, &modErrs)
+			require.Len(t, modErrs, 2)
+			assert.Equal(t, "gone", modErrs[0].Module)
+			assert.ErrorIs(t, modErrs[0], ErrModuleFileNotFound)
+			assert.Equal(t, "broken", modErrs[1].Module)
+			assert.Contains(t, err.Error(), "2 module(s) could not be included")
+			exists, _ := afero.DirExists(fs, "build")
+			assert.False(t, exists, "no output should be written")
+
+			// AllowMissing builds the rest and reports what was skipped
+			opts.AllowMissing = true
+			result, err := builder.Build(opts)
+			require.NoError(t, err)
+			assert.Len(t, result.Errors, 2)
+			var all string
+			for _, target := range result.Targets {
+				all += target.Content
+			}
+			assert.Contains(t, all, "echo present")
+			assert.Contains(t, all, "FILE NOT FOUND")
+			assert.Contains(t, all, "# --- broken --- (ERROR:")
+		})
+	}
+}
+
 // TestBuilderService_Build_RealExample tests with actual example files
 func TestBuilderService_Build_RealExample(t *testing.T) {
 	// Test with real filesystem
@@ -303,3 +634,633 @@ func TestBuilderService_Build_RealExample(t *testing.T) {
 		assert.Equal(t, target.Content, string(content))
 	}
 }
+
+// countingWriter records the paths written through it.
+type countingWriter struct {
+	FileWriter
+	written []string
+}
+
+func (w *countingWriter) WriteFile(path, content string) error {
+	w.written = append(w.written, path)
+	return w.FileWriter.WriteFile(path, content)
+}
+
+func TestBuilderService_Build_Incremental(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`modules:
+  - name: a
+    file: a.sh
+  - name: env
+    file: env.sh
+    target: zshenv
+`), 0o644)
+	afero.WriteFile(fs, "a.sh", []byte("echo a"), 0o644)
+	afero.WriteFile(fs, "env.sh", []byte("export A=1"), 0o644)
+
+	writer := &countingWriter{FileWriter: filesystem.NewWriter(fs)}
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), writer)
+	build := func(at time.Time, noCache bool) *BuildResult {
+		writer.written = nil
+		result, err := builder.Build(BuildOptions{
+			ConfigDir: ".",
+			Manifest:  "manifest.yaml",
+			OutputDir: "build",
+			OS:        "Mac",
+			BuildTime: at,
+			NoCache:   noCache,
+		})
+		require.NoError(t, err)
+		return result
+	}
+	contentOf := func(name string) string {
+		data, err := afero.ReadFile(fs, "build/"+name)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	first := build(time.Time{}, false)
+	assert.Len(t, first.Changed(), 2)
+	assert.Empty(t, first.Unchanged())
+	exists, _ := afero.Exists(fs, "build/"+domain.CacheFileName)
+	assert.True(t, exists)
+	zshrc := contentOf(".zshrc")
+
+	// Nothing changed: both files are reused as they are
+	second := build(time.Time{}, false)
+	assert.Empty(t, second.Changed())
+	assert.Len(t, second.Unchanged(), 2)
+	assert.NotContains(t, writer.written, "build/.zshrc")
+	assert.NotContains(t, writer.written, "build/.zshenv")
+	assert.Equal(t, zshrc, contentOf(".zshrc"))
+	assert.Equal(t, zshrc, second.Targets[1].Content)
+	assert.Equal(t, first.Targets[1].Sources, second.Targets[1].Sources)
+
+	// Only the target whose module changed is rebuilt
+	afero.WriteFile(fs, "env.sh", []byte("export A=2"), 0o644)
+	third := build(time.Time{}, false)
+	require.Len(t, third.Changed(), 1)
+	assert.Equal(t, "zshenv", third.Changed()[0].Target)
+	assert.Contains(t, contentOf(".zshenv"), "export A=2")
+	assert.Contains(t, writer.written, "build/.zshenv")
+	assert.NotContains(t, writer.written, "build/.zshrc")
+
+	// An edited output file is regenerated
+	afero.WriteFile(fs, "build/.zshrc", []byte("tampered"), 0o644)
+	fourth := build(time.Time{}, false)
+	assert.Len(t, fourth.Changed(), 1)
+	assert.NotEqual(t, "tampered", contentOf(".zshrc"))
+
+	// Without the cache, identical content is still not rewritten
+	fixed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	build(fixed, true)
+	fifth := build(fixed, true)
+	assert.Empty(t, fifth.Changed())
+	assert.NotContains(t, writer.written, "build/.zshrc")
+
+	// A manifest change invalidates the whole cache
+	afero.WriteFile(fs, "manifest.yaml", []byte(`modules:
+  - name: a
+    file: a.sh
+    description: changed
+  - name: env
+    file: env.sh
+    target: zshenv
+`), 0o644)
+	sixth := build(time.Time{}, false)
+	assert.Len(t, sixth.Changed(), 2)
+}
+
+func TestBuilderService_Build_Isolate(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`defaults:
+  isolate: true
+modules:
+  - name: broken
+    file: broken.sh
+    target: bashrc
+  - name: plain
+    file: plain.sh
+    target: bashrc
+    isolate: false
+  - name: after
+    file: after.sh
+    target: bashrc
+`), 0o644)
+	afero.WriteFile(fs, "broken.sh", []byte("source /nonexistent/shellforge-test\nBROKEN_RAN=1\n"), 0o644)
+	afero.WriteFile(fs, "plain.sh", []byte("PLAIN=1\n"), 0o644)
+	afero.WriteFile(fs, "after.sh", []byte("export AFTER=1\n"), 0o644)
+
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	result, err := builder.Build(BuildOptions{
+		ConfigDir: ".",
+		Manifest:  "manifest.yaml",
+		OS:        "Linux",
+		Shell:     "bash",
+		DryRun:    true,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Targets, 1)
+	target := result.Targets[0]
+
+	assert.Contains(t, target.Content, "__shellforge_module_broken() {")
+	assert.Contains(t, target.Content, "__shellforge_module_after() {")
+	assert.NotContains(t, target.Content, "__shellforge_module_plain")
+
+	// Source maps point at the module body, not the guard
+	lines := strings.Split(target.Content, "\n")
+	for _, span := range target.Sources {
+		first := lines[span.StartLine-1]
+		switch span.Module {
+		case "broken":
+			assert.Equal(t, "source /nonexistent/shellforge-test", first)
+		case "plain":
+			assert.Equal(t, "PLAIN=1", first)
+		case "after":
+			assert.Equal(t, "export AFTER=1", first)
+		}
+	}
+
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	cmd := exec.Command("bash", "--norc", "--noprofile", "-c", target.Content+"\necho \"ran=$BROKEN_RAN after=$AFTER plain=$PLAIN\"")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, "ran=1 after=1 plain=1\n", string(out))
+	assert.Contains(t, stderr.String(), "shellforge: module broken failed (status 1)")
+	assert.NotContains(t, stderr.String(), "module after failed")
+
+	// An ERR trap set before the modules survives them and does not fire
+	// for the isolated failure
+	cmd = exec.Command("bash", "--norc", "--noprofile", "-c", "trap 'echo user trap' ERR\n"+target.Content+"\ntrap -p ERR\nfalse")
+	out, err = cmd.Output()
+	require.Error(t, err)
+	assert.Equal(t, "trap -- 'echo user trap' ERR\nuser trap\n", string(out))
+}
+
+func TestBuilderService_Build_Lazy(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`modules:
+  - name: fakenvm
+    file: fakenvm.sh
+    target: bashrc
+    lazy: [fakenvm, fakenode]
+`), 0o644)
+	afero.WriteFile(fs, "fakenvm.sh", []byte(`echo loading >&2
+fakenvm() { echo "fakenvm $*"; }
+fakenode() { echo "fakenode $*"; }
+`), 0o644)
+
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	result, err := builder.Build(BuildOptions{
+		ConfigDir: ".",
+		Manifest:  "manifest.yaml",
+		OS:        "Linux",
+		Shell:     "bash",
+		DryRun:    true,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Targets, 1)
+	target := result.Targets[0]
+
+	assert.Contains(t, target.Content, "# Lazy: loaded on first use of fakenvm, fakenode")
+	assert.Contains(t, target.Content, `fakenode() { __shellforge_lazy_fakenvm; fakenode "$@"; }`)
+
+	// Source maps point at the module body, not the loader
+	lines := strings.Split(target.Content, "\n")
+	require.Len(t, target.Sources, 1)
+	assert.Equal(t, "echo loading >&2", lines[target.Sources[0].StartLine-1])
+
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	cmd := exec.Command("bash", "--norc", "--noprofile", "-c",
+		target.Content+"\necho startup\nfakenode a b\nfakenvm c\nfakenode d")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, "startup\nfakenode a b\nfakenvm c\nfakenode d\n", string(out))
+	assert.Equal(t, "loading\n", stderr.String(), "body loads once, on first use")
+}
+
+func TestBuilderService_Build_DependencyBeatsPriority(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`modules:
+  - name: path
+    file: path.sh
+    priority: 60
+  - name: aliases
+    file: aliases.sh
+    priority: 20
+  - name: prompt
+    file: prompt.sh
+    priority: 10
+    requires: [path]
+`), 0o644)
+	for _, name := range []string{"path", "aliases", "prompt"} {
+		afero.WriteFile(fs, name+".sh", []byte("# body of "+name), 0o644)
+	}
+
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	result, err := builder.Build(BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OutputDir: "build", OS: "Linux", DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, result.Targets, 1)
+
+	// prompt's priority 10 would put it first; its dependency on path wins
+	content := result.Targets[0].Content
+	aliases := strings.Index(content, "# body of aliases")
+	path := strings.Index(content, "# body of path")
+	prompt := strings.Index(content, "# body of prompt")
+	assert.True(t, aliases < path && path < prompt, content)
+}
+
+func TestBuilderService_Build_InlineModules(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	manifest := `modules:
+  - name: editor
+    target: bashrc
+    content: |
+      export EDITOR=vim
+      export PAGER=less
+  - name: tools
+    target: bashrc
+    generate:
+      env: {GOPATH: ~/go}
+      path: [~/bin]
+      aliases: {ll: "echo listing"}
+`
+	afero.WriteFile(fs, "manifest.yaml", []byte(manifest), 0o644)
+
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	opts := BuildOptions{ConfigDir: "modules", Manifest: "manifest.yaml", OutputDir: "build", OS: "Linux", Shell: "bash"}
+	result, err := builder.Build(opts)
+	require.NoError(t, err)
+	require.Len(t, result.Targets, 1)
+
+	content := result.Targets[0].Content
+	assert.Contains(t, content, "export EDITOR=vim\nexport PAGER=less\n")
+	assert.Contains(t, content, `export PATH="$HOME/bin:$PATH"`)
+
+	// Source map spans point into the manifest
+	sources := result.Targets[0].Sources
+	require.Len(t, sources, 2)
+	assert.Equal(t, domain.SourceSpan{Module: "editor", File: "manifest.yaml", StartLine: sources[0].StartLine, Lines: 2, SourceLine: 5, Inline: true}, sources[0])
+	assert.Equal(t, "manifest.yaml", sources[1].File)
+	assert.Equal(t, 10, sources[1].SourceLine)
+	assert.Equal(t, "export EDITOR=vim", strings.Split(content, "\n")[sources[0].StartLine-1])
+
+	if _, err := exec.LookPath("bash"); err == nil {
+		home := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(home, ".bashrc"), []byte(content), 0o644))
+		cmd := exec.Command("bash", "--norc", "--noprofile", "-O", "expand_aliases", "-c", ". \"$HOME/.bashrc\"\necho \"$EDITOR $GOPATH ${PATH%%:*}\"\nll")
+		cmd.Env = []string{"HOME=" + home, "PATH=" + os.Getenv("PATH")}
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		assert.Equal(t, "vim "+home+"/go "+home+"/bin\nlisting\n", string(out))
+	}
+
+	// Unchanged inline modules reuse the cached file; edited ones rebuild it
+	result, err = builder.Build(opts)
+	require.NoError(t, err)
+	assert.Len(t, result.Unchanged(), 1)
+
+	afero.WriteFile(fs, "manifest.yaml", []byte(strings.Replace(manifest, "EDITOR=vim", "EDITOR=nano", 1)), 0o644)
+	result, err = builder.Build(opts)
+	require.NoError(t, err)
+	require.Len(t, result.Changed(), 1)
+	assert.Contains(t, result.Targets[0].Content, "export EDITOR=nano")
+}
+
+func TestBuilderService_Build_PathBlock(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	manifest := `modules:
+  - name: local-bin
+    path:
+      - prepend: [~/bin, ~/missing]
+        if_exists: true
+  - name: tools
+    target: bashrc
+    content: export TOOLS=1
+    path:
+      - prepend: [/opt/tools/bin]
+      - append: [/usr/games]
+      - prepend: [/opt/homebrew/bin]
+        os: [Mac]
+`
+	afero.WriteFile(fs, "manifest.yaml", []byte(manifest), 0o644)
+
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	opts := BuildOptions{ConfigDir: "modules", Manifest: "manifest.yaml", OutputDir: "build", OS: "Linux", Shell: "bash"}
+	result, err := builder.Build(opts)
+	require.NoError(t, err, "a path-only module needs no valid target")
+	require.Len(t, result.Targets, 1)
+	assert.Equal(t, []string{"tools"}, result.Targets[0].ModuleNames)
+
+	content := result.Targets[0].Content
+	assert.Less(t, strings.Index(content, "export PATH"), strings.Index(content, "# --- tools ---"))
+	assert.Contains(t, content, `__shellforge_path prepend "$HOME/missing" if_exists`)
+	assert.Contains(t, content, `__shellforge_path append "/usr/games"`)
+	assert.NotContains(t, content, "homebrew")
+
+	// The source map still points at the module body
+	lines := strings.Split(content, "\n")
+	require.Len(t, result.Targets[0].Sources, 1)
+	assert.Equal(t, "export TOOLS=1", lines[result.Targets[0].Sources[0].StartLine-1])
+
+	if _, err := exec.LookPath("bash"); err == nil {
+		home := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(home, "bin"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(home, ".bashrc"), []byte(content), 0o644))
+		// Sourcing twice must not duplicate entries
+		cmd := exec.Command("bash", "--norc", "--noprofile", "-c", ". \"$HOME/.bashrc\"\n. \"$HOME/.bashrc\"\necho \"$PATH\"")
+		cmd.Env = []string{"HOME=" + home, "PATH=/usr/bin:" + home + "/bin:/bin"}
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		assert.Equal(t, "/opt/tools/bin:"+home+"/bin:/usr/bin:/bin:/usr/games\n", string(out))
+	}
+
+	// output.path_target moves the block to another file
+	afero.WriteFile(fs, "manifest.yaml", []byte("output:\n  path_target: bash_profile\n"+manifest), 0o644)
+	result, err = builder.Build(opts)
+	require.NoError(t, err)
+	require.Len(t, result.Targets, 2)
+	assert.Equal(t, "bash_profile", result.Targets[0].Target)
+	assert.Contains(t, result.Targets[0].Content, "export PATH")
+	assert.NotContains(t, result.Targets[1].Content, "export PATH")
+
+	afero.WriteFile(fs, "manifest.yaml", []byte("output:\n  path_target: bashrc.d\n"+manifest), 0o644)
+	_, err = builder.Build(opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PATH block target 'bashrc.d' is not a shell file target")
+}
+
+func TestBuilderService_Build_PortableModules(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	manifest := `modules:
+  - name: env
+    generate:
+      env: {EDITOR: vim}
+      aliases: {ll: "echo listing"}
+  - name: cargo
+    generate:
+      if: {dir: [~/.cargo]}
+      env: {CARGO_HOME: ~/.cargo}
+    path:
+      - prepend: [~/.cargo/bin]
+  - name: direnv
+    generate:
+      if: {command: [shellforge-no-such-command]}
+      env: {DIRENV: "on"}
+`
+	afero.WriteFile(fs, "manifest.yaml", []byte(manifest), 0o644)
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+
+	// Modules without a target go to each shell's main rc file
+	contents := make(map[string]string)
+	for shell, target := range map[string]string{"zsh": "zshrc", "bash": "bashrc", "fish": "config"} {
+		result, err := builder.Build(BuildOptions{Manifest: "manifest.yaml", OutputDir: "build-" + shell, OS: "Linux", Shell: shell})
+		require.NoError(t, err, shell)
+		require.Len(t, result.Targets, 1, shell)
+		assert.Equal(t, target, result.Targets[0].Target)
+		contents[shell] = result.Targets[0].Content
+	}
+	assert.Contains(t, contents["zsh"], `export EDITOR="vim"`)
+	assert.Contains(t, contents["fish"], `set -gx EDITOR "vim"`)
+	assert.Contains(t, contents["fish"], "if test -d \"$HOME/.cargo\"\n    set -gx CARGO_HOME \"$HOME/.cargo\"\nend")
+	assert.Contains(t, contents["fish"], `fish_add_path --move --path "$HOME/.cargo/bin"`)
+	assert.NotContains(t, contents["fish"], "export ")
+
+	if _, err := exec.LookPath("bash"); err == nil {
+		home := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(home, ".cargo"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(home, ".bashrc"), []byte(contents["bash"]), 0o644))
+		cmd := exec.Command("bash", "--norc", "--noprofile", "-O", "expand_aliases", "-c", ". \"$HOME/.bashrc\"\necho \"$EDITOR $CARGO_HOME ${DIRENV:-off}\"\nll")
+		cmd.Env = []string{"HOME=" + home, "PATH=" + os.Getenv("PATH")}
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		assert.Equal(t, "vim "+home+"/.cargo off\nlisting\n", string(out))
+	}
+
+	// Files and sourced scripts written for another shell, or not known to
+	// be fish scripts, and POSIX-only expansions are build errors for fish
+	afero.WriteFile(fs, "git.sh", []byte("alias g=git"), 0o644)
+	afero.WriteFile(fs, "aliases", []byte("alias l=ls"), 0o644)
+	afero.WriteFile(fs, "abbr", []byte("abbr -a g git"), 0o644)
+	afero.WriteFile(fs, "manifest.yaml", []byte(manifest+`  - name: git
+    file: git.sh
+  - name: fzf
+    generate:
+      source: [~/.fzf.zsh]
+  - name: aliases
+    file: aliases
+  - name: go
+    generate:
+      env: {GOBIN: "${GOPATH:-$HOME/go}/bin"}
+  - name: abbr
+    file: abbr
+    when: {shell: [fish]}
+`), 0o644)
+	_, err := builder.Build(BuildOptions{Manifest: "manifest.yaml", OutputDir: "build-fish", OS: "Linux", Shell: "fish"})
+	var modErrs ModuleErrors
+	require.ErrorAs(t, err, &modErrs)
+	require.Len(t, modErrs, 4)
+	assert.Contains(t, modErrs[0].Error(), "module 'git' (git.sh): 'git.sh' is a sh script, which fish cannot load")
+	assert.Contains(t, modErrs[1].Error(), "'~/.fzf.zsh' is a zsh script, which fish cannot load")
+	assert.Contains(t, modErrs[2].Error(), "module 'aliases' (aliases): 'aliases' has no .fish extension")
+	assert.Contains(t, modErrs[3].Error(), "module 'go' (manifest.yaml:25): generate: env 'GOBIN' '${GOPATH:-$HOME/go}/bin' uses '${', which fish does not support")
+
+	// The same modules build for bash, except the fish-only one
+	result, err := builder.Build(BuildOptions{Manifest: "manifest.yaml", OutputDir: "build-bash", OS: "Linux", Shell: "bash", AllowMissing: true})
+	require.NoError(t, err)
+	assert.Len(t, result.Errors, 1, "only the zsh script is rejected")
+	assert.NotContains(t, result.Targets[0].Content, "abbr -a")
+}
+
+func TestBuilderService_Build_DirectoryTarget(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	manifest := `output:
+  dir_loader: true
+modules:
+  - name: path
+    file: path.sh
+    target: bashrc.d
+    priority: 10
+  - name: git
+    file: git.sh
+    target: bashrc.d
+    priority: 20
+  - name: prompt
+    file: prompt.sh
+    target: bashrc.d
+    priority: 30
+`
+	afero.WriteFile(fs, "manifest.yaml", []byte(manifest), 0o644)
+	afero.WriteFile(fs, "path.sh", []byte(`ORDER="${ORDER}path "`), 0o644)
+	afero.WriteFile(fs, "git.sh", []byte(`ORDER="${ORDER}git "`), 0o644)
+	afero.WriteFile(fs, "prompt.sh", []byte(`ORDER="${ORDER}prompt "`), 0o644)
+	afero.WriteFile(fs, "build/.bashrc.d/notes.sh", []byte("# my own file\n"), 0o644)
+
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	opts := BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OutputDir: "build", OS: "Linux", Shell: "bash"}
+	result, err := builder.Build(opts)
+	require.NoError(t, err)
+
+	var files []string
+	for _, target := range result.Targets {
+		files = append(files, target.FilePath)
+	}
+	assert.Equal(t, []string{
+		filepath.Join("build", ".bashrc"),
+		filepath.Join("build", ".bashrc.d", "01-path.sh"),
+		filepath.Join("build", ".bashrc.d", "02-git.sh"),
+		filepath.Join("build", ".bashrc.d", "03-prompt.sh"),
+	}, files)
+	assert.Contains(t, result.Targets[0].Content, `for __shellforge_file in "$HOME/.bashrc.d"/*.sh; do`)
+	assert.Equal(t, 0, result.Targets[0].ModuleCount)
+
+	metaJSON, err := afero.ReadFile(fs, filepath.Join("build", domain.MetadataFileName))
+	require.NoError(t, err)
+	metadata, err := domain.ParseBuildMetadata(metaJSON)
+	require.NoError(t, err)
+	require.Len(t, metadata.Files, 4)
+	assert.Equal(t, filepath.Join(".bashrc.d", "02-git.sh"), metadata.Files[2].Source)
+	assert.Equal(t, filepath.Join(".bashrc.d", "02-git.sh"), metadata.Files[2].DestPath)
+
+	// The loader sources the files in lexical order, which is resolved order
+	if _, err := exec.LookPath("bash"); err == nil {
+		home := t.TempDir()
+		for _, target := range result.Targets {
+			dest := filepath.Join(home, strings.TrimPrefix(target.FilePath, "build"))
+			require.NoError(t, os.MkdirAll(filepath.Dir(dest), 0o755))
+			require.NoError(t, os.WriteFile(dest, []byte(target.Content), 0o644))
+		}
+		cmd := exec.Command("bash", "--norc", "--noprofile", "-c", `. "$HOME/.bashrc"; echo "$ORDER"`)
+		cmd.Env = []string{"HOME=" + home, "PATH=" + os.Getenv("PATH")}
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		assert.Equal(t, "path git prompt \n", string(out))
+	}
+
+	// Dropping a module renumbers the rest; the old files are removed, files
+	// shellforge did not generate are kept
+	afero.WriteFile(fs, "manifest.yaml", []byte(strings.Replace(manifest, "  - name: path\n    file: path.sh\n    target: bashrc.d\n    priority: 10\n", "", 1)), 0o644)
+	result, err = builder.Build(opts)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join("build", ".bashrc.d", "01-path.sh"),
+		filepath.Join("build", ".bashrc.d", "02-git.sh"),
+		filepath.Join("build", ".bashrc.d", "03-prompt.sh"),
+	}, result.Removed)
+
+	entries, err := afero.ReadDir(fs, filepath.Join("build", ".bashrc.d"))
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"01-git.sh", "02-prompt.sh", "notes.sh"}, names)
+}
+
+func TestBuilderService_Build_CustomTargets(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	manifest := `targets:
+  env:
+    path: ~/.config/shell/env.sh
+    sourced_by: bashrc
+  snippets:
+    path: .config/shell/bash.d
+    kind: directory
+    extension: bash
+    sourced_by: env
+  inputrc:
+    path: .inputrc.d/shellforge
+    comment: "$"
+modules:
+  - name: editor
+    file: editor.sh
+    target: env
+  - name: prompt
+    file: prompt.sh
+    target: snippets
+  - name: readline
+    file: readline.inputrc
+    target: inputrc
+`
+	afero.WriteFile(fs, "manifest.yaml", []byte(manifest), 0o644)
+	afero.WriteFile(fs, "editor.sh", []byte(`ORDER="${ORDER}editor "`), 0o644)
+	afero.WriteFile(fs, "prompt.sh", []byte(`ORDER="${ORDER}prompt "`), 0o644)
+	afero.WriteFile(fs, "readline.inputrc", []byte("set bell-style none"), 0o644)
+
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	opts := BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OutputDir: "build", OS: "Linux", Shell: "bash", ProfileStartup: true}
+	result, err := builder.Build(opts)
+	require.NoError(t, err)
+
+	contents := make(map[string]string)
+	for _, target := range result.Targets {
+		contents[target.FilePath] = target.Content
+	}
+	bashrc := contents[filepath.Join("build", ".bashrc")]
+	assert.Contains(t, bashrc, `[ -r "$HOME/.config/shell/env.sh" ] && . "$HOME/.config/shell/env.sh"`)
+	env := contents[filepath.Join("build", ".config", "shell", "env.sh")]
+	assert.Contains(t, env, `for __shellforge_file in "$HOME/.config/shell/bash.d"/*.bash; do`)
+	assert.Contains(t, contents[filepath.Join("build", ".config", "shell", "bash.d", "01-prompt.bash")], "prompt")
+
+	// Files no shell sources get the target's comment style and no probes
+	inputrc := contents[filepath.Join("build", ".inputrc.d", "shellforge")]
+	assert.True(t, strings.HasPrefix(inputrc, "$ Generated by shellforge\n"), inputrc)
+	assert.Contains(t, inputrc, "set bell-style none")
+	assert.NotContains(t, inputrc, "__shellforge_")
+	assert.Contains(t, env, "__shellforge_")
+
+	metaJSON, err := afero.ReadFile(fs, filepath.Join("build", domain.MetadataFileName))
+	require.NoError(t, err)
+	metadata, err := domain.ParseBuildMetadata(metaJSON)
+	require.NoError(t, err)
+	dests := make(map[string]domain.BuildFileInfo)
+	for _, f := range metadata.Files {
+		dests[f.DestPath] = f
+	}
+	require.Contains(t, dests, filepath.Join(".config", "shell", "env.sh"))
+	assert.Equal(t, filepath.Join(".config", "shell", "env.sh"), dests[filepath.Join(".config", "shell", "env.sh")].Source)
+	require.Contains(t, dests, filepath.Join(".config", "shell", "bash.d", "01-prompt.bash"))
+	assert.True(t, dests[filepath.Join(".config", "shell", "bash.d", "01-prompt.bash")].Directory)
+	assert.False(t, dests[filepath.Join(".inputrc.d", "shellforge")].Directory)
+
+	if _, err := exec.LookPath("bash"); err == nil {
+		home := t.TempDir()
+		for _, target := range result.Targets {
+			dest := filepath.Join(home, strings.TrimPrefix(target.FilePath, "build"))
+			require.NoError(t, os.MkdirAll(filepath.Dir(dest), 0o755))
+			require.NoError(t, os.WriteFile(dest, []byte(target.Content), 0o644))
+		}
+		cmd := exec.Command("bash", "--norc", "--noprofile", "-c", `. "$HOME/.bashrc"; echo "$ORDER"`)
+		cmd.Env = []string{"HOME=" + home, "PATH=" + os.Getenv("PATH")}
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		assert.Equal(t, "editor prompt \n", string(out))
+	}
+}
+
+func TestBuilderService_Build_CustomTargetErrors(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`targets:
+  env:
+    path: env.sh
+    sourced_by: zshrc
+modules:
+  - name: editor
+    file: editor.sh
+    target: env
+`), 0o644)
+	afero.WriteFile(fs, "editor.sh", []byte("export EDITOR=vim"), 0o644)
+
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	_, err := builder.Build(BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OutputDir: "build", OS: "Linux", Shell: "bash"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sourced by 'zshrc'")
+}
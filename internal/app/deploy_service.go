@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
@@ -15,9 +16,10 @@ type DirectoryReader interface {
 	ListDir(path string) ([]string, error)
 }
 
-// BackupWriter extends FileWriter with backup and directory support.
+// BackupWriter extends FileWriter with backup, removal and directory support.
 type BackupWriter interface {
 	FileWriter
+	FileRemover
 	Copy(src, dst string) error
 	MkdirAll(path string) error
 }
@@ -98,6 +100,7 @@ type DeployResult struct {
 	SkippedCount  int
 	ErrorCount    int
 	BackupPaths   map[string]string // source -> backup path
+	RemovedFiles  []string          // stale generated files deleted from directory targets (would be, in a dry run)
 	DeployedAt    time.Time
 }
 
@@ -230,9 +233,77 @@ func (s *DeployService) Deploy(opts DeployOptions) (*DeployResult, error) {
 		result.DeployedFiles = append(result.DeployedFiles, deployed)
 	}
 
+	s.removeStaleFiles(opts, metadata, result)
+
 	return result, nil
 }
 
+// removeStaleFiles deletes generated files that earlier deploys left in
+// the directory targets of this build, e.g. after a module was removed or
+// renumbered. Files without the shellforge header are never touched.
+func (s *DeployService) removeStaleFiles(opts DeployOptions, metadata *domain.BuildMetadata, result *DeployResult) {
+	// Every file this build deploys is kept, including single-file targets
+	// that live inside a directory target's directory
+	keep := make(map[string]bool, len(metadata.Files))
+	scan := make(map[string]bool)
+	for _, fileInfo := range metadata.Files {
+		dest := fileInfo.DestPath
+		if !filepath.IsAbs(dest) {
+			dest = filepath.Join(opts.HomeDir, dest)
+		}
+		keep[dest] = true
+		if fileInfo.Directory && !filepath.IsAbs(fileInfo.DestPath) {
+			scan[filepath.Dir(dest)] = true
+		}
+	}
+
+	dirs := make([]string, 0, len(scan))
+	for dir := range scan {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		names, err := s.reader.ListDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if keep[path] {
+				continue
+			}
+			content, err := s.reader.ReadFile(path)
+			if err != nil || !domain.IsGeneratedFile(content) {
+				continue
+			}
+			if opts.DryRun {
+				result.RemovedFiles = append(result.RemovedFiles, path)
+				continue
+			}
+
+			removed := DeployedFile{DestPath: path}
+			if opts.CreateBackup {
+				backupPath, err := s.createBackup(path)
+				if err != nil {
+					removed.Error = fmt.Errorf("backup failed: %w", err)
+					result.ErrorCount++
+					result.DeployedFiles = append(result.DeployedFiles, removed)
+					continue
+				}
+				result.BackupPaths[path] = backupPath
+			}
+			if err := s.writer.Remove(path); err != nil {
+				removed.Error = fmt.Errorf("failed to remove stale file: %w", err)
+				result.ErrorCount++
+				result.DeployedFiles = append(result.DeployedFiles, removed)
+				continue
+			}
+			result.RemovedFiles = append(result.RemovedFiles, path)
+		}
+	}
+}
+
 // ensureDir creates a directory if it doesn't exist.
 func (s *DeployService) ensureDir(dir string) error {
 	return s.writer.MkdirAll(dir)
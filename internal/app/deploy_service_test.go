@@ -3,6 +3,7 @@ package app
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
@@ -58,7 +59,8 @@ func (m *MockDirectoryReader) AddDirectory(path string, files []string) {
 
 // MockBackupWriter implements BackupWriter for testing.
 type MockBackupWriter struct {
-	files map[string]string
+	files   map[string]string
+	removed []string
 }
 
 func NewMockBackupWriter() *MockBackupWriter {
@@ -77,6 +79,12 @@ func (m *MockBackupWriter) Copy(src, dst string) error {
 	return nil
 }
 
+func (m *MockBackupWriter) Remove(path string) error {
+	delete(m.files, path)
+	m.removed = append(m.removed, path)
+	return nil
+}
+
 func (m *MockBackupWriter) MkdirAll(path string) error {
 	// Mock implementation - just track that the directory was created
 	return nil
@@ -449,3 +457,78 @@ func TestDeployService_Deploy_SystemTarget_DryRun(t *testing.T) {
 		t.Error("dry-run must not write files")
 	}
 }
+
+func TestDeployService_Deploy_RemovesStaleDirectoryFiles(t *testing.T) {
+	reader := NewMockDirectoryReader()
+	writer := NewMockBackupWriter()
+	service := NewDeployServiceWithChecker(reader, writer, &MockPermissionChecker{})
+
+	reader.AddDirectory("./build", []string{".zshrc"})
+	reader.AddFile("build/.zshrc", "zshrc content")
+	reader.AddFile("build/.zshrc.d/01-git.zsh", "git content")
+	reader.AddFile("build/"+domain.MetadataFileName, createTestMetadata([]domain.BuildFileInfo{
+		{Source: ".zshrc", Target: "zshrc", DestPath: ".zshrc"},
+		{Source: ".zshrc.d/01-git.zsh", Target: "zshrc.d", DestPath: ".zshrc.d/01-git.zsh", Directory: true},
+	}))
+
+	// Left by an earlier deploy: two generated files and one written by hand
+	reader.AddDirectory("/home/test/.zshrc.d", []string{"01-git.zsh", "01-path.zsh", "02-git.zsh", "local.zsh"})
+	reader.AddFile("/home/test/.zshrc.d/01-path.zsh", domain.GeneratedHeader+"\n# Module: path\n")
+	reader.AddFile("/home/test/.zshrc.d/02-git.zsh", domain.GeneratedHeader+"\n# Module: git\n")
+	reader.AddFile("/home/test/.zshrc.d/local.zsh", "# my own settings\n")
+
+	result, err := service.Deploy(DeployOptions{BuildDir: "./build", HomeDir: "/home/test", DryRun: true})
+	if err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+	want := []string{"/home/test/.zshrc.d/01-path.zsh", "/home/test/.zshrc.d/02-git.zsh"}
+	if !slices.Equal(result.RemovedFiles, want) {
+		t.Errorf("dry run RemovedFiles = %v, want %v", result.RemovedFiles, want)
+	}
+	if len(writer.removed) != 0 {
+		t.Errorf("dry run removed %v", writer.removed)
+	}
+
+	result, err = service.Deploy(DeployOptions{BuildDir: "./build", HomeDir: "/home/test", CreateBackup: true})
+	if err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+	if !slices.Equal(writer.removed, want) {
+		t.Errorf("removed = %v, want %v", writer.removed, want)
+	}
+	if result.ErrorCount != 0 {
+		t.Errorf("ErrorCount = %d, want 0", result.ErrorCount)
+	}
+	if _, ok := result.BackupPaths["/home/test/.zshrc.d/02-git.zsh"]; !ok {
+		t.Error("expected a backup of the removed file")
+	}
+}
+
+func TestDeployService_Deploy_KeepsFileTargetsInsideDirectoryTargets(t *testing.T) {
+	reader := NewMockDirectoryReader()
+	writer := NewMockBackupWriter()
+	service := NewDeployServiceWithChecker(reader, writer, &MockPermissionChecker{})
+
+	// A custom directory target at ~/.config/shell and a file target inside it
+	reader.AddDirectory("./build", []string{".config"})
+	reader.AddFile("build/.config/shell/env.sh", domain.GeneratedHeader+"\n# Module: env\n")
+	reader.AddFile("build/.config/shell/01-git.sh", domain.GeneratedHeader+"\n# Module: git\n")
+	reader.AddFile("build/"+domain.MetadataFileName, createTestMetadata([]domain.BuildFileInfo{
+		{Source: ".config/shell/env.sh", Target: "env", DestPath: ".config/shell/env.sh"},
+		{Source: ".config/shell/01-git.sh", Target: "snippets", DestPath: ".config/shell/01-git.sh", Directory: true},
+	}))
+
+	reader.AddDirectory("/home/test/.config/shell", []string{"01-git.sh", "02-old.sh", "env.sh"})
+	reader.AddFile("/home/test/.config/shell/env.sh", domain.GeneratedHeader+"\n# Module: env\n")
+	reader.AddFile("/home/test/.config/shell/01-git.sh", domain.GeneratedHeader+"\n# Module: git\n")
+	reader.AddFile("/home/test/.config/shell/02-old.sh", domain.GeneratedHeader+"\n# Module: old\n")
+
+	result, err := service.Deploy(DeployOptions{BuildDir: "./build", HomeDir: "/home/test"})
+	if err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+	want := []string{"/home/test/.config/shell/02-old.sh"}
+	if !slices.Equal(result.RemovedFiles, want) || !slices.Equal(writer.removed, want) {
+		t.Errorf("RemovedFiles = %v, removed = %v, want %v", result.RemovedFiles, writer.removed, want)
+	}
+}
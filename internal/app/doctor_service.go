@@ -1,7 +1,13 @@
 package app
 
 import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain/shellmeta"
 )
 
 // MissingDep describes one external prerequisite that is absent.
@@ -11,11 +17,31 @@ type MissingDep struct {
 	Kind    string   // "binary" or "path"
 }
 
+// LazyCandidate suggests loading a module with 'lazy:' because it sets up a
+// version manager shellmeta marks as lazy-loadable.
+type LazyCandidate struct {
+	Module   string   // module name
+	Manager  string   // version manager it was matched to, e.g. "nvm"
+	Triggers []string // suggested value for the module's lazy field
+}
+
+// MissingPathEntry is a directory a module's 'path' declaration adds to
+// PATH that does not exist on the host.
+type MissingPathEntry struct {
+	Dir      string   // directory as declared
+	Modules  []string // modules that declare it
+	IfExists bool     // every declaration is if_exists, so the shell skips it
+}
+
 // DoctorResult is the output of a doctor check run.
 type DoctorResult struct {
-	Missing     []MissingDep
-	CheckedOS   string
-	ModuleCount int
+	Missing        []MissingDep
+	LazyCandidates []LazyCandidate // suggestions only; they do not affect AllOK
+	CheckedOS      string
+	ModuleCount    int
+
+	// MissingPathEntries are reported only; they do not affect AllOK.
+	MissingPathEntries []MissingPathEntry
 }
 
 // AllOK returns true when no prerequisites are missing.
@@ -30,15 +56,23 @@ type DoctorService struct{}
 func NewDoctorService() *DoctorService { return &DoctorService{} }
 
 // Check runs the prerequisite check for all modules that apply to targetOS.
-// Missing entries are grouped by dep name so each install hint appears once.
+// It is CheckHost with no host facts other than the OS.
 func (s *DoctorService) Check(manifest *domain.Manifest, targetOS string, lookup domain.PrereqLookup) *DoctorResult {
+	return s.CheckHost(manifest, domain.HostFacts{OS: targetOS}, lookup)
+}
+
+// CheckHost runs the prerequisite check for all modules selected for the host
+// by Module.Evaluate, the same predicate the resolver uses.
+// Missing entries are grouped by dep name so each install hint appears once.
+func (s *DoctorService) CheckHost(manifest *domain.Manifest, facts domain.HostFacts, lookup domain.PrereqLookup) *DoctorResult {
 	// grouped[name] → list of module names that need it
 	binMissing := make(map[string][]string)
 	pathMissing := make(map[string][]string)
+	entriesMissing := make(map[string]*MissingPathEntry)
 	moduleCount := 0
 
 	for _, mod := range manifest.Modules {
-		if !mod.AppliesTo(targetOS) {
+		if !mod.Evaluate(facts).Included {
 			continue
 		}
 		moduleCount++
@@ -56,6 +90,26 @@ func (s *DoctorService) Check(manifest *domain.Manifest, targetOS string, lookup
 				pathMissing[p] = append(pathMissing[p], mod.Name)
 			}
 		}
+
+		for _, rule := range mod.Path {
+			if !rule.AppliesTo(facts.OS) {
+				continue
+			}
+			for _, dir := range append(append([]string{}, rule.Prepend...), rule.Append...) {
+				if lookup.PathExists(domain.ExpandPath(dir)) {
+					continue
+				}
+				entry, ok := entriesMissing[dir]
+				if !ok {
+					entry = &MissingPathEntry{Dir: dir, IfExists: true}
+					entriesMissing[dir] = entry
+				}
+				if !slices.Contains(entry.Modules, mod.Name) {
+					entry.Modules = append(entry.Modules, mod.Name)
+				}
+				entry.IfExists = entry.IfExists && rule.IfExists
+			}
+		}
 	}
 
 	var missing []MissingDep
@@ -66,9 +120,78 @@ func (s *DoctorService) Check(manifest *domain.Manifest, targetOS string, lookup
 		missing = append(missing, MissingDep{Name: p, Modules: mods, Kind: "path"})
 	}
 
+	var entries []MissingPathEntry
+	for _, entry := range entriesMissing {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Dir < entries[j].Dir
+	})
+
 	return &DoctorResult{
-		Missing:     missing,
-		CheckedOS:   targetOS,
-		ModuleCount: moduleCount,
+		Missing:            missing,
+		MissingPathEntries: entries,
+		CheckedOS:          facts.OS,
+		ModuleCount:        moduleCount,
+	}
+}
+
+// SuggestLazy matches the modules selected for the host against the
+// language version managers in dev that are marked lazy_load, and returns
+// the ones not already lazy, sorted by module name. A module matches a
+// manager when its name or file name is the manager's name (optionally
+// followed by a suffix such as "-init") or it requires the manager's binary.
+func (s *DoctorService) SuggestLazy(manifest *domain.Manifest, facts domain.HostFacts, dev *shellmeta.DevProfiles) []LazyCandidate {
+	if dev == nil {
+		return nil
+	}
+
+	managers := make([]string, 0, len(dev.LanguageVersionManagers))
+	for name, mgr := range dev.LanguageVersionManagers {
+		if mgr.LazyLoad {
+			managers = append(managers, name)
+		}
+	}
+	sort.Strings(managers)
+
+	var candidates []LazyCandidate
+	for _, mod := range manifest.Modules {
+		if mod.IsLazy() || !mod.Evaluate(facts).Included {
+			continue
+		}
+		for _, name := range managers {
+			if !moduleSetsUp(mod, name) {
+				continue
+			}
+			triggers := dev.LanguageVersionManagers[name].LazyTriggers
+			if len(triggers) == 0 {
+				triggers = []string{name}
+			}
+			candidates = append(candidates, LazyCandidate{Module: mod.Name, Manager: name, Triggers: triggers})
+			break
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Module < candidates[j].Module
+	})
+	return candidates
+}
+
+// moduleSetsUp reports whether mod looks like the init module of the
+// version manager named manager.
+func moduleSetsUp(mod domain.Module, manager string) bool {
+	file := strings.TrimSuffix(filepath.Base(mod.File), filepath.Ext(mod.File))
+	for _, name := range []string{mod.Name, file} {
+		name = strings.ToLower(name)
+		if name == manager || strings.HasPrefix(name, manager+"-") || strings.HasPrefix(name, manager+"_") {
+			return true
+		}
+	}
+	for _, bin := range mod.RequiresBin {
+		if bin == manager {
+			return true
+		}
 	}
+	return false
 }
@@ -1,12 +1,14 @@
 package app_test
 
 import (
+	"reflect"
 	"slices"
 	"sort"
 	"testing"
 
 	"github.com/gizzahub/gzh-cli-shellforge/internal/app"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain/shellmeta"
 )
 
 // mockLookup lets tests control which binaries/paths "exist".
@@ -92,6 +94,30 @@ func TestDoctorService_MissingPath(t *testing.T) {
 	}
 }
 
+func TestDoctorService_MissingPathEntries(t *testing.T) {
+	manifest := makeManifest([]domain.Module{
+		{Name: "local-bin", Path: []domain.PathRule{{Prepend: []string{"/opt/bin", "/gone"}, IfExists: true}}},
+		{Name: "tools", File: "tools.sh", Path: []domain.PathRule{
+			{Append: []string{"/gone", "/usr/games"}},
+			{Prepend: []string{"/opt/homebrew/bin"}, OS: []string{"Mac"}},
+		}},
+	})
+	lookup := newMock(nil, []string{"/opt/bin"})
+
+	result := app.NewDoctorService().Check(manifest, "Linux", lookup)
+
+	if !result.AllOK() {
+		t.Errorf("missing PATH entries must not fail the check: %+v", result.Missing)
+	}
+	want := []app.MissingPathEntry{
+		{Dir: "/gone", Modules: []string{"local-bin", "tools"}, IfExists: false},
+		{Dir: "/usr/games", Modules: []string{"tools"}, IfExists: false},
+	}
+	if !reflect.DeepEqual(result.MissingPathEntries, want) {
+		t.Errorf("MissingPathEntries = %+v, want %+v", result.MissingPathEntries, want)
+	}
+}
+
 func TestDoctorService_OSFiltering(t *testing.T) {
 	manifest := makeManifest([]domain.Module{
 		{Name: "mac-only", File: "mac.sh", RequiresBin: []string{"pbcopy"}, OS: []string{"Mac"}},
@@ -114,6 +140,27 @@ func TestDoctorService_OSFiltering(t *testing.T) {
 	}
 }
 
+func TestDoctorService_CheckHost_WhenConditions(t *testing.T) {
+	manifest := makeManifest([]domain.Module{
+		{Name: "brew-arm", File: "a.sh", RequiresPath: []string{"/opt/homebrew/bin"}, When: &domain.Condition{Arch: []string{"arm64"}}},
+		{Name: "brew-intel", File: "i.sh", RequiresPath: []string{"/usr/local/bin/brew"}, When: &domain.Condition{Arch: []string{"amd64"}}},
+	})
+	lookup := newMock(nil, nil)
+
+	result := app.NewDoctorService().CheckHost(manifest, domain.HostFacts{OS: "Mac", Arch: "arm64"}, lookup)
+
+	names := depNames(result.Missing)
+	if !contains(names, "/opt/homebrew/bin") {
+		t.Error("arm64 module should be checked on arm64")
+	}
+	if contains(names, "/usr/local/bin/brew") {
+		t.Error("amd64 module should be skipped on arm64")
+	}
+	if result.ModuleCount != 1 {
+		t.Errorf("ModuleCount = %d, want 1", result.ModuleCount)
+	}
+}
+
 func TestDoctorService_GroupsByDepName(t *testing.T) {
 	manifest := makeManifest([]domain.Module{
 		{Name: "prompt", File: "prompt.sh", RequiresBin: []string{"starship"}},
@@ -149,3 +196,41 @@ func depNames(deps []app.MissingDep) []string {
 func contains(slice []string, s string) bool {
 	return slices.Contains(slice, s)
 }
+
+func TestDoctorService_SuggestLazy(t *testing.T) {
+	manifest := makeManifest([]domain.Module{
+		{Name: "nvm", File: "init/nvm.sh"},
+		{Name: "node-versions", File: "init/pyenv-init.sh"},
+		{Name: "ruby", File: "init/ruby.sh", RequiresBin: []string{"rbenv"}},
+		{Name: "conda", File: "init/conda.sh", Lazy: []string{"conda"}},
+		{Name: "rvm", File: "init/rvm.sh"},
+		{Name: "nvm-mac", File: "init/nvm.sh", OS: []string{"Mac"}},
+	})
+	dev := &shellmeta.DevProfiles{LanguageVersionManagers: map[string]shellmeta.LanguageVersionMgr{
+		"nvm":   {LazyLoad: true, LazyTriggers: []string{"nvm", "node"}},
+		"pyenv": {LazyLoad: true},
+		"rbenv": {LazyLoad: true, LazyTriggers: []string{"rbenv", "ruby"}},
+		"conda": {LazyLoad: true},
+		"rvm":   {},
+	}}
+
+	candidates := app.NewDoctorService().SuggestLazy(manifest, domain.HostFacts{OS: "Linux"}, dev)
+
+	want := []app.LazyCandidate{
+		{Module: "node-versions", Manager: "pyenv", Triggers: []string{"pyenv"}},
+		{Module: "nvm", Manager: "nvm", Triggers: []string{"nvm", "node"}},
+		{Module: "ruby", Manager: "rbenv", Triggers: []string{"rbenv", "ruby"}},
+	}
+	if !reflect.DeepEqual(candidates, want) {
+		t.Errorf("SuggestLazy = %+v, want %+v", candidates, want)
+	}
+
+	result := app.NewDoctorService().Check(manifest, "Linux", newMock([]string{"rbenv"}, nil))
+	result.LazyCandidates = candidates
+	if !result.AllOK() {
+		t.Error("lazy suggestions must not fail the check")
+	}
+	if got := app.NewDoctorService().SuggestLazy(manifest, domain.HostFacts{OS: "Linux"}, nil); got != nil {
+		t.Errorf("SuggestLazy without profiles data = %v, want nil", got)
+	}
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+)
+
+// SourceLocation is a generated line traced back to its module file.
+type SourceLocation struct {
+	Target     string // build file, as recorded in build metadata
+	Line       int    // line in the generated file
+	Module     string
+	File       string // module file path, including the build's config dir
+	ModuleLine int    // line in the module file
+}
+
+// LocateService maps lines of generated files back to module files using
+// the source map in the build metadata.
+type LocateService struct {
+	reader FileReader
+}
+
+// NewLocateService creates a new locate service.
+func NewLocateService(reader FileReader) *LocateService {
+	return &LocateService{reader: reader}
+}
+
+// Locate finds the module that produced line of the generated file named by
+// target (see domain.BuildMetadata.FindFile) in buildDir.
+func (s *LocateService) Locate(buildDir, target string, line int) (*SourceLocation, error) {
+	metaPath := filepath.Join(buildDir, domain.MetadataFileName)
+	if !s.reader.FileExists(metaPath) {
+		return nil, fmt.Errorf("metadata file not found: %s\n\nRun 'gz-shellforge build' to regenerate", metaPath)
+	}
+	metaContent, err := s.reader.ReadFile(metaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	metadata, err := domain.ParseBuildMetadata([]byte(metaContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+
+	file, ok := metadata.FindFile(target)
+	if !ok {
+		return nil, fmt.Errorf("no generated file matches '%s' in %s", target, buildDir)
+	}
+	if len(file.Sources) == 0 {
+		return nil, fmt.Errorf("%s has no source map\n\nRun 'gz-shellforge build' to regenerate", file.Source)
+	}
+
+	span, moduleLine, ok := file.Sources.Locate(line)
+	if !ok {
+		return nil, fmt.Errorf("%s:%d is generated by shellforge (header or module separator), not part of a module", file.Source, line)
+	}
+	moduleFile := filepath.Join(metadata.ConfigDir, span.File)
+	if span.Inline {
+		moduleFile = span.File
+	}
+	return &SourceLocation{
+		Target:     file.Source,
+		Line:       line,
+		Module:     span.Module,
+		File:       moduleFile,
+		ModuleLine: moduleLine,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/filesystem"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/yamlparser"
+)
+
+func TestLocateService_Locate(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`modules:
+  - name: path
+    file: path.zsh
+    description: PATH setup
+  - name: git
+    file: git.zsh
+    priority: 60
+`), 0o644)
+	afero.WriteFile(fs, "modules/path.zsh", []byte("export PATH=$HOME/bin:$PATH\n"), 0o644)
+	afero.WriteFile(fs, "modules/git.zsh", []byte("alias g=git\nalias gs='git status'\ngit-undefined-cmd\n"), 0o644)
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+
+	result, err := builder.Build(BuildOptions{
+		ConfigDir: "modules",
+		Manifest:  "manifest.yaml",
+		OutputDir: "build",
+		OS:        "Mac",
+		HomeDir:   "/home/me",
+	})
+	require.NoError(t, err)
+
+	// Find the output line holding "git-undefined-cmd"
+	lines := strings.Split(result.Targets[0].Content, "\n")
+	outputLine := 0
+	for i, line := range lines {
+		if line == "git-undefined-cmd" {
+			outputLine = i + 1
+		}
+	}
+	require.NotZero(t, outputLine)
+
+	svc := NewLocateService(filesystem.NewReader(fs))
+
+	for _, target := range []string{"zshrc", ".zshrc", "/home/me/.zshrc", "~/.zshrc"} {
+		t.Run(target, func(t *testing.T) {
+			loc, err := svc.Locate("build", target, outputLine)
+			require.NoError(t, err)
+			assert.Equal(t, "git", loc.Module)
+			assert.Equal(t, "modules/git.zsh", loc.File)
+			assert.Equal(t, 3, loc.ModuleLine)
+			assert.Equal(t, ".zshrc", loc.Target)
+		})
+	}
+
+	t.Run("header line", func(t *testing.T) {
+		_, err := svc.Locate("build", "zshrc", 1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not part of a module")
+	})
+
+	t.Run("unknown target", func(t *testing.T) {
+		_, err := svc.Locate("build", "bashrc", 1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no generated file matches 'bashrc'")
+	})
+
+	t.Run("no build", func(t *testing.T) {
+		_, err := svc.Locate("elsewhere", "zshrc", 1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "metadata file not found")
+	})
+}
+
+func TestLocateService_Locate_InlineModule(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`modules:
+  - name: aliases
+    content: |
+      alias g=git
+      alias gs='git status'
+`), 0o644)
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+	result, err := builder.Build(BuildOptions{ConfigDir: "modules", Manifest: "manifest.yaml", OutputDir: "build", OS: "Mac"})
+	require.NoError(t, err)
+
+	lines := strings.Split(result.Targets[0].Content, "\n")
+	outputLine := slices.Index(lines, "alias gs='git status'") + 1
+	require.NotZero(t, outputLine)
+
+	// The manifest path is not joined with the config dir
+	loc, err := NewLocateService(filesystem.NewReader(fs)).Locate("build", "zshrc", outputLine)
+	require.NoError(t, err)
+	assert.Equal(t, "aliases", loc.Module)
+	assert.Equal(t, "manifest.yaml", loc.File)
+	assert.Equal(t, 5, loc.ModuleLine)
+}
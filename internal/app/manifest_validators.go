@@ -2,12 +2,18 @@ package app
 
 import (
 	"fmt"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain/shellmeta"
 )
 
 // ManifestStructureValidator checks module names, required fields, and dep refs.
+// A requires_optional naming an unknown module is only a warning.
 type ManifestStructureValidator struct{}
 
 func (ManifestStructureValidator) Name() string { return "manifest-structure" }
@@ -17,49 +23,279 @@ func (ManifestStructureValidator) Validate(m *domain.Manifest, _ string) []Findi
 	for _, err := range m.Validate() {
 		findings = append(findings, Finding{Severity: SeverityError, Message: err.Error()})
 	}
+	for _, err := range m.UnknownOptionalDependencies() {
+		findings = append(findings, Finding{Severity: SeverityWarn, Message: err.Error()})
+	}
 	return findings
 }
 
-// CircularDependencyValidator checks for cycles in module dependency graph.
+// CircularDependencyValidator reports every dependency cycle as an ordered
+// chain with manifest references. It shares domain.FindCycles with the
+// resolver, so validate and build describe cycles identically.
 type CircularDependencyValidator struct{}
 
 func (CircularDependencyValidator) Name() string { return "circular-dependencies" }
 
 func (CircularDependencyValidator) Validate(m *domain.Manifest, _ string) []Finding {
-	depMap := make(map[string][]string, len(m.Modules))
-	for _, mod := range m.Modules {
-		depMap[mod.Name] = mod.Requires
+	var findings []Finding
+	for _, cycle := range domain.FindCycles(m.Modules) {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Module:   cycle.Modules[0],
+			Message:  "circular dependency: " + cycle.Describe(),
+		})
+	}
+	return findings
+}
+
+// PriorityConflictValidator reports every priority the dependency order
+// contradicts: a module whose priority is lower than that of a module of the
+// same target it requires. The build loads dependencies first regardless,
+// so these are warnings about a priority that has no effect.
+type PriorityConflictValidator struct{}
+
+func (PriorityConflictValidator) Name() string { return "priority-conflicts" }
+
+func (PriorityConflictValidator) Validate(m *domain.Manifest, _ string) []Finding {
+	var findings []Finding
+	for _, c := range m.PriorityConflicts() {
+		findings = append(findings, Finding{
+			Severity: SeverityWarn,
+			Module:   c.Module,
+			Message:  c.Error(),
+		})
 	}
-	for name := range depMap {
-		visited := make(map[string]bool)
-		if detectCycle(name, depMap, visited, make(map[string]bool)) {
-			return []Finding{{
+	return findings
+}
+
+// ExcludedDependencyValidator checks, for every OS the manifest mentions,
+// whether the OS filter removes a module that another applicable module needs.
+// Hard requires are errors (the build would fail on that OS); requires_optional
+// edges are reported as warnings because the build silently drops them.
+type ExcludedDependencyValidator struct{}
+
+func (ExcludedDependencyValidator) Name() string { return "excluded-dependencies" }
+
+func (ExcludedDependencyValidator) Validate(m *domain.Manifest, _ string) []Finding {
+	var findings []Finding
+	for _, targetOS := range m.OSNames() {
+		hard, optional := m.ExcludedDependencies(targetOS)
+		for _, e := range hard {
+			findings = append(findings, Finding{
 				Severity: SeverityError,
-				Message:  fmt.Sprintf("circular dependency involving module '%s'", name),
-			}}
+				Module:   e.Module,
+				Message:  e.Error(),
+			})
+		}
+		for _, e := range optional {
+			findings = append(findings, Finding{
+				Severity: SeverityWarn,
+				Module:   e.Module,
+				Message: fmt.Sprintf("optional dependency '%s' is skipped on %s ('%s' applies to: %s)",
+					e.Dependency, e.OS, e.Dependency, strings.Join(e.DepOS, ", ")),
+			})
 		}
 	}
-	return nil
+	return findings
+}
+
+// LoadOrderValidator checks 'requires' between modules of different targets
+// against the order in which the shell really reads its startup files
+// (shellmeta load_priority modes), for login, interactive non-login and
+// non-interactive shells. A dependency whose target loads after the
+// dependent's is an error. A dependency whose target is not loaded at all
+// in a mode that loads the dependent's is a warning, because a shell
+// started from a login shell often inherits what the login files exported.
+type LoadOrderValidator struct {
+	Modes map[string]shellmeta.LoadModes // per shell type
 }
 
-func detectCycle(node string, graph map[string][]string, visited, recStack map[string]bool) bool {
-	visited[node] = true
-	recStack[node] = true
-	for _, neighbor := range graph[node] {
-		if !visited[neighbor] {
-			if detectCycle(neighbor, graph, visited, recStack) {
-				return true
+func (LoadOrderValidator) Name() string { return "load-order" }
+
+func (v LoadOrderValidator) Validate(m *domain.Manifest, _ string) []Finding {
+	shell := strings.ToLower(m.Shell.Type)
+	if shell == "" {
+		shell = "zsh"
+	}
+	modes, ok := v.Modes[shell]
+	if !ok {
+		return nil
+	}
+
+	resolver := domain.NewTargetResolver(shell, "~")
+	if err := resolver.AddCustomTargets(m.Targets); err != nil {
+		for _, name := range m.CustomTargetNames() {
+			if m.Targets[name].Validate(name) != nil {
+				return nil // reported by ManifestStructureValidator
 			}
-		} else if recStack[neighbor] {
-			return true
 		}
+		return []Finding{{Severity: SeverityError, Message: err.Error()}}
 	}
-	recStack[node] = false
-	return false
+
+	targetOf := make(map[string]string, len(m.Modules))
+	used := make(map[string]bool)
+	for _, mod := range m.Modules {
+		target := strings.ToLower(mod.Target)
+		if target == "" {
+			target = resolver.GetDefaultTarget()
+		}
+		targetOf[mod.Name] = target
+		// The file sourcing a target is generated along with it
+		for ; target != "" && !used[target]; target = loadedBy(resolver, target) {
+			used[target] = true
+		}
+	}
+
+	sessions := []struct {
+		name      string
+		positions map[string]int
+	}{
+		{"login", loadPositions(modes.Login, resolver, used)},
+		{"interactive non-login", loadPositions(modes.Interactive, resolver, used)},
+		{"non-interactive", loadPositions(modes.NonInteractive, resolver, used)},
+	}
+
+	var findings []Finding
+	for _, mod := range m.Modules {
+		target := targetOf[mod.Name]
+		for _, dep := range mod.Requires {
+			depTarget, ok := targetOf[dep]
+			if !ok || depTarget == target {
+				continue
+			}
+			var later, missing []string
+			for _, session := range sessions {
+				pos, loaded := session.positions[target]
+				if !loaded {
+					continue
+				}
+				depPos, depLoaded := session.positions[depTarget]
+				switch {
+				case !depLoaded:
+					missing = append(missing, session.name)
+				case depPos > pos:
+					later = append(later, session.name)
+				}
+			}
+			if len(later) > 0 {
+				findings = append(findings, Finding{
+					Severity: SeverityError,
+					Module:   mod.Name,
+					Message: fmt.Sprintf("requires '%s' from target '%s', which %s shells load after '%s'",
+						dep, depTarget, joinAnd(later), target),
+				})
+			}
+			if len(missing) > 0 {
+				findings = append(findings, Finding{
+					Severity: SeverityWarn,
+					Module:   mod.Name,
+					Message: fmt.Sprintf("requires '%s' from target '%s', which %s shells do not load",
+						dep, depTarget, joinAnd(missing)),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// loadedBy returns the target whose file sources target: the sourced_by of
+// a custom target, or the rc file of zshrc.d and bashrc.d.
+func loadedBy(resolver *domain.TargetResolver, target string) string {
+	if rc := resolver.SourcedBy(target); rc != "" {
+		return rc
+	}
+	return domain.DirectorySourcedBy(target)
+}
+
+// loadPositions returns the position at which each used target is read
+// during a startup that reads steps. Of alternative files, the first one
+// that is a used target is read. Targets sourced by another target load at
+// the end of its file, in the order the builder appends their loaders.
+func loadPositions(steps []shellmeta.LoadStep, resolver *domain.TargetResolver, used map[string]bool) map[string]int {
+	byPath := make(map[string]string)
+	children := make(map[string][]string)
+	for _, target := range resolver.GetValidTargets() {
+		file, err := resolver.Resolve(target)
+		if err != nil {
+			continue
+		}
+		byPath[filepath.ToSlash(file)] = target
+		if rc := loadedBy(resolver, target); rc != "" && used[target] {
+			children[rc] = append(children[rc], target)
+		}
+	}
+
+	positions := make(map[string]int)
+	var visit func(target string)
+	visit = func(target string) {
+		if _, seen := positions[target]; seen {
+			return
+		}
+		positions[target] = len(positions)
+		sort.Strings(children[target])
+		for _, child := range children[target] {
+			visit(child)
+		}
+	}
+	for _, step := range steps {
+		for _, file := range step {
+			if target, ok := byPath[path.Clean(file)]; ok && used[target] {
+				visit(target)
+				break
+			}
+		}
+	}
+	return positions
+}
+
+// joinAnd joins items as "a", "a and b" or "a, b and c".
+func joinAnd(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// SelectionValidator checks module selections: every declared profile plus
+// an optional extra selection (the --tag / --exclude-tag flags). A selected
+// module whose hard requirement is excluded is an error; a tag that matches
+// no module is a warning, since it is most likely a typo.
+type SelectionValidator struct {
+	Extra domain.ModuleSelector
+}
+
+func (SelectionValidator) Name() string { return "selection" }
+
+func (v SelectionValidator) Validate(m *domain.Manifest, _ string) []Finding {
+	var findings []Finding
+	check := func(label string, sel domain.ModuleSelector) {
+		for _, err := range sel.Check(m) {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s: %v", label, err),
+			})
+		}
+		for _, tag := range slices.Concat(sel.IncludeTags, sel.ExcludeTags) {
+			if !m.HasTag(tag) {
+				findings = append(findings, Finding{
+					Severity: SeverityWarn,
+					Message:  fmt.Sprintf("%s: tag '%s' matches no module", label, tag),
+				})
+			}
+		}
+	}
+
+	for _, name := range m.ProfileNames() {
+		check(fmt.Sprintf("profile '%s'", name), m.Profiles[name].ModuleSelector)
+	}
+	check("tag selection", v.Extra)
+	return findings
 }
 
 // FileExistenceValidator checks that all referenced module files exist.
-// Uses the FileReader interface defined in builder.go.
+// Uses the FileReader interface defined in builder.go. File paths are
+// interpolated with the manifest's base vars; paths that cannot be are left
+// to VariableValidator.
 type FileExistenceValidator struct {
 	reader FileReader
 }
@@ -74,7 +310,14 @@ func (*FileExistenceValidator) Name() string { return "file-existence" }
 func (v *FileExistenceValidator) Validate(m *domain.Manifest, modulesDir string) []Finding {
 	var findings []Finding
 	for _, mod := range m.Modules {
-		filePath := filepath.Join(modulesDir, mod.File)
+		if mod.IsInline() || !mod.HasBody() {
+			continue
+		}
+		file, err := domain.Interpolate(mod.File, m.Vars)
+		if err != nil {
+			continue
+		}
+		filePath := filepath.Join(modulesDir, file)
 		if !v.reader.FileExists(filePath) {
 			findings = append(findings, Finding{
 				Severity: SeverityError,
@@ -85,3 +328,83 @@ func (v *FileExistenceValidator) Validate(m *domain.Manifest, modulesDir string)
 	}
 	return findings
 }
+
+// inlineBody returns the body of a module declared in the manifest, with
+// generators rendered for shell.
+func inlineBody(mod domain.Module, shell string) string {
+	if mod.Generate != nil {
+		body, _ := mod.Generate.Render(shell)
+		return body
+	}
+	return mod.Content
+}
+
+// VariableValidator reports {{ .Vars.name }} references to variables that are
+// not declared anywhere in the manifest (vars, os_vars or a profile), and
+// malformed templates. It checks module file paths, requires_path and the
+// bodies of modules with 'interpolate: true'.
+type VariableValidator struct {
+	reader FileReader
+}
+
+// NewVariableValidator creates a VariableValidator that reads module bodies
+// through reader.
+func NewVariableValidator(reader FileReader) *VariableValidator {
+	return &VariableValidator{reader: reader}
+}
+
+func (*VariableValidator) Name() string { return "variables" }
+
+func (v *VariableValidator) Validate(m *domain.Manifest, modulesDir string) []Finding {
+	declared := m.DeclaredVars()
+	var findings []Finding
+	check := func(mod, where, s string) {
+		refs, err := domain.VarRefs(s)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Module:   mod,
+				Message:  fmt.Sprintf("invalid template in %s: %v", where, err),
+			})
+			return
+		}
+		for _, ref := range refs {
+			if !slices.Contains(declared, ref) {
+				findings = append(findings, Finding{
+					Severity: SeverityError,
+					Module:   mod,
+					Message:  fmt.Sprintf("undefined variable '%s' in %s", ref, where),
+				})
+			}
+		}
+	}
+
+	for _, mod := range m.Modules {
+		check(mod.Name, "file", mod.File)
+		for _, p := range mod.RequiresPath {
+			check(mod.Name, "requires_path", p)
+		}
+		for _, rule := range mod.Path {
+			for _, dir := range append(append([]string{}, rule.Prepend...), rule.Append...) {
+				check(mod.Name, "path", dir)
+			}
+		}
+		if !mod.IsInterpolated() {
+			continue
+		}
+		if mod.IsInline() {
+			check(mod.Name, "module body", inlineBody(mod, m.GetShellType()))
+			continue
+		}
+		file, err := domain.Interpolate(mod.File, m.Vars)
+		if err != nil {
+			continue
+		}
+		content, err := v.reader.ReadFile(filepath.Join(modulesDir, file))
+		if err != nil {
+			continue // reported by FileExistenceValidator
+		}
+		check(mod.Name, "module body", content)
+	}
+	return findings
+}
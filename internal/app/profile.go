@@ -0,0 +1,38 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+)
+
+// Selection describes which modules of a manifest a command works on.
+type Selection struct {
+	Profile  string                // explicit profile name
+	Hostname string                // hostname for automatic profile selection ("" disables it)
+	Tags     domain.ModuleSelector // --tag / --exclude-tag, applied after the profile
+}
+
+// SelectModules narrows manifest to the profile named explicitly, or to the
+// one whose host patterns match the hostname when no name is given, and then
+// to the tag selection. It returns the manifest to use and the applied
+// profile name ("" when none applies). Hard dependencies of selected modules
+// are always kept.
+func SelectModules(manifest *domain.Manifest, sel Selection) (*domain.Manifest, string, error) {
+	profileName, profile, err := manifest.ResolveProfile(sel.Profile, sel.Hostname)
+	if err != nil {
+		return nil, "", err
+	}
+	if profile != nil {
+		manifest, err = profile.Select(manifest)
+		if err != nil {
+			return nil, "", fmt.Errorf("profile '%s': %w", profileName, err)
+		}
+	}
+
+	manifest, err = sel.Tags.Select(manifest)
+	if err != nil {
+		return nil, "", fmt.Errorf("tag selection: %w", err)
+	}
+	return manifest, profileName, nil
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+)
+
+// ShellLauncher starts an interactive shell that exits once its startup
+// files are loaded.
+type ShellLauncher interface {
+	// Launch runs shell (as a login shell if login is set) with env as its
+	// whole environment and returns its output.
+	Launch(shell string, login bool, env []string) (string, error)
+}
+
+// profileLogName is the log the probes write to, inside the sandbox home.
+const profileLogName = ".shellforge-profile.log"
+
+// StartupReportService measures per-module load time of a build made with
+// BuildOptions.ProfileStartup, by deploying it into a sandbox home and
+// starting a shell there.
+type StartupReportService struct {
+	reader   FileReader
+	deployer *DeployService
+	launcher ShellLauncher
+}
+
+// NewStartupReportService creates a new startup report service.
+func NewStartupReportService(reader FileReader, deployer *DeployService, launcher ShellLauncher) *StartupReportService {
+	return &StartupReportService{
+		reader:   reader,
+		deployer: deployer,
+		launcher: launcher,
+	}
+}
+
+// StartupReportOptions contains options for measuring shell startup.
+type StartupReportOptions struct {
+	BuildDir string   // Directory containing the profiled build
+	Home     string   // Empty sandbox home directory to deploy into
+	Runs     int      // Number of shell startups to average (default: 1)
+	Login    bool     // Start a login shell, loading profile files too
+	Env      []string // Base environment (PATH, TERM, ...); HOME and the profile log are added
+}
+
+// StartupReport ranks modules by load time.
+type StartupReport struct {
+	Shell      string
+	Runs       int
+	Modules    []domain.ModuleTiming // slowest first, averaged over runs
+	Total      time.Duration         // sum of module load times
+	Wall       time.Duration         // average time from launch to exit, including the shell itself
+	Unmeasured []string              // built modules the shell never loaded
+}
+
+// Report deploys the build into opts.Home, starts the shell opts.Runs
+// times and collects the probes' measurements.
+func (s *StartupReportService) Report(opts StartupReportOptions) (*StartupReport, error) {
+	if opts.Runs < 1 {
+		opts.Runs = 1
+	}
+
+	metadata, err := s.readMetadata(opts.BuildDir)
+	if err != nil {
+		return nil, err
+	}
+	if !metadata.Profiling {
+		return nil, fmt.Errorf("%s was built without timing probes\n\nRun 'gz-shellforge build --profile-startup' first", opts.BuildDir)
+	}
+
+	if _, err := s.deployer.Deploy(DeployOptions{BuildDir: opts.BuildDir, HomeDir: opts.Home}); err != nil {
+		return nil, fmt.Errorf("failed to deploy into sandbox home: %w", err)
+	}
+
+	logPath := filepath.Join(opts.Home, profileLogName)
+	env := append(slices.Clone(opts.Env), "HOME="+opts.Home, domain.StartupProfileEnv+"="+logPath)
+
+	var wall time.Duration
+	for range opts.Runs {
+		start := time.Now()
+		out, err := s.launcher.Launch(metadata.Shell, opts.Login, env)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n%s", err, out)
+		}
+		wall += time.Since(start)
+	}
+
+	log := ""
+	if s.reader.FileExists(logPath) {
+		if log, err = s.reader.ReadFile(logPath); err != nil {
+			return nil, fmt.Errorf("failed to read profile log: %w", err)
+		}
+	}
+	timings, err := domain.ParseStartupProfile(log)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StartupReport{
+		Shell:   metadata.Shell,
+		Runs:    opts.Runs,
+		Modules: timings,
+		Wall:    wall / time.Duration(opts.Runs),
+	}
+	measured := make(map[string]bool, len(timings))
+	for _, t := range timings {
+		report.Total += t.Duration
+		measured[t.Module] = true
+	}
+	for _, file := range metadata.Files {
+		for _, span := range file.Sources {
+			if !measured[span.Module] {
+				report.Unmeasured = append(report.Unmeasured, span.Module)
+			}
+		}
+	}
+	return report, nil
+}
+
+// readMetadata loads the build metadata of buildDir.
+func (s *StartupReportService) readMetadata(buildDir string) (*domain.BuildMetadata, error) {
+	metaPath := filepath.Join(buildDir, domain.MetadataFileName)
+	if !s.reader.FileExists(metaPath) {
+		return nil, fmt.Errorf("metadata file not found: %s\n\nRun 'gz-shellforge build --profile-startup' first", metaPath)
+	}
+	metaContent, err := s.reader.ReadFile(metaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	metadata, err := domain.ParseBuildMetadata([]byte(metaContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	return metadata, nil
+}
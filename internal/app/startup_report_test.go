@@ -0,0 +1,89 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/filesystem"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/yamlparser"
+)
+
+// fakeLauncher appends canned probe lines to the profile log on each launch.
+type fakeLauncher struct {
+	fs       afero.Fs
+	lines    []string
+	launches int
+	env      []string
+}
+
+func (f *fakeLauncher) Launch(shell string, login bool, env []string) (string, error) {
+	f.launches++
+	f.env = env
+	for _, kv := range env {
+		if path, ok := strings.CutPrefix(kv, domain.StartupProfileEnv+"="); ok {
+			existing, _ := afero.ReadFile(f.fs, path)
+			afero.WriteFile(f.fs, path, append(existing, []byte(strings.Join(f.lines, "\n")+"\n")...), 0o644)
+		}
+	}
+	return "", nil
+}
+
+func TestStartupReportService_Report(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`modules:
+  - name: nvm
+    file: nvm.sh
+  - name: aliases
+    file: aliases.sh
+  - name: logout
+    file: logout.sh
+    target: zlogout
+`), 0o644)
+	for _, name := range []string{"nvm", "aliases", "logout"} {
+		afero.WriteFile(fs, name+".sh", []byte("echo "+name), 0o644)
+	}
+
+	reader := filesystem.NewReader(fs)
+	writer := filesystem.NewWriter(fs)
+	builder := NewBuilderService(yamlparser.New(fs), reader, writer)
+	deployer := NewDeployServiceWithChecker(reader, writer, &MockPermissionChecker{})
+
+	launcher := &fakeLauncher{fs: fs, lines: []string{
+		"shellforge-profile\tnvm\tzshrc\t100.000000\t100.400000",
+		"shellforge-profile\taliases\tzshrc\t100,400000\t100,410000",
+	}}
+	svc := NewStartupReportService(reader, deployer, launcher)
+
+	// A build without probes is refused
+	_, err := builder.Build(BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OutputDir: "build", OS: "Mac"})
+	require.NoError(t, err)
+	_, err = svc.Report(StartupReportOptions{BuildDir: "build", Home: "/sandbox"})
+	assert.ErrorContains(t, err, "--profile-startup")
+
+	_, err = builder.Build(BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OutputDir: "build", OS: "Mac", ProfileStartup: true})
+	require.NoError(t, err)
+
+	report, err := svc.Report(StartupReportOptions{BuildDir: "build", Home: "/sandbox", Runs: 3, Login: true, Env: []string{"PATH=/bin"}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, launcher.launches)
+	assert.Contains(t, launcher.env, "HOME=/sandbox")
+	assert.Contains(t, launcher.env, "PATH=/bin")
+
+	assert.Equal(t, "zsh", report.Shell)
+	require.Len(t, report.Modules, 2)
+	assert.Equal(t, "nvm", report.Modules[0].Module)
+	assert.InDelta(t, 400*time.Millisecond, report.Modules[0].Duration, float64(time.Millisecond))
+	assert.InDelta(t, 10*time.Millisecond, report.Modules[1].Duration, float64(time.Millisecond))
+	assert.InDelta(t, 410*time.Millisecond, report.Total, float64(time.Millisecond))
+	assert.Equal(t, []string{"logout"}, report.Unmeasured)
+
+	deployed, err := afero.ReadFile(fs, "/sandbox/.zshrc")
+	require.NoError(t, err)
+	assert.Contains(t, string(deployed), "zmodload zsh/datetime")
+}
@@ -1,10 +1,12 @@
 package app_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/gizzahub/gzh-cli-shellforge/internal/app"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain/shellmeta"
 )
 
 // --- Validator names ---
@@ -17,7 +19,12 @@ func TestValidatorNames(t *testing.T) {
 	}{
 		{app.ManifestStructureValidator{}, "manifest-structure"},
 		{app.CircularDependencyValidator{}, "circular-dependencies"},
+		{app.ExcludedDependencyValidator{}, "excluded-dependencies"},
+		{app.LoadOrderValidator{}, "load-order"},
+		{app.PriorityConflictValidator{}, "priority-conflicts"},
+		{app.SelectionValidator{}, "selection"},
 		{app.NewFileExistenceValidator(reader), "file-existence"},
+		{app.NewVariableValidator(reader), "variables"},
 	}
 	for _, c := range cases {
 		if c.v.Name() != c.want {
@@ -84,6 +91,21 @@ func TestManifestStructureValidator_NonExistentDep(t *testing.T) {
 	}
 }
 
+func TestManifestStructureValidator_UnknownOptionalDep(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "a", File: "a.sh", RequiresOptional: []string{"ghost"}},
+	})
+
+	findings := app.ManifestStructureValidator{}.Validate(m, "")
+
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %v", findings)
+	}
+	if findings[0].Severity != app.SeverityWarn {
+		t.Errorf("expected warn severity, got %q", findings[0].Severity)
+	}
+}
+
 // --- CircularDependencyValidator ---
 
 func TestCircularDependencyValidator_NoCycle(t *testing.T) {
@@ -130,6 +152,31 @@ func TestCircularDependencyValidator_IndirectCycle(t *testing.T) {
 	}
 }
 
+func TestCircularDependencyValidator_ReportsEachCyclePath(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "innocent", File: "i.sh", Requires: []string{"a"}},
+		{Name: "a", File: "a.sh", Requires: []string{"b"}, Location: domain.SourceLocation{File: "manifest.yaml", Line: 5}},
+		{Name: "b", File: "b.sh", Requires: []string{"a"}, Location: domain.SourceLocation{File: "manifest.yaml", Line: 8}},
+		{Name: "c", File: "c.sh", Requires: []string{"d"}},
+		{Name: "d", File: "d.sh", Requires: []string{"c"}},
+	})
+
+	findings := app.CircularDependencyValidator{}.Validate(m, "")
+
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %v", findings)
+	}
+	if want := "circular dependency: a (manifest.yaml:5) → b (manifest.yaml:8) → a"; findings[0].Message != want {
+		t.Errorf("first finding = %q, want %q", findings[0].Message, want)
+	}
+	if findings[0].Module != "a" {
+		t.Errorf("expected module 'a', got %q", findings[0].Module)
+	}
+	if want := "circular dependency: c → d → c"; findings[1].Message != want {
+		t.Errorf("second finding = %q, want %q", findings[1].Message, want)
+	}
+}
+
 func TestCircularDependencyValidator_SelfDependency(t *testing.T) {
 	m := makeManifest([]domain.Module{
 		{Name: "a", File: "a.sh", Requires: []string{"a"}},
@@ -142,14 +189,214 @@ func TestCircularDependencyValidator_SelfDependency(t *testing.T) {
 	}
 }
 
+// --- ExcludedDependencyValidator ---
+
+func TestExcludedDependencyValidator_HardAndOptional(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "brew", File: "brew.sh", OS: []string{"Mac"}},
+		{Name: "apt", File: "apt.sh", OS: []string{"Linux"}},
+		{Name: "tools", File: "tools.sh", OS: []string{"Linux"}, Requires: []string{"brew"}},
+		{Name: "shared", File: "shared.sh", RequiresOptional: []string{"brew", "apt"}},
+	})
+
+	findings := app.ExcludedDependencyValidator{}.Validate(m, "")
+
+	var errs, warns []app.Finding
+	for _, f := range findings {
+		if f.IsError() {
+			errs = append(errs, f)
+		} else {
+			warns = append(warns, f)
+		}
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	if errs[0].Module != "tools" || !strings.Contains(errs[0].Message, "'brew'") || !strings.Contains(errs[0].Message, "excluded on Linux") {
+		t.Errorf("unexpected error finding: %+v", errs[0])
+	}
+
+	// shared → apt skipped on Mac, shared → brew skipped on Linux
+	if len(warns) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", warns)
+	}
+	if !strings.Contains(warns[0].Message, "'apt' is skipped on Mac") {
+		t.Errorf("unexpected first warning: %q", warns[0].Message)
+	}
+	if !strings.Contains(warns[1].Message, "'brew' is skipped on Linux") {
+		t.Errorf("unexpected second warning: %q", warns[1].Message)
+	}
+}
+
+func TestExcludedDependencyValidator_NoOSFilters(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "a", File: "a.sh"},
+		{Name: "b", File: "b.sh", Requires: []string{"a"}},
+	})
+
+	if findings := (app.ExcludedDependencyValidator{}).Validate(m, ""); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+// --- PriorityConflictValidator ---
+
+func TestPriorityConflictValidator(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "path", File: "path.sh", Priority: 60},
+		{Name: "env", File: "env.sh", Target: "zshenv", Requires: []string{"path"}},
+		{Name: "prompt", File: "prompt.sh", Priority: 10, Requires: []string{"env"}},
+		{Name: "git", File: "git.sh", Priority: 70, Requires: []string{"path"}},
+	})
+
+	findings := app.PriorityConflictValidator{}.Validate(m, "")
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %v", findings)
+	}
+	want := "priority 10 is lower than priority 60 of 'path', which it requires in target 'zshrc'; 'path' still loads first"
+	if findings[0].Module != "prompt" || findings[0].IsError() || findings[0].Message != want {
+		t.Errorf("unexpected finding: %+v", findings[0])
+	}
+}
+
+// --- LoadOrderValidator ---
+
+// testLoadModes mirrors load_priority.modes in data/shell-profiles/core.yaml.
+var testLoadModes = map[string]shellmeta.LoadModes{
+	"zsh": {
+		Login:          []shellmeta.LoadStep{{"/etc/zsh/zshenv"}, {"~/.zshenv"}, {"~/.zprofile"}, {"/etc/zshrc"}, {"~/.zshrc"}, {"~/.zlogin"}},
+		Interactive:    []shellmeta.LoadStep{{"/etc/zsh/zshenv"}, {"~/.zshenv"}, {"/etc/zshrc"}, {"~/.zshrc"}},
+		NonInteractive: []shellmeta.LoadStep{{"/etc/zsh/zshenv"}, {"~/.zshenv"}},
+	},
+	"bash": {
+		Login:       []shellmeta.LoadStep{{"/etc/profile"}, {"~/.bash_profile", "~/.bash_login", "~/.profile"}},
+		Interactive: []shellmeta.LoadStep{{"~/.bashrc"}},
+	},
+}
+
+func TestLoadOrderValidator_Zsh(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "os-detection", File: "a.sh", Target: "zshenv"},
+		{Name: "brew-path", File: "b.sh", Target: "zprofile", Requires: []string{"os-detection"}},
+		{Name: "gcloud", File: "c.sh", Target: "zshrc", Requires: []string{"brew-path"}},
+		{Name: "prompt", File: "d.sh", Requires: []string{"os-detection"}},
+		{Name: "editor", File: "e.sh", Target: "zshenv", Requires: []string{"prompt"}},
+		{Name: "motd", File: "f.sh", Target: "zlogin", Requires: []string{"prompt"}},
+	})
+
+	findings := app.LoadOrderValidator{Modes: testLoadModes}.Validate(m, "")
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %v", findings)
+	}
+
+	// zprofile is only read by login shells
+	if findings[0].Module != "gcloud" || findings[0].IsError() ||
+		findings[0].Message != "requires 'brew-path' from target 'zprofile', which interactive non-login shells do not load" {
+		t.Errorf("unexpected first finding: %+v", findings[0])
+	}
+	// zshrc (the default target) is read after zshenv, and not at all by scripts
+	if findings[1].Module != "editor" || !findings[1].IsError() ||
+		findings[1].Message != "requires 'prompt' from target 'zshrc', which login and interactive non-login shells load after 'zshenv'" {
+		t.Errorf("unexpected second finding: %+v", findings[1])
+	}
+	if findings[2].Module != "editor" || findings[2].IsError() ||
+		!strings.Contains(findings[2].Message, "which non-interactive shells do not load") {
+		t.Errorf("unexpected third finding: %+v", findings[2])
+	}
+}
+
+func TestLoadOrderValidator_BashReadsFirstLoginFile(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "base", File: "a.sh", Target: "profile"},
+		{Name: "path", File: "b.sh", Target: "bash_profile", Requires: []string{"base"}},
+	})
+	m.Shell.Type = "bash"
+
+	findings := app.LoadOrderValidator{Modes: testLoadModes}.Validate(m, "")
+	if len(findings) != 1 || findings[0].IsError() ||
+		findings[0].Message != "requires 'base' from target 'profile', which login shells do not load" {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+}
+
+func TestLoadOrderValidator_SourcedTargets(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "env", File: "a.sh", Target: "env"},
+		{Name: "prompt", File: "b.sh", Target: "zshrc", Requires: []string{"env"}},
+		{Name: "plugins", File: "c.sh", Target: "zshrc.d", Requires: []string{"env"}},
+	})
+	m.Targets = map[string]domain.CustomTarget{"env": {Path: ".config/shell/env.sh", SourcedBy: "zshrc"}}
+
+	// env is sourced at the end of .zshrc, before zshrc.d (sorted loaders)
+	findings := app.LoadOrderValidator{Modes: testLoadModes}.Validate(m, "")
+	if len(findings) != 1 || findings[0].Module != "prompt" || !findings[0].IsError() ||
+		!strings.Contains(findings[0].Message, "load after 'zshrc'") {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+
+	m.Targets["env"] = domain.CustomTarget{Path: "env.sh", SourcedBy: "bashrc"}
+	findings = app.LoadOrderValidator{Modes: testLoadModes}.Validate(m, "")
+	if len(findings) != 1 || !findings[0].IsError() || !strings.Contains(findings[0].Message, "sourced by 'bashrc'") {
+		t.Errorf("expected invalid sourced_by error, got %v", findings)
+	}
+}
+
+func TestLoadOrderValidator_UnknownShell(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "a", File: "a.sh", Target: "zshrc"},
+		{Name: "b", File: "b.sh", Target: "zshenv", Requires: []string{"a"}},
+	})
+	m.Shell.Type = "fish"
+
+	if findings := (app.LoadOrderValidator{Modes: testLoadModes}).Validate(m, ""); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+// --- SelectionValidator ---
+
+func TestSelectionValidator_ProfilesAndTags(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "path", File: "path.sh", Tags: []string{"core"}},
+		{Name: "proxy", File: "proxy.sh", Tags: []string{"work"}, Requires: []string{"path"}},
+	})
+	m.Profiles = map[string]domain.Profile{
+		"broken": {ModuleSelector: domain.ModuleSelector{IncludeTags: []string{"work"}, ExcludeTags: []string{"core"}}},
+		"fine":   {ModuleSelector: domain.ModuleSelector{IncludeTags: []string{"work"}}},
+	}
+
+	v := app.SelectionValidator{Extra: domain.ModuleSelector{IncludeTags: []string{"wrok"}}}
+	findings := v.Validate(m, "")
+
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %v", findings)
+	}
+	if !findings[0].IsError() || !strings.Contains(findings[0].Message, "profile 'broken': module 'proxy' requires 'path'") {
+		t.Errorf("unexpected first finding: %+v", findings[0])
+	}
+	if findings[1].IsError() || findings[1].Message != "tag selection: tag 'wrok' matches no module" {
+		t.Errorf("unexpected second finding: %+v", findings[1])
+	}
+}
+
+func TestSelectionValidator_NoSelections(t *testing.T) {
+	m := makeManifest([]domain.Module{{Name: "a", File: "a.sh"}})
+
+	if findings := (app.SelectionValidator{}).Validate(m, ""); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
 // --- FileExistenceValidator ---
 
 // mockFileReader lets tests control which files "exist".
 type mockFileReader struct {
 	existing map[string]bool
+	contents map[string]string
 }
 
-func (m mockFileReader) ReadFile(path string) (string, error) { return "", nil }
+func (m mockFileReader) ReadFile(path string) (string, error) { return m.contents[path], nil }
 func (m mockFileReader) FileExists(path string) bool          { return m.existing[path] }
 
 func TestFileExistenceValidator_AllExist(t *testing.T) {
@@ -189,6 +436,97 @@ func TestFileExistenceValidator_MissingFile(t *testing.T) {
 	}
 }
 
+func TestFileExistenceValidator_InterpolatesPaths(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "brew", File: "{{ .Vars.brew }}.sh"},
+		{Name: "broken", File: "{{ .Vars.missing }}.sh"},
+	})
+	m.Vars = map[string]string{"brew": "homebrew"}
+	reader := mockFileReader{existing: map[string]bool{"modules/homebrew.sh": true}}
+
+	// Uninterpolatable paths are left to VariableValidator
+	if findings := app.NewFileExistenceValidator(reader).Validate(m, "modules"); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+func TestFileExistenceValidator_SkipsInlineModules(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "aliases", Content: "alias g=git"},
+		{Name: "paths", Generate: &domain.ModuleGenerator{Path: []string{"~/bin"}}},
+		{Name: "local-bin", Path: []domain.PathRule{{Prepend: []string{"~/bin"}}}},
+	})
+	reader := mockFileReader{existing: map[string]bool{}}
+
+	if findings := app.NewFileExistenceValidator(reader).Validate(m, "modules"); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+// --- VariableValidator ---
+
+func TestVariableValidator_UndefinedVars(t *testing.T) {
+	interpolate := true
+	m := makeManifest([]domain.Module{
+		{Name: "brew", File: "{{ .Vars.brew }}.sh", RequiresPath: []string{"{{ .Vars.prefix }}/bin"}},
+		{Name: "proxy", File: "proxy.sh", Interpolate: &interpolate},
+		{Name: "raw", File: "raw.sh"},
+		{Name: "bad", File: "{{ .Vars.brew "},
+	})
+	m.Vars = map[string]string{"brew": "homebrew"}
+	m.Profiles = map[string]domain.Profile{"work": {Vars: map[string]string{"proxy": "http://p"}}}
+	reader := mockFileReader{
+		existing: map[string]bool{"modules/proxy.sh": true, "modules/raw.sh": true},
+		contents: map[string]string{
+			"modules/proxy.sh": `export HTTP_PROXY="{{ .Vars.proxy }}" NO_PROXY="{{ .Vars.no_proxy }}"`,
+			"modules/raw.sh":   `echo "{{ .Vars.ignored }}"`,
+		},
+	}
+
+	findings := app.NewVariableValidator(reader).Validate(m, "modules")
+
+	want := []string{
+		"brew: undefined variable 'prefix' in requires_path",
+		"proxy: undefined variable 'no_proxy' in module body",
+		"bad: invalid template in file",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %v", len(want), findings)
+	}
+	for i, f := range findings {
+		if !f.IsError() || !strings.HasPrefix(f.Module+": "+f.Message, want[i]) {
+			t.Errorf("finding %d = %+v, want prefix %q", i, f, want[i])
+		}
+	}
+}
+
+func TestVariableValidator_InlineModules(t *testing.T) {
+	interpolate := true
+	m := makeManifest([]domain.Module{
+		{Name: "proxy", Content: `export HTTP_PROXY="{{ .Vars.proxy }}"`, Interpolate: &interpolate},
+		{Name: "env", Generate: &domain.ModuleGenerator{Env: map[string]string{"GOPATH": "{{ .Vars.gopath }}"}}, Interpolate: &interpolate},
+		{Name: "raw", Content: `echo "{{ .Vars.ignored }}"`},
+	})
+	m.Vars = map[string]string{"proxy": "http://p"}
+
+	findings := app.NewVariableValidator(mockFileReader{}).Validate(m, "modules")
+	if len(findings) != 1 || findings[0].Module != "env" || findings[0].Message != "undefined variable 'gopath' in module body" {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+}
+
+func TestVariableValidator_PathEntries(t *testing.T) {
+	m := makeManifest([]domain.Module{
+		{Name: "brew", Path: []domain.PathRule{{Prepend: []string{"{{ .Vars.brew }}/bin"}, Append: []string{"{{ .Vars.games }}"}}}},
+	})
+	m.Vars = map[string]string{"brew": "/opt/homebrew"}
+
+	findings := app.NewVariableValidator(mockFileReader{}).Validate(m, "modules")
+	if len(findings) != 1 || findings[0].Message != "undefined variable 'games' in path" {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+}
+
 // --- ValidationPipeline ---
 
 func TestValidationPipeline_Empty(t *testing.T) {
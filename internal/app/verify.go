@@ -0,0 +1,186 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SyntaxChecker parses a script with a shell's own parser without running it.
+type SyntaxChecker interface {
+	// Available reports whether the interpreter for shell is installed.
+	Available(shell string) bool
+	// Check returns the shell's diagnostics, empty if the script parses.
+	Check(shell, script string) (string, error)
+}
+
+// SyntaxError is a shell parse error traced back to the module it came from.
+type SyntaxError struct {
+	Target     string // generated target (e.g. "zshrc")
+	Module     string // originating module, empty if not attributable
+	File       string // module file, as written in the manifest
+	Line       int    // line in the generated file, 0 if unknown
+	ModuleLine int    // line in the module file, 0 if unknown
+	Message    string // the shell's diagnostic
+}
+
+func (e SyntaxError) Error() string {
+	switch {
+	case e.Module != "" && e.ModuleLine > 0:
+		return fmt.Sprintf("%s:%d (module '%s', %s line %d): %s", e.File, e.ModuleLine, e.Module, e.Target, e.Line, e.Message)
+	case e.Module != "":
+		return fmt.Sprintf("%s (module '%s', %s): %s", e.File, e.Module, e.Target, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("%s line %d: %s", e.Target, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Target, e.Message)
+}
+
+// VerifyResult is the outcome of checking a build's output with the shell.
+type VerifyResult struct {
+	Shell   string
+	Checked []string      // targets that were parsed
+	Skipped string        // why nothing was checked, empty if the shell ran
+	Errors  []SyntaxError // parse errors, in target order
+}
+
+// OK reports whether every checked target parsed cleanly.
+func (r *VerifyResult) OK() bool { return len(r.Errors) == 0 }
+
+// VerifySyntax parses every generated target the shell reads with the
+// build's shell (zsh -n, bash -n, fish --no-execute). When a target fails, each module's portion is
+// parsed on its own so the error points at the module and line that caused
+// it; errors only visible in the concatenation are mapped through the
+// target's source map. Verification is skipped, not failed, when the shell
+// is not installed.
+func VerifySyntax(result *BuildResult, checker SyntaxChecker) (*VerifyResult, error) {
+	vr := &VerifyResult{Shell: result.ShellType}
+	if !checker.Available(result.ShellType) {
+		vr.Skipped = fmt.Sprintf("%s is not installed", result.ShellType)
+		return vr, nil
+	}
+
+	for _, target := range result.Targets {
+		if target.NotSourced {
+			continue
+		}
+		out, err := checker.Check(result.ShellType, target.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", target.Target, err)
+		}
+		vr.Checked = append(vr.Checked, target.Target)
+		if out == "" {
+			continue
+		}
+
+		errs, err := attributeSyntaxErrors(result.ShellType, target, checker)
+		if err != nil {
+			return nil, err
+		}
+		if len(errs) == 0 {
+			errs = locateSyntaxErrors(target, out)
+		}
+		vr.Errors = append(vr.Errors, errs...)
+	}
+	return vr, nil
+}
+
+// attributeSyntaxErrors parses each module of target separately and reports
+// the ones that fail by themselves.
+func attributeSyntaxErrors(shell string, target TargetResult, checker SyntaxChecker) ([]SyntaxError, error) {
+	lines := strings.Split(target.Content, "\n")
+	var errs []SyntaxError
+	for _, span := range target.Sources {
+		if span.StartLine < 1 || span.EndLine() > len(lines) {
+			continue
+		}
+		body := strings.Join(lines[span.StartLine-1:span.EndLine()], "\n") + "\n"
+		out, err := checker.Check(shell, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check module '%s': %w", span.Module, err)
+		}
+		for _, d := range parseDiagnostics(out) {
+			se := SyntaxError{Target: target.Target, Module: span.Module, File: span.File, Message: d.message}
+			if d.line > 0 {
+				// A missing 'fi'/'end' is reported one past the last line
+				se.Line = span.StartLine + min(d.line, span.Lines) - 1
+				se.ModuleLine = span.ModuleLine(se.Line)
+			}
+			errs = append(errs, se)
+		}
+	}
+	return errs, nil
+}
+
+// locateSyntaxErrors maps diagnostics against the whole target through its
+// source map.
+func locateSyntaxErrors(target TargetResult, out string) []SyntaxError {
+	var errs []SyntaxError
+	for _, d := range parseDiagnostics(out) {
+		se := SyntaxError{Target: target.Target, Line: d.line, Message: d.message}
+		if span, moduleLine, ok := target.Sources.Locate(d.line); ok {
+			se.Module, se.File, se.ModuleLine = span.Module, span.File, moduleLine
+		}
+		errs = append(errs, se)
+	}
+	return errs
+}
+
+type diagnostic struct {
+	line    int
+	message string
+}
+
+// diagnosticPatterns match the line-numbered messages of each shell when the
+// script is read from stdin:
+//
+//	bash: line 3: syntax error: unexpected end of file
+//	zsh:3: parse error near `fi'
+//	- (line 3): 'end' outside of a block
+var diagnosticPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^[^:]*: line (\d+): (.*)$`),
+	regexp.MustCompile(`^[^:\s]*:(\d+): (.*)$`),
+	regexp.MustCompile(`\(line (\d+)\): (.*)$`),
+}
+
+// parseDiagnostics extracts one message per reported line. Continuation
+// lines (source excerpts, carets) are dropped; output with no recognisable
+// line number becomes a single unlocated diagnostic.
+func parseDiagnostics(out string) []diagnostic {
+	if out == "" {
+		return nil
+	}
+	var diags []diagnostic
+	seen := make(map[int]bool)
+	for _, text := range strings.Split(out, "\n") {
+		for _, re := range diagnosticPatterns {
+			m := re.FindStringSubmatch(strings.TrimSpace(text))
+			if m == nil {
+				continue
+			}
+			line, _ := strconv.Atoi(m[1])
+			if !seen[line] {
+				seen[line] = true
+				diags = append(diags, diagnostic{line: line, message: m[2]})
+			}
+			break
+		}
+	}
+	if len(diags) == 0 {
+		diags = append(diags, diagnostic{message: strings.TrimSpace(strings.SplitN(out, "\n", 2)[0])})
+	}
+	return diags
+}
+
+// SyntaxFindings converts a verification result to validation findings.
+func SyntaxFindings(vr *VerifyResult) []Finding {
+	if vr.Skipped != "" {
+		return []Finding{{Severity: SeverityWarn, Message: "syntax check skipped: " + vr.Skipped}}
+	}
+	findings := make([]Finding, 0, len(vr.Errors))
+	for _, se := range vr.Errors {
+		findings = append(findings, Finding{Severity: SeverityError, Module: se.Module, Message: "syntax error: " + se.Error()})
+	}
+	return findings
+}
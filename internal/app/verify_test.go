@@ -0,0 +1,186 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/filesystem"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/syntaxcheck"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/yamlparser"
+)
+
+type fakeSyntaxChecker struct {
+	available bool
+	check     func(script string) string
+}
+
+func (f fakeSyntaxChecker) Available(string) bool { return f.available }
+
+func (f fakeSyntaxChecker) Check(_, script string) (string, error) {
+	return f.check(script), nil
+}
+
+func TestVerifySyntax_Skipped(t *testing.T) {
+	vr, err := VerifySyntax(&BuildResult{ShellType: "zsh"}, fakeSyntaxChecker{available: false})
+	require.NoError(t, err)
+	assert.Equal(t, "zsh is not installed", vr.Skipped)
+	assert.True(t, vr.OK())
+	assert.Equal(t, "warn", SyntaxFindings(vr)[0].Severity)
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want []diagnostic
+	}{
+		{
+			name: "bash",
+			out:  "bash: line 2: syntax error near unexpected token `fi'\nbash: line 2: `fi'",
+			want: []diagnostic{{line: 2, message: "syntax error near unexpected token `fi'"}},
+		},
+		{
+			name: "zsh",
+			out:  "zsh:7: parse error near `fi'",
+			want: []diagnostic{{line: 7, message: "parse error near `fi'"}},
+		},
+		{
+			name: "fish",
+			out:  "- (line 3): 'end' outside of a block\nend\n^~^",
+			want: []diagnostic{{line: 3, message: "'end' outside of a block"}},
+		},
+		{
+			name: "no line number",
+			out:  "something went wrong\nmore",
+			want: []diagnostic{{message: "something went wrong"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseDiagnostics(tt.out))
+		})
+	}
+}
+
+func TestVerifySyntax_Bash(t *testing.T) {
+	checker := syntaxcheck.NewChecker()
+	if !checker.Available("bash") {
+		t.Skip("bash is not installed")
+	}
+
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`shell:
+  type: bash
+modules:
+  - name: good
+    file: good.sh
+    target: bashrc
+  - name: broken
+    file: broken.sh
+    target: bashrc
+  - name: profile
+    file: profile.sh
+    target: bash_profile
+`), 0o644)
+	afero.WriteFile(fs, "good.sh", []byte("alias ll='ls -l'\n"), 0o644)
+	afero.WriteFile(fs, "broken.sh", []byte("echo one\nif [ -d /tmp ]; then\n  echo two\n"), 0o644)
+	afero.WriteFile(fs, "profile.sh", []byte("export EDITOR=vi\n"), 0o644)
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+
+	result, err := builder.Build(BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OS: "Linux", DryRun: true})
+	require.NoError(t, err)
+
+	vr, err := VerifySyntax(result, checker)
+	require.NoError(t, err)
+	assert.Empty(t, vr.Skipped)
+	assert.ElementsMatch(t, []string{"bashrc", "bash_profile"}, vr.Checked)
+	require.Len(t, vr.Errors, 1)
+
+	se := vr.Errors[0]
+	assert.Equal(t, "bashrc", se.Target)
+	assert.Equal(t, "broken", se.Module)
+	assert.Equal(t, "broken.sh", se.File)
+	assert.Equal(t, 3, se.ModuleLine)
+	assert.Contains(t, se.Message, "unexpected end of file")
+
+	// The reported output line holds the module's line
+	for _, target := range result.Targets {
+		if target.Target == "bashrc" {
+			span, line, ok := target.Sources.Locate(se.Line)
+			require.True(t, ok)
+			assert.Equal(t, "broken", span.Module)
+			assert.Equal(t, 3, line)
+		}
+	}
+
+	findings := SyntaxFindings(vr)
+	require.Len(t, findings, 1)
+	assert.True(t, findings[0].IsError())
+	assert.Equal(t, "broken", findings[0].Module)
+}
+
+func TestVerifySyntax_Unattributed(t *testing.T) {
+	// Modules parse alone but not together: fall back to the source map
+	result := &BuildResult{
+		ShellType: "zsh",
+		Targets: []TargetResult{{
+			Target:  "zshrc",
+			Content: "# header\nif true; then\nfi\n",
+			Sources: domain.SourceMap{
+				{Module: "opens", File: "opens.zsh", StartLine: 2, Lines: 1, SourceLine: 1},
+				{Module: "closes", File: "closes.zsh", StartLine: 3, Lines: 1, SourceLine: 1},
+			},
+		}},
+	}
+
+	checker := fakeSyntaxChecker{available: true, check: func(script string) string {
+		if script == result.Targets[0].Content {
+			return "zsh:3: parse error near `fi'"
+		}
+		return ""
+	}}
+
+	vr, err := VerifySyntax(result, checker)
+	require.NoError(t, err)
+	require.Len(t, vr.Errors, 1)
+	assert.Equal(t, "closes", vr.Errors[0].Module)
+	assert.Equal(t, 1, vr.Errors[0].ModuleLine)
+	assert.Equal(t, 3, vr.Errors[0].Line)
+}
+
+func TestVerifySyntax_SkipsTargetsTheShellDoesNotRead(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`targets:
+  inputrc:
+    path: .inputrc
+modules:
+  - name: aliases
+    content: alias ll='ls -l'
+  - name: readline
+    content: |
+      $if Bash
+      set editing-mode vi
+      $endif
+    target: inputrc
+`), 0o644)
+	builder := NewBuilderService(yamlparser.New(fs), filesystem.NewReader(fs), filesystem.NewWriter(fs))
+
+	result, err := builder.Build(BuildOptions{ConfigDir: ".", Manifest: "manifest.yaml", OS: "Linux", DryRun: true})
+	require.NoError(t, err)
+
+	checker := fakeSyntaxChecker{available: true, check: func(script string) string {
+		if strings.Contains(script, "$if Bash") {
+			return "zsh:2: parse error near `$if'"
+		}
+		return ""
+	}}
+	vr, err := VerifySyntax(result, checker)
+	require.NoError(t, err)
+	assert.True(t, vr.OK())
+	assert.Equal(t, []string{"zshrc"}, vr.Checked)
+}
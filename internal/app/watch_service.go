@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ChangeDetector fingerprints files for change polling.
+type ChangeDetector interface {
+	// Fingerprint returns a stamp per file among paths (directories are
+	// walked); a file that changes gets a different stamp.
+	Fingerprint(paths []string) map[string]string
+}
+
+// WatchService rebuilds whenever the manifest, an included manifest or a
+// file under the module directory changes.
+type WatchService struct {
+	parser   ManifestParser
+	builder  *BuilderService
+	deployer *DeployService
+	detector ChangeDetector
+}
+
+// NewWatchService creates a new watch service. deployer may be nil when
+// WatchOptions.Deploy is never set.
+func NewWatchService(parser ManifestParser, builder *BuilderService, deployer *DeployService, detector ChangeDetector) *WatchService {
+	return &WatchService{
+		parser:   parser,
+		builder:  builder,
+		deployer: deployer,
+		detector: detector,
+	}
+}
+
+// WatchOptions contains options for watch mode.
+type WatchOptions struct {
+	Build    BuildOptions  // Options for every rebuild
+	Interval time.Duration // Polling interval (default: 500ms)
+	Debounce time.Duration // Files must be quiet this long before rebuilding (default: 200ms)
+
+	// Deploy, if set, deploys each successful build, typically to a
+	// sandbox home directory.
+	Deploy *DeployOptions
+}
+
+// WatchEvent reports one build of the watch loop.
+type WatchEvent struct {
+	Changed []string      // Watched files that changed; empty for the initial build
+	Result  *BuildResult  // Build result, nil if the build failed
+	Deploy  *DeployResult // Deploy result, if deploying
+	Err     error         // Build or deploy error; the loop keeps running
+}
+
+// Run builds once, then polls the watched files and rebuilds after every
+// change until ctx is cancelled. Build errors, such as a manifest saved
+// half-edited, are passed to report and do not stop the loop.
+func (s *WatchService) Run(ctx context.Context, opts WatchOptions, report func(WatchEvent)) error {
+	if opts.Interval <= 0 {
+		opts.Interval = 500 * time.Millisecond
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = 200 * time.Millisecond
+	}
+
+	paths := s.watchPaths(opts.Build, nil)
+	last := s.fingerprint(paths, opts.Build)
+	report(s.build(opts, nil))
+
+	for {
+		if !sleep(ctx, opts.Interval) {
+			return nil
+		}
+		current := s.fingerprint(paths, opts.Build)
+		if maps.Equal(current, last) {
+			continue
+		}
+
+		// Wait for the editor (or git checkout) to finish writing
+		for {
+			if !sleep(ctx, opts.Debounce) {
+				return nil
+			}
+			settled := s.fingerprint(paths, opts.Build)
+			if maps.Equal(settled, current) {
+				break
+			}
+			current = settled
+		}
+
+		changed := changedFiles(last, current)
+
+		// Includes may have been added or removed
+		paths = s.watchPaths(opts.Build, paths)
+		last = s.fingerprint(paths, opts.Build)
+
+		report(s.build(opts, changed))
+	}
+}
+
+// build runs one build and, if requested, deploy.
+func (s *WatchService) build(opts WatchOptions, changed []string) WatchEvent {
+	event := WatchEvent{Changed: changed}
+	event.Result, event.Err = s.builder.Build(opts.Build)
+	if event.Err != nil || opts.Deploy == nil || opts.Build.DryRun {
+		return event
+	}
+	event.Deploy, event.Err = s.deployer.Deploy(*opts.Deploy)
+	return event
+}
+
+// watchPaths returns the manifest, every manifest it includes and the
+// module directory. When the manifest does not parse, the previous
+// includes are kept so fixing them is still noticed.
+func (s *WatchService) watchPaths(opts BuildOptions, previous []string) []string {
+	paths := []string{opts.Manifest, opts.ConfigDir}
+	if manifest, err := s.parser.Parse(opts.Manifest); err == nil {
+		paths = append(paths, manifest.Sources...)
+	} else {
+		paths = append(paths, previous...)
+	}
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
+
+// fingerprint stamps the watched files, leaving out the build's own output
+// in case it lives inside the module directory.
+func (s *WatchService) fingerprint(paths []string, opts BuildOptions) map[string]string {
+	stamps := s.detector.Fingerprint(paths)
+	if opts.OutputDir == "" {
+		return stamps
+	}
+	outputDir := filepath.Clean(opts.OutputDir) + string(filepath.Separator)
+	for path := range stamps {
+		if strings.HasPrefix(filepath.Clean(path), outputDir) {
+			delete(stamps, path)
+		}
+	}
+	return stamps
+}
+
+// changedFiles returns the files added, removed or modified between two
+// fingerprints, sorted.
+func changedFiles(before, after map[string]string) []string {
+	var changed []string
+	for path, stamp := range after {
+		if before[path] != stamp {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// sleep waits for d and reports false if ctx was cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
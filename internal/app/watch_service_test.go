@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/filesystem"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/yamlparser"
+)
+
+func TestWatchService_Run(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte(`include: [extra.yaml]
+modules:
+  - name: a
+    file: a.sh
+`), 0o644)
+	afero.WriteFile(fs, "extra.yaml", []byte(`modules:
+  - name: env
+    file: env.sh
+    target: zshenv
+`), 0o644)
+	afero.WriteFile(fs, "modules/a.sh", []byte("echo a"), 0o644)
+	afero.WriteFile(fs, "modules/env.sh", []byte("export A=1"), 0o644)
+
+	parser := yamlparser.New(fs)
+	reader := filesystem.NewReader(fs)
+	writer := filesystem.NewWriter(fs)
+	watcher := NewWatchService(parser, NewBuilderService(parser, reader, writer),
+		NewDeployServiceWithChecker(reader, writer, &MockPermissionChecker{}), filesystem.NewFingerprinter(fs))
+
+	events := make(chan WatchEvent, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(ctx, WatchOptions{
+			Build: BuildOptions{
+				ConfigDir: "modules",
+				Manifest:  "manifest.yaml",
+				OutputDir: "modules/build", // output inside the watched tree is ignored
+				OS:        "Mac",
+			},
+			Interval: 5 * time.Millisecond,
+			Debounce: 10 * time.Millisecond,
+			Deploy:   &DeployOptions{BuildDir: "modules/build", HomeDir: "/sandbox"},
+		}, func(e WatchEvent) { events <- e })
+	}()
+
+	next := func() WatchEvent {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(2 * time.Second):
+			t.Fatal("no rebuild")
+			return WatchEvent{}
+		}
+	}
+
+	initial := next()
+	require.NoError(t, initial.Err)
+	assert.Empty(t, initial.Changed)
+	assert.Len(t, initial.Result.Changed(), 2)
+	require.NotNil(t, initial.Deploy)
+	deployed, _ := afero.ReadFile(fs, "/sandbox/.zshrc")
+	assert.Contains(t, string(deployed), "echo a")
+
+	// Module change rebuilds only the affected target
+	afero.WriteFile(fs, "modules/a.sh", []byte("echo changed"), 0o644)
+	e := next()
+	require.NoError(t, e.Err)
+	assert.Equal(t, []string{"modules/a.sh"}, e.Changed)
+	require.Len(t, e.Result.Changed(), 1)
+	assert.Equal(t, "zshrc", e.Result.Changed()[0].Target)
+
+	// A broken included manifest is reported, and watching continues
+	afero.WriteFile(fs, "extra.yaml", []byte("modules: [\n"), 0o644)
+	e = next()
+	assert.Error(t, e.Err)
+	assert.Equal(t, []string{"extra.yaml"}, e.Changed)
+
+	afero.WriteFile(fs, "extra.yaml", []byte(`modules:
+  - name: env
+    file: env.sh
+    target: zshenv
+    description: fixed
+`), 0o644)
+	e = next()
+	require.NoError(t, e.Err)
+	assert.Equal(t, []string{"extra.yaml"}, e.Changed)
+
+	cancel()
+	assert.NoError(t, <-done)
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected rebuild: %v", e.Changed)
+	default:
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	before := map[string]string{"a": "1", "b": "1", "c": "1"}
+	after := map[string]string{"a": "1", "b": "2", "d": "1"}
+	assert.Equal(t, []string{"b", "c", "d"}, changedFiles(before, after))
+}
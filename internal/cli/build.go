@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -14,14 +17,24 @@ import (
 )
 
 type buildFlags struct {
-	configDir string
-	manifest  string
-	targetOS  string
-	dryRun    bool
-	verbose   bool
-	outputDir string
-	shell     string
-	targets   []string
+	configDir    string
+	manifest     string
+	targetOS     string
+	dryRun       bool
+	verbose      bool
+	outputDir    string
+	shell        string
+	targets      []string
+	buildTime    string
+	vars         []string
+	allowMissing bool
+	verify       bool
+	noCache      bool
+	profileStart bool
+	watch        bool
+	interval     time.Duration
+	deployHome   string
+	selectionFlags
 }
 
 func newBuildCmd() *cobra.Command {
@@ -33,16 +46,51 @@ func newBuildCmd() *cobra.Command {
 		Long: `Build generates shell configuration files from modular components.
 
 Modules are grouped by their 'target' field (zshrc, zprofile, etc.)
-and written to separate RC files in the output directory.
+and written to separate RC files in the output directory. Modules without
+a target go to the shell's main rc file (zshrc, bashrc or fish config), so
+a manifest of portable 'generate:' modules builds for every shell. A module
+file or sourced script written for another shell (.sh or .zsh in fish,
+.fish in zsh) fails the build.
 
 The build process:
   1. Reads the manifest file
   2. Resolves module dependencies using topological sorting
   3. Filters modules by target OS (auto-detected if not specified)
+     and by profile (--profile, or the profile whose hosts match this machine)
+     and by each module's 'when:' conditions (arch, distro, hostname, env, shell)
   4. Groups modules by target RC file
   5. Sorts modules by priority within each target
   6. Writes the output files to the build directory
 
+Output is deterministic: modules that are not ordered by a dependency keep
+their manifest order. Pass --build-time or set SOURCE_DATE_EPOCH to pin the
+header timestamp and get byte-identical files across builds.
+
+A module whose file is missing, unreadable or fails to render fails the
+build, and no files are written; every broken module is listed. Pass
+--allow-missing to build anyway with a placeholder comment in their place.
+
+With --verify, each generated file is parsed with the target shell
+(zsh -n, bash -n or fish --no-execute) and parse errors are reported against
+the module file and line they come from. The check is skipped with a
+warning when the shell is not installed.
+
+Builds are incremental: a cache next to .shellforge-build.json records the
+SHA-256 of every module each file was built from. Files whose modules and
+manifest settings are unchanged, or whose regenerated content is identical,
+are left untouched. Pass --no-cache to regenerate every file.
+
+With --profile-startup, every module is wrapped in timing probes that
+append its load time to $SHELLFORGE_PROFILE_LOG; 'gz-shellforge
+startup-report' starts the built config in a clean shell and ranks modules
+by load time. Do not deploy a profiled build for everyday use.
+
+With --watch, the manifest, every included manifest and the --config-dir
+tree are polled, and the build reruns with the same options after each
+change (debounced). A failing build, such as a half-saved manifest, is
+reported and watching continues. --deploy-home deploys every successful
+build into a sandbox home directory. Stop with Ctrl-C.
+
 Use 'gz-shellforge deploy' to copy built files to their actual paths.`,
 		Example: `  # Build to default ./build/ directory (OS auto-detected)
   gz-shellforge build
@@ -56,12 +104,39 @@ Use 'gz-shellforge deploy' to copy built files to their actual paths.`,
   # Build for bash shell
   gz-shellforge build --shell bash
 
+  # Build the "work" profile from the manifest
+  gz-shellforge build --profile work
+
+  # Minimal server build: only "core" modules and what they require
+  gz-shellforge build --tag core --exclude-tag gui
+
+  # Override a manifest variable
+  gz-shellforge build --var proxy=http://proxy.corp:3128
+
   # Build only specific targets
   gz-shellforge build --target zshrc --target zprofile
 
+  # Check the generated files parse with the target shell
+  gz-shellforge build --verify
+
+  # Build even if some module files are missing
+  gz-shellforge build --allow-missing
+
+  # Regenerate every file, ignoring the build cache
+  gz-shellforge build --no-cache
+
+  # Find the modules that slow down shell startup
+  gz-shellforge build --profile-startup && gz-shellforge startup-report
+
+  # Rebuild on every module or manifest change, deploying to a sandbox home
+  gz-shellforge build --watch --deploy-home /tmp/shellforge-home
+
   # Build to custom directory
   gz-shellforge build --output-dir ~/staging
 
+  # Reproducible build (fixed timestamp, also honours SOURCE_DATE_EPOCH)
+  gz-shellforge build --build-time 2024-01-01T00:00:00Z
+
   # Full workflow: build then deploy
   gz-shellforge build && gz-shellforge deploy --backup`,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -76,6 +151,16 @@ Use 'gz-shellforge deploy' to copy built files to their actual paths.`,
 	cmd.Flags().StringVarP(&flags.outputDir, "output-dir", "d", "", "Output directory (default: ./build)")
 	cmd.Flags().StringVarP(&flags.shell, "shell", "s", "", "Shell type (zsh, bash, fish)")
 	cmd.Flags().StringArrayVarP(&flags.targets, "target", "t", nil, "Specific targets to build (can be repeated)")
+	addSelectionFlags(cmd, &flags.selectionFlags, "Manifest profile to apply (default: matched by hostname)")
+	cmd.Flags().StringArrayVar(&flags.vars, "var", nil, "Set a manifest variable, name=value (can be repeated)")
+	cmd.Flags().BoolVar(&flags.verify, "verify", false, "Parse generated files with the target shell and fail on syntax errors")
+	cmd.Flags().BoolVar(&flags.allowMissing, "allow-missing", false, "Build with placeholders for missing or unreadable module files instead of failing")
+	cmd.Flags().BoolVar(&flags.profileStart, "profile-startup", false, "Wrap modules in timing probes for 'startup-report'")
+	cmd.Flags().BoolVarP(&flags.watch, "watch", "w", false, "Rebuild whenever the manifest or a module file changes")
+	cmd.Flags().DurationVar(&flags.interval, "interval", 500*time.Millisecond, "Polling interval for --watch")
+	cmd.Flags().StringVar(&flags.deployHome, "deploy-home", "", "With --watch, deploy each build into this sandbox home directory")
+	cmd.Flags().BoolVar(&flags.noCache, "no-cache", false, "Ignore the build cache and regenerate every file")
+	cmd.Flags().StringVar(&flags.buildTime, "build-time", "", "Fixed build timestamp, RFC3339 or Unix seconds (default: $SOURCE_DATE_EPOCH or now)")
 
 	// Common options
 	cmd.Flags().StringVarP(&flags.configDir, "config-dir", "c", "modules", "Directory containing module files")
@@ -109,6 +194,17 @@ func runBuild(flags *buildFlags) error {
 		homeDir = ""
 	}
 
+	// Resolve fixed timestamp for reproducible builds
+	buildTime, err := helpers.ResolveBuildTime(flags.buildTime)
+	if err != nil {
+		return err
+	}
+
+	vars, err := helpers.ParseVars(flags.vars)
+	if err != nil {
+		return err
+	}
+
 	// Verbose output
 	if flags.verbose {
 		printBuildHeader(flags)
@@ -120,15 +216,24 @@ func runBuild(flags *buildFlags) error {
 
 	// Build options
 	opts := app.BuildOptions{
-		ConfigDir: flags.configDir,
-		Manifest:  flags.manifest,
-		OS:        flags.targetOS,
-		DryRun:    flags.dryRun,
-		Verbose:   flags.verbose,
-		OutputDir: flags.outputDir,
-		Shell:     flags.shell,
-		Targets:   flags.targets,
-		HomeDir:   homeDir,
+		ConfigDir:      flags.configDir,
+		Manifest:       flags.manifest,
+		OS:             flags.targetOS,
+		DryRun:         flags.dryRun,
+		Verbose:        flags.verbose,
+		OutputDir:      flags.outputDir,
+		Shell:          flags.shell,
+		Targets:        flags.targets,
+		HomeDir:        homeDir,
+		BuildTime:      buildTime,
+		Profile:        flags.profile,
+		Hostname:       helpers.DetectHostname(),
+		Tags:           flags.tagSelector(),
+		Vars:           vars,
+		Host:           detectHostFacts(flags.targetOS, flags.shell),
+		AllowMissing:   flags.allowMissing,
+		NoCache:        flags.noCache,
+		ProfileStartup: flags.profileStart,
 	}
 
 	// Expand output directory path
@@ -140,12 +245,21 @@ func runBuild(flags *buildFlags) error {
 		opts.OutputDir = expanded
 	}
 
+	if flags.watch {
+		return runWatch(flags, services, opts)
+	}
+	if flags.deployHome != "" {
+		return fmt.Errorf("--deploy-home requires --watch")
+	}
+
 	// Execute build
 	result, err := builder.Build(opts)
 	if err != nil {
 		return clierrors.WrapError("build", err)
 	}
 
+	printSkippedModules(result)
+
 	// Display results
 	if flags.dryRun {
 		printDryRunResult(flags, result)
@@ -153,9 +267,102 @@ func runBuild(flags *buildFlags) error {
 		printBuildResult(flags, result)
 	}
 
+	if flags.verify {
+		return verifyBuild(services, result)
+	}
 	return nil
 }
 
+// runWatch rebuilds on every change until interrupted.
+func runWatch(flags *buildFlags, services *factory.Services, opts app.BuildOptions) error {
+	watchOpts := app.WatchOptions{Build: opts, Interval: flags.interval}
+	if flags.deployHome != "" {
+		if flags.dryRun {
+			return fmt.Errorf("--deploy-home cannot be used with --dry-run")
+		}
+		home, err := helpers.ExpandHomePath(flags.deployHome)
+		if err != nil {
+			return clierrors.InvalidPath("deploy-home", err)
+		}
+		watchOpts.Deploy = &app.DeployOptions{BuildDir: opts.OutputDir, HomeDir: home}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("👀 Watching %s and %s (Ctrl-C to stop)\n", flags.manifest, flags.configDir)
+	return services.NewWatcher().Run(ctx, watchOpts, func(event app.WatchEvent) {
+		printWatchEvent(flags, services, event)
+	})
+}
+
+// printWatchEvent prints a compact summary of one watch rebuild.
+func printWatchEvent(flags *buildFlags, services *factory.Services, event app.WatchEvent) {
+	stamp := time.Now().Format("15:04:05")
+	if len(event.Changed) > 0 {
+		fmt.Printf("\n[%s] changed: %s\n", stamp, strings.Join(event.Changed, ", "))
+	}
+	if event.Result == nil {
+		fmt.Fprintf(os.Stderr, "[%s] ✗ build failed: %v\n", stamp, event.Err)
+		return
+	}
+
+	printSkippedModules(event.Result)
+	var changed []string
+	for _, target := range event.Result.Changed() {
+		changed = append(changed, target.Target+" ("+target.FilePath+")")
+	}
+	fmt.Printf("[%s] ✓ built: %d changed, %d unchanged\n", stamp, len(changed), len(event.Result.Unchanged()))
+	for _, target := range changed {
+		fmt.Printf("  • %s\n", target)
+	}
+
+	if event.Err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] ✗ deploy failed: %v\n", stamp, event.Err)
+	} else if event.Deploy != nil {
+		fmt.Printf("[%s] ✓ deployed %d file(s) to %s\n", stamp, event.Deploy.DeployedCount, flags.deployHome)
+	}
+
+	if flags.verify {
+		if err := verifyBuild(services, event.Result); err != nil {
+			fmt.Fprintf(os.Stderr, "[%s] ✗ %v\n", stamp, err)
+		}
+	}
+}
+
+// verifyBuild parses the generated files with the target shell.
+func verifyBuild(services *factory.Services, result *app.BuildResult) error {
+	vr, err := app.VerifySyntax(result, services.Syntax)
+	if err != nil {
+		return clierrors.WrapError("syntax verification", err)
+	}
+	if vr.Skipped != "" {
+		fmt.Fprintf(os.Stderr, "⚠️  Syntax check skipped: %s\n", vr.Skipped)
+		return nil
+	}
+	if vr.OK() {
+		fmt.Printf("✓ Syntax OK (%s): %s\n", vr.Shell, strings.Join(vr.Checked, ", "))
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "✗ Syntax errors (%d):\n", len(vr.Errors))
+	for _, se := range vr.Errors {
+		fmt.Fprintf(os.Stderr, "  - %s\n", se.Error())
+	}
+	return fmt.Errorf("syntax verification failed with %d error(s)", len(vr.Errors))
+}
+
+// printSkippedModules warns about modules left out under --allow-missing.
+func printSkippedModules(result *app.BuildResult) {
+	if len(result.Errors) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "⚠️  %d module(s) skipped:\n", len(result.Errors))
+	for _, modErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "  - %s\n", modErr.Error())
+	}
+}
+
 func printBuildHeader(flags *buildFlags) {
 	fmt.Printf("Building shell configuration...\n")
 	fmt.Printf("  Manifest: %s\n", flags.manifest)
@@ -170,6 +377,12 @@ func printBuildHeader(flags *buildFlags) {
 	if len(flags.targets) > 0 {
 		fmt.Printf("  Targets: %v\n", flags.targets)
 	}
+	if flags.profile != "" {
+		fmt.Printf("  Profile: %s\n", flags.profile)
+	}
+	if len(flags.tags) > 0 || len(flags.excludeTags) > 0 {
+		fmt.Printf("  Tags: %v, excluded: %v\n", flags.tags, flags.excludeTags)
+	}
 	if flags.dryRun {
 		fmt.Printf("  Dry run: yes (no files will be written)\n")
 	}
@@ -181,6 +394,9 @@ func printDryRunResult(flags *buildFlags, result *app.BuildResult) {
 		fmt.Printf("✓ Build preview completed\n")
 		fmt.Printf("  Shell: %s\n", result.ShellType)
 		fmt.Printf("  OS: %s\n", result.TargetOS)
+		if result.Profile != "" {
+			fmt.Printf("  Profile: %s\n", result.Profile)
+		}
 		fmt.Printf("  Total modules: %d\n", result.TotalModuleCount)
 		fmt.Printf("  Targets: %d\n", len(result.Targets))
 		fmt.Println()
@@ -210,18 +426,29 @@ func printBuildResult(flags *buildFlags, result *app.BuildResult) {
 		fmt.Printf("✓ Build completed successfully\n")
 		fmt.Printf("  Shell: %s\n", result.ShellType)
 		fmt.Printf("  OS: %s\n", result.TargetOS)
+		if result.Profile != "" {
+			fmt.Printf("  Profile: %s\n", result.Profile)
+		}
 		fmt.Printf("  Total modules: %d\n", result.TotalModuleCount)
 		fmt.Printf("  Generated at: %s\n", result.GeneratedAt.Format("2006-01-02 15:04:05"))
 		fmt.Println()
 	}
 
-	fmt.Printf("✓ Generated %d RC files in %s:\n", len(result.Targets), flags.outputDir)
+	fmt.Printf("✓ Generated %d RC files in %s (%d changed, %d unchanged):\n",
+		len(result.Targets), flags.outputDir, len(result.Changed()), len(result.Unchanged()))
 	for _, target := range result.Targets {
-		fmt.Printf("  • %s → %s (%d modules)\n", target.Target, target.FilePath, target.ModuleCount)
+		status := ""
+		if target.Unchanged {
+			status = ", unchanged"
+		}
+		fmt.Printf("  • %s → %s (%d modules%s)\n", target.Target, target.FilePath, target.ModuleCount, status)
 		if flags.verbose {
 			fmt.Printf("    Modules: %s\n", strings.Join(target.ModuleNames, ", "))
 		}
 	}
+	for _, path := range result.Removed {
+		fmt.Printf("  • removed stale %s\n", path)
+	}
 
 	fmt.Printf("\nNext: gz-shellforge deploy --backup\n")
 }
@@ -43,6 +43,34 @@ func TestBuildCmd_Flags(t *testing.T) {
 	verboseFlag := cmd.Flags().Lookup("verbose")
 	require.NotNil(t, verboseFlag)
 	assert.Equal(t, "false", verboseFlag.DefValue)
+
+	profileFlag := cmd.Flags().Lookup("profile")
+	require.NotNil(t, profileFlag)
+	assert.Equal(t, "", profileFlag.DefValue)
+
+	buildTimeFlag := cmd.Flags().Lookup("build-time")
+	require.NotNil(t, buildTimeFlag)
+	assert.Equal(t, "", buildTimeFlag.DefValue)
+
+	allowMissingFlag := cmd.Flags().Lookup("allow-missing")
+	require.NotNil(t, allowMissingFlag)
+	assert.Equal(t, "false", allowMissingFlag.DefValue)
+
+	verifyFlag := cmd.Flags().Lookup("verify")
+	require.NotNil(t, verifyFlag)
+	assert.Equal(t, "false", verifyFlag.DefValue)
+
+	for name, def := range map[string]string{"watch": "false", "no-cache": "false", "interval": "500ms", "deploy-home": ""} {
+		flag := cmd.Flags().Lookup(name)
+		require.NotNil(t, flag, name)
+		assert.Equal(t, def, flag.DefValue, name)
+	}
+
+	for _, name := range []string{"tag", "exclude-tag", "var"} {
+		flag := cmd.Flags().Lookup(name)
+		require.NotNil(t, flag, name)
+		assert.Equal(t, "stringArray", flag.Value.Type())
+	}
 }
 
 func TestBuildCmd_Help(t *testing.T) {
@@ -132,6 +132,9 @@ func printDeployResult(flags *deployFlags, result *app.DeployResult) {
 		for _, file := range result.DeployedFiles {
 			fmt.Printf("  • %s → %s\n", file.SourcePath, file.DestPath)
 		}
+		for _, path := range result.RemovedFiles {
+			fmt.Printf("  • remove stale %s\n", path)
+		}
 		fmt.Println()
 		fmt.Printf("Run without --dry-run to deploy these files.\n")
 		return
@@ -145,6 +148,9 @@ func printDeployResult(flags *deployFlags, result *app.DeployResult) {
 
 	fmt.Printf("  Deployed: %d/%d files\n", result.DeployedCount, result.TotalFiles)
 
+	if len(result.RemovedFiles) > 0 {
+		fmt.Printf("  Removed stale: %d files\n", len(result.RemovedFiles))
+	}
 	if result.ErrorCount > 0 {
 		fmt.Printf("  Errors: %d\n", result.ErrorCount)
 	}
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -14,12 +15,15 @@ import (
 
 	"github.com/gizzahub/gzh-cli-shellforge/internal/app"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain/shellmeta"
 )
 
 type doctorFlags struct {
 	manifest string
 	targetOS string
 	verbose  bool
+	vars     []string
+	selectionFlags
 }
 
 func newDoctorCmd() *cobra.Command {
@@ -33,6 +37,13 @@ against the current system.  It reports missing binaries (requires_bin) and
 missing filesystem paths (requires_path) grouped by tool, with the list of
 modules that depend on each missing item.
 
+It also lists directories that module 'path' declarations add to PATH but
+that do not exist on this host.  They do not affect the exit code.
+
+It also suggests 'lazy:' triggers for modules that set up a version manager
+(nvm, pyenv, rbenv, conda, ...) the shell profiles data marks as lazy-loadable.
+Suggestions do not affect the exit code.
+
 Doctor never installs anything.  Exit code 0 = all present, 1 = at least one
 prerequisite is missing.`,
 		Example: `  # Check against auto-detected OS
@@ -41,6 +52,9 @@ prerequisite is missing.`,
   # Check against a specific OS
   gz-shellforge doctor --os Linux
 
+  # Check only the modules of the "server" profile
+  gz-shellforge doctor --profile server
+
   # Show verbose output
   gz-shellforge doctor --verbose`,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -50,6 +64,8 @@ prerequisite is missing.`,
 
 	cmd.Flags().StringVarP(&flags.manifest, "manifest", "m", "manifest.yaml", "Path to manifest file")
 	cmd.Flags().StringVar(&flags.targetOS, "os", "", "Target OS (auto-detected if omitted)")
+	addSelectionFlags(cmd, &flags.selectionFlags, "Manifest profile to apply (default: matched by hostname)")
+	cmd.Flags().StringArrayVar(&flags.vars, "var", nil, "Set a manifest variable, name=value (can be repeated)")
 	cmd.Flags().BoolVarP(&flags.verbose, "verbose", "v", false, "Show all checked modules, not just failures")
 
 	return cmd
@@ -67,8 +83,29 @@ func runDoctor(flags *doctorFlags) error {
 		return clierrors.WrapError("manifest parsing", err)
 	}
 
+	manifest, profile, err := app.SelectModules(manifest, flags.selection(helpers.DetectHostname()))
+	if err != nil {
+		return clierrors.WrapError("module selection", err)
+	}
+
+	// requires_path may reference {{ .Vars.name }}
+	vars, err := helpers.ParseVars(flags.vars)
+	if err != nil {
+		return err
+	}
+	facts := detectHostFacts(targetOS, manifest.GetShellType())
+	manifest, err = manifest.ExpandVarsFor(facts, manifest.ResolveVars(targetOS, profile, vars))
+	if err != nil {
+		return clierrors.WrapError("variable expansion", err)
+	}
+
 	svc := app.NewDoctorService()
-	result := svc.Check(manifest, targetOS, domain.OsPrereqLookup{})
+	result := svc.CheckHost(manifest, facts, domain.OsPrereqLookup{})
+	dev, err := loadDevProfiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Lazy loading suggestions skipped: %v\n", err)
+	}
+	result.LazyCandidates = svc.SuggestLazy(manifest, facts, dev)
 
 	printDoctorResult(result, flags.verbose)
 
@@ -83,6 +120,8 @@ func printDoctorResult(result *app.DoctorResult, verbose bool) {
 
 	if result.AllOK() {
 		fmt.Println("✓ All prerequisites satisfied.")
+		printMissingPathEntries(result.MissingPathEntries)
+		printLazyCandidates(result.LazyCandidates)
 		return
 	}
 
@@ -105,6 +144,46 @@ func printDoctorResult(result *app.DoctorResult, verbose bool) {
 		fmt.Printf("  [%s] %s\n", kindLabel, dep.Name)
 		fmt.Printf("           needed by: %s\n", strings.Join(dep.Modules, ", "))
 	}
+	printMissingPathEntries(result.MissingPathEntries)
+	printLazyCandidates(result.LazyCandidates)
 
 	fmt.Printf("\nRun 'gz-shellforge doctor --verbose' for full module list.\n")
 }
+
+// printMissingPathEntries lists PATH directories that do not exist.
+func printMissingPathEntries(entries []app.MissingPathEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Printf("\nPATH entries not found on this host (%d):\n\n", len(entries))
+	for _, entry := range entries {
+		note := ""
+		if entry.IfExists {
+			note = " (if_exists: skipped at startup)"
+		}
+		fmt.Printf("  %s%s\n", entry.Dir, note)
+		fmt.Printf("           declared by: %s\n", strings.Join(entry.Modules, ", "))
+	}
+}
+
+// printLazyCandidates lists modules that could be loaded on first use.
+func printLazyCandidates(candidates []app.LazyCandidate) {
+	if len(candidates) == 0 {
+		return
+	}
+	fmt.Printf("\nLazy loading candidates (%d):\n\n", len(candidates))
+	for _, c := range candidates {
+		fmt.Printf("  %s (%s)\n", c.Module, c.Manager)
+		fmt.Printf("           lazy: [%s]\n", strings.Join(c.Triggers, ", "))
+	}
+}
+
+// loadDevProfiles reads the version manager data from shellmeta dev.yaml.
+func loadDevProfiles() (*shellmeta.DevProfiles, error) {
+	fs, dataDir := profilesData("")
+	dev, err := shellmeta.NewLoader(fs).LoadDev(filepath.Join(dataDir, "dev.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shell profiles data: %w", err)
+	}
+	return dev, nil
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/filesystem"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/git"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/shellexec"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/snapshot"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/syntaxcheck"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/yamlparser"
 )
 
@@ -17,6 +19,7 @@ type Services struct {
 	Parser *yamlparser.Parser
 	Reader *filesystem.Reader
 	Writer *filesystem.Writer
+	Syntax *syntaxcheck.Checker
 }
 
 // NewServices creates a new Services instance with all common dependencies
@@ -27,6 +30,7 @@ func NewServices() *Services {
 		Parser: yamlparser.New(fs),
 		Reader: filesystem.NewReader(fs),
 		Writer: filesystem.NewWriter(fs),
+		Syntax: syntaxcheck.NewChecker(),
 	}
 }
 
@@ -40,6 +44,18 @@ func (s *Services) NewDeployer() *app.DeployService {
 	return app.NewDeployService(s.Reader, s.Writer)
 }
 
+// NewWatcher creates a WatchService that builds with NewBuilder and deploys
+// with NewDeployer
+func (s *Services) NewWatcher() *app.WatchService {
+	return app.NewWatchService(s.Parser, s.NewBuilder(), s.NewDeployer(), filesystem.NewFingerprinter(s.Fs))
+}
+
+// NewStartupReporter creates a StartupReportService that deploys with
+// NewDeployer and starts real shells
+func (s *Services) NewStartupReporter() *app.StartupReportService {
+	return app.NewStartupReportService(s.Reader, s.NewDeployer(), shellexec.NewLauncher())
+}
+
 // BackupServices holds services specifically for backup operations
 type BackupServices struct {
 	Fs            afero.Fs
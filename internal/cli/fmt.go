@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/cli/factory"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/yamlparser"
+)
+
+type fmtFlags struct {
+	check bool
+}
+
+func newFmtCmd() *cobra.Command {
+	flags := &fmtFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "fmt [manifest...]",
+		Short: "Format manifest files canonically",
+		Long: `Fmt rewrites manifest files in canonical form while keeping comments:
+
+  - keys in a fixed order (name, file, description, target, priority, os, ...)
+  - OS names spelled as OS detection reports them (mac → Mac)
+  - module keys holding default values removed (priority: 50, false flags,
+    empty lists), except in 'extends: true' patches; 'target' is removed
+    only when it names the main rc file of the manifest's own shell.type
+    in a version 2+ manifest
+  - two-space indentation with a blank line between sections and modules
+
+Files are rewritten in place. With --check nothing is written; a diff is
+printed for each file that is not formatted and the command fails, which
+suits pre-commit hooks and CI.`,
+		Example: `  # Format manifest.yaml in place
+  shellforge fmt
+
+  # Format several manifests
+  shellforge fmt manifest.yaml hosts/*.yaml
+
+  # Fail (and show a diff) if anything is not formatted
+  shellforge fmt --check`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = []string{"manifest.yaml"}
+			}
+			return runFmt(cmd, flags, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.check, "check", false, "Report unformatted files with a diff instead of rewriting them")
+
+	return cmd
+}
+
+func runFmt(cmd *cobra.Command, flags *fmtFlags, paths []string) error {
+	fs := factory.NewServices().Fs
+	out := cmd.OutOrStdout()
+
+	unformatted := 0
+	for _, path := range paths {
+		data, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		formatted, err := yamlparser.Format(data, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if bytes.Equal(data, formatted) {
+			continue
+		}
+		unformatted++
+
+		if flags.check {
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(string(data)),
+				B:        difflib.SplitLines(string(formatted)),
+				FromFile: path,
+				ToFile:   path + " (formatted)",
+				Context:  3,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to diff %s: %w", path, err)
+			}
+			fmt.Fprint(out, diff)
+			continue
+		}
+
+		info, err := fs.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if err := afero.WriteFile(fs, path, formatted, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Fprintf(out, "formatted %s\n", path)
+	}
+
+	if flags.check && unformatted > 0 {
+		return fmt.Errorf("%d file(s) need formatting; run 'shellforge fmt'", unformatted)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unformattedManifest = `modules:
+  - file: git.sh
+    name: git
+    os: [mac]
+    priority: 50
+`
+
+const formattedManifest = `modules:
+  - name: git
+    file: git.sh
+    os: [Mac]
+`
+
+func TestFmtCmd_Flags(t *testing.T) {
+	cmd := newFmtCmd()
+
+	checkFlag := cmd.Flags().Lookup("check")
+	require.NotNil(t, checkFlag)
+	assert.Equal(t, "false", checkFlag.DefValue)
+}
+
+func TestFmtCmd_Help(t *testing.T) {
+	cmd := newFmtCmd()
+
+	assert.Equal(t, "fmt [manifest...]", cmd.Use)
+	assert.Contains(t, cmd.Short, "Format")
+	assert.Contains(t, cmd.Long, "--check")
+	assert.Contains(t, cmd.Example, "shellforge fmt --check")
+}
+
+func TestFmtCmd_RewritesInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(unformattedManifest), 0o600))
+
+	cmd := newFmtCmd()
+	cmd.SetArgs([]string{path})
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "formatted "+path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, formattedManifest, string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "file mode should be preserved")
+}
+
+func TestFmtCmd_Check(t *testing.T) {
+	dir := t.TempDir()
+	dirty := filepath.Join(dir, "dirty.yaml")
+	clean := filepath.Join(dir, "clean.yaml")
+	require.NoError(t, os.WriteFile(dirty, []byte(unformattedManifest), 0o644))
+	require.NoError(t, os.WriteFile(clean, []byte(formattedManifest), 0o644))
+
+	cmd := newFmtCmd()
+	cmd.SetArgs([]string{"--check", clean, dirty})
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 file(s) need formatting")
+	assert.Contains(t, buf.String(), "--- "+dirty)
+	assert.Contains(t, buf.String(), "+    os: [Mac]")
+	assert.NotContains(t, buf.String(), clean)
+
+	// --check never writes
+	data, err := os.ReadFile(dirty)
+	require.NoError(t, err)
+	assert.Equal(t, unformattedManifest, string(data))
+}
@@ -0,0 +1,43 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SourceDateEpochEnv is the reproducible-builds environment variable holding
+// a fixed build timestamp in Unix seconds.
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// ResolveBuildTime determines the fixed timestamp for a reproducible build.
+// An explicit value (RFC3339 or Unix seconds) takes precedence over
+// SOURCE_DATE_EPOCH. Returns the zero time when neither is set, meaning
+// "use the current time". Fixed timestamps are normalised to UTC so output
+// does not depend on the local timezone.
+func ResolveBuildTime(explicit string) (time.Time, error) {
+	value := strings.TrimSpace(explicit)
+	source := "--build-time"
+	if value == "" {
+		value = strings.TrimSpace(os.Getenv(SourceDateEpochEnv))
+		source = SourceDateEpochEnv
+	}
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	if source == SourceDateEpochEnv {
+		return time.Time{}, fmt.Errorf("invalid %s %q: must be Unix seconds", source, value)
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: must be RFC3339 or Unix seconds", source, value)
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBuildTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		explicit string
+		epoch    string
+		want     time.Time
+		wantErr  bool
+	}{
+		{name: "nothing set", want: time.Time{}},
+		{name: "source date epoch", epoch: "1700000000", want: time.Unix(1700000000, 0).UTC()},
+		{name: "explicit unix seconds", explicit: "1600000000", want: time.Unix(1600000000, 0).UTC()},
+		{name: "explicit RFC3339", explicit: "2024-01-02T03:04:05+09:00", want: time.Date(2024, 1, 1, 18, 4, 5, 0, time.UTC)},
+		{name: "explicit wins over env", explicit: "1600000000", epoch: "1700000000", want: time.Unix(1600000000, 0).UTC()},
+		{name: "invalid explicit", explicit: "yesterday", wantErr: true},
+		{name: "invalid env", epoch: "2024-01-01T00:00:00Z", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SourceDateEpochEnv, tt.epoch)
+
+			got, err := ResolveBuildTime(tt.explicit)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %v, want %v", got, tt.want)
+			if !got.IsZero() {
+				assert.Equal(t, time.UTC, got.Location())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// osReleasePath is the freedesktop os-release file consulted first for the distro ID.
+const osReleasePath = "/etc/os-release"
+
+// DetectArch returns the CPU architecture in GOARCH form (amd64, arm64, ...).
+func DetectArch() string {
+	return runtime.GOARCH
+}
+
+// DetectEnv returns the current environment as a map.
+func DetectEnv() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// DetectDistro identifies the Linux distribution on the current machine.
+// distroFiles maps distribution IDs to marker files, as in shellmeta
+// core.yaml (os_detection.linux.distro_files); it may be nil.
+// Returns an empty string when the distribution cannot be determined.
+func DetectDistro(distroFiles map[string][]string) string {
+	return detectDistro(distroFiles, os.ReadFile)
+}
+
+// detectDistro prefers the ID from /etc/os-release. Without it, the first
+// distribution (by name) whose distribution-specific marker file exists wins;
+// markers shared by several distributions (like /etc/lsb-release) are ignored.
+func detectDistro(distroFiles map[string][]string, readFile func(string) ([]byte, error)) string {
+	if data, err := readFile(osReleasePath); err == nil {
+		if id := osReleaseID(data); id != "" {
+			return id
+		}
+	}
+
+	owners := make(map[string]int)
+	for _, files := range distroFiles {
+		for _, f := range files {
+			owners[f]++
+		}
+	}
+
+	names := make([]string, 0, len(distroFiles))
+	for name := range distroFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, f := range distroFiles[name] {
+			if owners[f] != 1 {
+				continue
+			}
+			if _, err := readFile(f); err == nil {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// osReleaseID extracts the lower-cased ID= value from os-release content.
+func osReleaseID(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if value, ok := strings.CutPrefix(line, "ID="); ok {
+			return strings.ToLower(strings.Trim(value, `"'`))
+		}
+	}
+	return ""
+}
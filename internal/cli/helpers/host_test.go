@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeReadFile(files map[string]string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		if content, ok := files[path]; ok {
+			return []byte(content), nil
+		}
+		return nil, errors.New("not found")
+	}
+}
+
+func TestDetectDistro(t *testing.T) {
+	distroFiles := map[string][]string{
+		"ubuntu":  {"/etc/lsb-release", "/etc/os-release"},
+		"debian":  {"/etc/debian_version", "/etc/os-release"},
+		"arch":    {"/etc/arch-release", "/etc/os-release"},
+		"manjaro": {"/etc/lsb-release", "/etc/os-release"},
+	}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "os-release ID wins",
+			files: map[string]string{"/etc/os-release": "NAME=\"Ubuntu\"\nID=ubuntu\nID_LIKE=debian\n", "/etc/debian_version": "12"},
+			want:  "ubuntu",
+		},
+		{
+			name:  "quoted ID is lower-cased",
+			files: map[string]string{"/etc/os-release": "ID=\"Fedora\"\n"},
+			want:  "fedora",
+		},
+		{
+			name:  "distro-specific marker without os-release",
+			files: map[string]string{"/etc/arch-release": ""},
+			want:  "arch",
+		},
+		{
+			name:  "shared markers are ambiguous",
+			files: map[string]string{"/etc/lsb-release": "DISTRIB_ID=Ubuntu"},
+			want:  "",
+		},
+		{
+			name:  "nothing found",
+			files: map[string]string{},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectDistro(distroFiles, fakeReadFile(tt.files)))
+		})
+	}
+}
+
+func TestDetectEnv(t *testing.T) {
+	t.Setenv("SHELLFORGE_TEST_VAR", "a=b")
+
+	env := DetectEnv()
+	assert.Equal(t, "a=b", env["SHELLFORGE_TEST_VAR"])
+}
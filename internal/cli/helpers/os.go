@@ -1,7 +1,10 @@
 // Package helpers provides common utility functions for CLI commands.
 package helpers
 
-import "runtime"
+import (
+	"os"
+	"runtime"
+)
 
 // DetectOS returns the detected OS name normalized for shellforge.
 // Maps runtime.GOOS values to user-friendly names that match manifest OS values.
@@ -23,3 +26,13 @@ func DetectOS() string {
 		return runtime.GOOS
 	}
 }
+
+// DetectHostname returns the machine's hostname for profile auto-selection,
+// or an empty string if it cannot be determined.
+func DetectHostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
+}
@@ -4,6 +4,8 @@ import (
 	"runtime"
 	"slices"
 	"testing"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
 )
 
 func TestDetectOS(t *testing.T) {
@@ -44,3 +46,12 @@ func TestDetectOS_ReturnsKnownValue(t *testing.T) {
 		t.Errorf("DetectOS() = %q, expected known value or %q", result, runtime.GOOS)
 	}
 }
+
+func TestDetectOS_UsesKnownSpelling(t *testing.T) {
+	// fmt normalises manifest OS names to domain.KnownOSNames; detection
+	// must produce the same spelling or built modules would not match.
+	detected := DetectOS()
+	if detected != runtime.GOOS && !slices.Contains(domain.KnownOSNames, detected) {
+		t.Errorf("DetectOS() = %q, not in domain.KnownOSNames %v", detected, domain.KnownOSNames)
+	}
+}
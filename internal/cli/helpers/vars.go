@@ -0,0 +1,24 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseVars parses repeated --var name=value flags into a map. Later
+// occurrences of a name win. The value may be empty and may contain '='.
+func ParseVars(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected name=value", pair)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
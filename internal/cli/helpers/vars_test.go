@@ -0,0 +1,29 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVars(t *testing.T) {
+	vars, err := ParseVars([]string{"brew=/opt/homebrew", "proxy=http://p:8080/?a=b", "empty=", "brew=/usr/local"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"brew":  "/usr/local",
+		"proxy": "http://p:8080/?a=b",
+		"empty": "",
+	}, vars)
+
+	vars, err = ParseVars(nil)
+	require.NoError(t, err)
+	assert.Nil(t, vars)
+}
+
+func TestParseVars_Invalid(t *testing.T) {
+	for _, pair := range []string{"novalue", "=value", " =x"} {
+		_, err := ParseVars([]string{pair})
+		assert.Error(t, err, pair)
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/cli/helpers"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain/shellmeta"
+)
+
+// detectHostFacts gathers the facts that module 'when:' conditions are
+// evaluated against. Machine facts (arch, distro, environment) are only known
+// when building for the OS we are running on; for any other target OS they
+// stay empty, so conditions on them do not match.
+func detectHostFacts(targetOS, shell string) domain.HostFacts {
+	facts := domain.HostFacts{
+		OS:       targetOS,
+		Shell:    strings.ToLower(shell),
+		Hostname: helpers.DetectHostname(),
+	}
+	if !strings.EqualFold(targetOS, helpers.DetectOS()) {
+		return facts
+	}
+
+	facts.Arch = helpers.DetectArch()
+	facts.Env = helpers.DetectEnv()
+	if strings.EqualFold(targetOS, "Linux") {
+		facts.Distro = helpers.DetectDistro(loadDistroFiles())
+	}
+	return facts
+}
+
+// loadDistroFiles reads distribution marker files from shellmeta core.yaml,
+// or returns nil when the data cannot be read.
+func loadDistroFiles() map[string][]string {
+	fs, dataDir := profilesData("")
+	core, err := shellmeta.NewLoader(fs).LoadCore(filepath.Join(dataDir, "core.yaml"))
+	if err != nil {
+		return nil
+	}
+	return core.OSDetection.Linux.DistroFiles
+}
@@ -7,8 +7,10 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/gizzahub/gzh-cli-shellforge/internal/app"
 	clierrors "github.com/gizzahub/gzh-cli-shellforge/internal/cli/errors"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/cli/factory"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/cli/helpers"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
 )
 
@@ -17,6 +19,7 @@ type listFlags struct {
 	configDir string
 	verbose   bool
 	filterOS  string
+	selectionFlags
 }
 
 func newListCmd() *cobra.Command {
@@ -33,8 +36,12 @@ This command reads the manifest and displays module information including:
 - Dependencies
 - OS compatibility
 
-Use --filter to show only modules for a specific OS.
-Use --verbose to show detailed information including full file paths.`,
+Use --filter to show only the modules a build for that OS would include
+(os: and when: conditions, as build evaluates them).
+Use --verbose to show detailed information including full file paths,
+for manifests that use 'include' the file and line each module came from,
+and why each module is included or excluded on this machine (os: and when:
+conditions evaluated against the --filter OS or the detected one).`,
 		Example: `  # List all modules
   shellforge list
 
@@ -48,7 +55,13 @@ Use --verbose to show detailed information including full file paths.`,
   shellforge list --verbose
 
   # List Linux modules with verbose output
-  shellforge list --filter Linux --verbose`,
+  shellforge list --filter Linux --verbose
+
+  # List the modules selected by the "work" profile
+  shellforge list --profile work
+
+  # List modules tagged "core" and everything they require
+  shellforge list --tag core`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runList(cmd, flags)
 		},
@@ -58,6 +71,7 @@ Use --verbose to show detailed information including full file paths.`,
 	cmd.Flags().StringVarP(&flags.configDir, "config-dir", "c", "modules", "Directory containing module files")
 	cmd.Flags().BoolVarP(&flags.verbose, "verbose", "v", false, "Show detailed output")
 	cmd.Flags().StringVarP(&flags.filterOS, "filter", "F", "", "Filter modules by OS (Mac, Linux)")
+	addSelectionFlags(cmd, &flags.selectionFlags, "Only list modules selected by this manifest profile")
 
 	return cmd
 }
@@ -79,12 +93,26 @@ func runList(cmd *cobra.Command, flags *listFlags) error {
 		cmd.PrintErrln()
 	}
 
-	// Filter modules by OS if specified
+	// Apply profile/tags only when requested; listing shows the whole manifest by default
+	manifest, profile, err := app.SelectModules(manifest, flags.selection(""))
+	if err != nil {
+		return clierrors.WrapError("module selection", err)
+	}
+
+	// Host facts used to filter by OS and to explain each module's
+	// selection in verbose mode
+	factsOS := flags.filterOS
+	if factsOS == "" {
+		factsOS = helpers.DetectOS()
+	}
+	facts := detectHostFacts(factsOS, manifest.GetShellType())
+
+	// Filter modules by OS if specified, with the predicate build uses
 	modules := manifest.Modules
 	if flags.filterOS != "" {
 		var filtered []domain.Module
 		for _, module := range modules {
-			if module.AppliesTo(flags.filterOS) {
+			if module.Evaluate(facts).Included {
 				filtered = append(filtered, module)
 			}
 		}
@@ -97,6 +125,9 @@ func runList(cmd *cobra.Command, flags *listFlags) error {
 	} else {
 		cmd.Printf("Modules (%d)\n", len(modules))
 	}
+	if profile != "" {
+		cmd.Printf("Profile: %s\n", profile)
+	}
 	cmd.Printf("Manifest: %s\n\n", flags.manifest)
 
 	// Check if module files exist
@@ -119,15 +150,40 @@ func runList(cmd *cobra.Command, flags *listFlags) error {
 			cmd.Printf("   %s\n", module.Description)
 		}
 
+		// Tags
+		if len(module.Tags) > 0 {
+			cmd.Printf("   Tags: %s\n", strings.Join(module.Tags, ", "))
+		}
+
 		// File path (verbose mode)
 		if flags.verbose {
-			fullPath := filepath.Join(flags.configDir, module.File)
-			fileExists := reader.FileExists(fullPath)
-			existsMarker := "✓"
-			if !fileExists {
-				existsMarker = "✗"
+			switch {
+			case module.IsInline():
+				cmd.Printf("   Body: %s at %s\n", module.BodySource(), module.BodyLocation)
+			case module.File != "":
+				fullPath := filepath.Join(flags.configDir, module.File)
+				existsMarker := "✓"
+				if !reader.FileExists(fullPath) {
+					existsMarker = "✗"
+				}
+				cmd.Printf("   File: %s %s\n", module.File, existsMarker)
+			}
+			for _, rule := range module.Path {
+				cmd.Printf("   Path: %s\n", rule.String())
+			}
+			if !module.Location.IsZero() {
+				cmd.Printf("   Source: %s\n", module.Location)
+			}
+			for _, loc := range module.PatchedAt {
+				cmd.Printf("   Patched: %s\n", loc)
+			}
+
+			decision := module.Evaluate(facts)
+			if decision.Included {
+				cmd.Printf("   Status: ✓ included on %s (%s)\n", facts.OS, decision.Reason)
+			} else {
+				cmd.Printf("   Status: ✗ excluded on %s (%s)\n", facts.OS, decision.Reason)
 			}
-			cmd.Printf("   File: %s %s\n", module.File, existsMarker)
 		}
 
 		// Dependencies
@@ -139,6 +195,15 @@ func runList(cmd *cobra.Command, flags *listFlags) error {
 			}
 		}
 
+		// Optional dependencies
+		if len(module.RequiresOptional) > 0 {
+			if flags.verbose {
+				cmd.Printf("   Optional: %s\n", strings.Join(module.RequiresOptional, ", "))
+			} else {
+				cmd.Printf("   ⇢ %s (optional)\n", strings.Join(module.RequiresOptional, ", "))
+			}
+		}
+
 		// Spacing between modules
 		if i < len(modules)-1 {
 			cmd.Println()
@@ -2,6 +2,8 @@ package cli
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -252,3 +254,91 @@ func TestListCmd_VerboseIntegration(t *testing.T) {
 	output := buf.String()
 	assert.Contains(t, output, "File:", "verbose mode should show file paths")
 }
+
+func TestListCmd_VerboseShowsIncludeProvenance(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.yaml"), []byte(`modules:
+  - name: git
+    file: git.sh
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte(`include: [base.yaml]
+modules:
+  - name: git
+    extends: true
+    description: patched
+`), 0o644))
+
+	cmd := newListCmd()
+	cmd.SetArgs([]string{"--manifest", filepath.Join(dir, "manifest.yaml"), "--config-dir", dir, "--verbose"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	require.NoError(t, cmd.Execute())
+
+	output := buf.String()
+	assert.Contains(t, output, "Source: "+filepath.Join(dir, "base.yaml")+":2")
+	assert.Contains(t, output, "Patched: "+filepath.Join(dir, "manifest.yaml")+":3")
+	assert.Contains(t, output, "patched")
+}
+
+func TestListCmd_TagSelection(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte(`modules:
+  - name: path
+    file: path.sh
+  - name: proxy
+    file: proxy.sh
+    tags: [work]
+    requires: [path]
+  - name: games
+    file: games.sh
+    tags: [personal]
+`), 0o644))
+
+	cmd := newListCmd()
+	cmd.SetArgs([]string{"--manifest", filepath.Join(dir, "manifest.yaml"), "--config-dir", dir, "--tag", "work"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	require.NoError(t, cmd.Execute())
+
+	output := buf.String()
+	assert.Contains(t, output, "proxy")
+	assert.Contains(t, output, "path")
+	assert.NotContains(t, output, "games")
+}
+
+func TestListCmd_FilterAppliesWhenConditions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte(`modules:
+  - name: git
+    file: git.sh
+  - name: fish-abbr
+    file: abbr.fish
+    when: {shell: [fish]}
+  - name: mac-only
+    file: mac.sh
+    os: [Mac]
+`), 0o644))
+
+	cmd := newListCmd()
+	cmd.SetArgs([]string{"--manifest", filepath.Join(dir, "manifest.yaml"), "--config-dir", dir, "--filter", "Linux"})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+
+	require.NoError(t, cmd.Execute())
+
+	// The zsh manifest never builds the fish-only module, so it is filtered
+	// out like the one for another OS
+	output := buf.String()
+	assert.Contains(t, output, "Modules (1) - Filtered by OS: Linux")
+	assert.Contains(t, output, "git")
+	assert.NotContains(t, output, "fish-abbr")
+	assert.NotContains(t, output, "mac-only")
+}
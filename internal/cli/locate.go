@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/app"
+	clierrors "github.com/gizzahub/gzh-cli-shellforge/internal/cli/errors"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/cli/factory"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/cli/helpers"
+)
+
+type locateFlags struct {
+	buildDir string
+}
+
+func newLocateCmd() *cobra.Command {
+	flags := &locateFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "locate <target>:<line>",
+		Short: "Find the module a line of a generated file came from",
+		Long: `Locate maps a line of a generated file back to the module file and line
+it was copied from, using the source map build records in
+.shellforge-build.json.
+
+The target may be the target name (zshrc), the file in the build directory
+(conf.d/git.fish) or the deployed path shown in shell errors (.zshrc,
+~/.zshrc, /home/me/.zshrc).`,
+		Example: `  # zsh reported "/home/me/.zshrc:412: command not found: foo"
+  gz-shellforge locate ~/.zshrc:412
+
+  # Look up a line of a build in another directory
+  gz-shellforge locate zshrc:40 --build-dir ~/staging`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, line, err := parseLocation(args[0])
+			if err != nil {
+				return err
+			}
+			buildDir, err := helpers.ExpandHomePath(flags.buildDir)
+			if err != nil {
+				return clierrors.InvalidPath("build-dir", err)
+			}
+
+			services := factory.NewServices()
+			loc, err := app.NewLocateService(services.Reader).Locate(buildDir, target, line)
+			if err != nil {
+				return clierrors.WrapError("locate", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s:%d: module '%s' (%s line %d)\n", loc.File, loc.ModuleLine, loc.Module, loc.Target, loc.Line)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.buildDir, "build-dir", "d", "./build", "Build directory containing .shellforge-build.json")
+
+	return cmd
+}
+
+// parseLocation splits "target:line" at the last colon.
+func parseLocation(arg string) (string, int, error) {
+	i := strings.LastIndex(arg, ":")
+	if i > 0 {
+		if line, err := strconv.Atoi(arg[i+1:]); err == nil && line > 0 {
+			return arg[:i], line, nil
+		}
+	}
+	return "", 0, fmt.Errorf("invalid location %q: expected <target>:<line>", arg)
+}
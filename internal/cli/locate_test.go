@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		arg      string
+		target   string
+		line     int
+		wantFail bool
+	}{
+		{arg: "zshrc:12", target: "zshrc", line: 12},
+		{arg: "/home/me/.zshrc:412", target: "/home/me/.zshrc", line: 412},
+		{arg: "C:/x/.bashrc:3", target: "C:/x/.bashrc", line: 3},
+		{arg: "zshrc", wantFail: true},
+		{arg: "zshrc:0", wantFail: true},
+		{arg: "zshrc:abc", wantFail: true},
+		{arg: ":12", wantFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			target, line, err := parseLocation(tt.arg)
+			if tt.wantFail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.target, target)
+			assert.Equal(t, tt.line, line)
+		})
+	}
+}
+
+func TestLocateCmd(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".shellforge-build.json"), []byte(`{
+  "shell": "zsh",
+  "os": "Mac",
+  "config_dir": "modules",
+  "generated_at": "2024-01-01T00:00:00Z",
+  "files": [
+    {
+      "source": ".zshrc",
+      "target": "zshrc",
+      "dest_path": ".zshrc",
+      "sources": [
+        {"module": "git", "file": "git.zsh", "start_line": 10, "lines": 5, "source_line": 1}
+      ]
+    }
+  ]
+}`), 0o644))
+
+	cmd := newLocateCmd()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"~/.zshrc:12", "--build-dir", dir})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "modules/git.zsh:3: module 'git' (.zshrc line 12)\n", buf.String())
+
+	cmd = newLocateCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"zshrc:2", "--build-dir", dir})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not part of a module")
+}
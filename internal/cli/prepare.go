@@ -20,6 +20,7 @@ type prepareFlags struct {
 	check    bool
 	dryRun   bool
 	verbose  bool
+	selectionFlags
 }
 
 func newPrepareCmd() *cobra.Command {
@@ -49,7 +50,10 @@ installs.`,
   gz-shellforge prepare --dry-run
 
   # Install missing packages
-  gz-shellforge prepare`,
+  gz-shellforge prepare
+
+  # Install only what the "work" profile needs
+  gz-shellforge prepare --profile work`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			targetOS := flags.targetOS
 			if targetOS == "" {
@@ -61,6 +65,7 @@ installs.`,
 
 	cmd.Flags().StringVarP(&flags.manifest, "manifest", "m", "manifest.yaml", "Path to manifest file")
 	cmd.Flags().StringVar(&flags.targetOS, "os", "", "Target OS (auto-detected if omitted)")
+	addSelectionFlags(cmd, &flags.selectionFlags, "Manifest profile to apply (default: matched by hostname)")
 	cmd.Flags().BoolVar(&flags.check, "check", false, "Report missing packages without installing")
 	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print the install plan without installing")
 	cmd.Flags().BoolVarP(&flags.verbose, "verbose", "v", false, "Show all declared packages, not just missing ones")
@@ -101,6 +106,11 @@ func runPrepare(flags *prepareFlags, managers map[string]domain.PackageManager)
 		return clierrors.WrapError("manifest parsing", err)
 	}
 
+	manifest, _, err = app.SelectModules(manifest, flags.selection(helpers.DetectHostname()))
+	if err != nil {
+		return clierrors.WrapError("module selection", err)
+	}
+
 	svc := app.NewPrepareService(managers)
 
 	switch {
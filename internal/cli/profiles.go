@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
+	shellprofiles "github.com/gizzahub/gzh-cli-shellforge/data/shell-profiles"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain/shellmeta"
 )
 
@@ -148,33 +149,36 @@ Contexts:
 }
 
 func loadProfiles(flags *profilesFlags) (*shellmeta.ShellProfiles, error) {
-	dataDir := flags.dataDir
-	if dataDir == "" {
-		// Try to find data directory relative to executable or current directory
-		execPath, err := os.Executable()
-		if err == nil {
-			// Check relative to executable
-			candidate := filepath.Join(filepath.Dir(execPath), "..", "data", "shell-profiles")
-			if _, err := os.Stat(candidate); err == nil {
-				dataDir = candidate
-			}
-		}
+	fs, dataDir := profilesData(flags.dataDir)
+	return shellmeta.NewLoader(fs).Load(dataDir)
+}
 
-		if dataDir == "" {
-			// Check relative to current directory
-			candidate := filepath.Join("data", "shell-profiles")
-			if _, err := os.Stat(candidate); err == nil {
-				dataDir = candidate
-			}
+// profilesData returns the filesystem and directory holding the shell
+// profiles data: dataDir if set, otherwise the data directory found relative
+// to the executable or the current directory, otherwise the copy embedded in
+// the binary.
+func profilesData(dataDir string) (afero.Fs, string) {
+	if dataDir != "" {
+		return afero.NewOsFs(), dataDir
+	}
+
+	// Try to find data directory relative to executable or current directory
+	execPath, err := os.Executable()
+	if err == nil {
+		// Check relative to executable
+		candidate := filepath.Join(filepath.Dir(execPath), "..", "data", "shell-profiles")
+		if _, err := os.Stat(candidate); err == nil {
+			return afero.NewOsFs(), candidate
 		}
+	}
 
-		if dataDir == "" {
-			return nil, fmt.Errorf("shell profiles data not found. Use --data-dir to specify location")
-		}
+	// Check relative to current directory
+	candidate := filepath.Join("data", "shell-profiles")
+	if _, err := os.Stat(candidate); err == nil {
+		return afero.NewOsFs(), candidate
 	}
 
-	loader := shellmeta.NewLoader(afero.NewOsFs())
-	return loader.Load(dataDir)
+	return afero.FromIOFS{FS: shellprofiles.FS}, "."
 }
 
 func runProfilesList(category string, flags *profilesFlags) error {
@@ -28,7 +28,11 @@ single shell configuration file.`,
 	cmd.AddCommand(newPrepareCmd())
 	cmd.AddCommand(newBuildCmd())
 	cmd.AddCommand(newDeployCmd())
+	cmd.AddCommand(newLocateCmd())
+	cmd.AddCommand(newStartupReportCmd())
 	cmd.AddCommand(newValidateCmd())
+	cmd.AddCommand(newSchemaCmd())
+	cmd.AddCommand(newFmtCmd())
 	cmd.AddCommand(newDoctorCmd())
 	cmd.AddCommand(newListCmd())
 	cmd.AddCommand(newBackupCmd())
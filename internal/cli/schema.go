@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/yamlparser"
+)
+
+func newSchemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema for manifest.yaml",
+		Long: `Schema prints the JSON Schema describing manifest.yaml (format versions
+1 and 2). It is generated from the same definitions the parser uses, so
+editors validate and complete exactly the keys shellforge accepts.
+
+Point a YAML language server at the saved schema with a modeline at the top
+of the manifest:
+
+  # yaml-language-server: $schema=./manifest.schema.json`,
+		Example: `  # Save the schema next to the manifest
+  shellforge schema > manifest.schema.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := json.MarshalIndent(yamlparser.ManifestSchema(), "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode schema: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+
+	return cmd
+}
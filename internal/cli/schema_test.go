@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaCmd_Help(t *testing.T) {
+	cmd := newSchemaCmd()
+
+	assert.Equal(t, "schema", cmd.Use)
+	assert.Contains(t, cmd.Short, "JSON Schema")
+	assert.Contains(t, cmd.Long, "yaml-language-server")
+	assert.NotEmpty(t, cmd.Example)
+}
+
+func TestSchemaCmd_PrintsValidJSON(t *testing.T) {
+	cmd := newSchemaCmd()
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs(nil)
+
+	require.NoError(t, cmd.Execute())
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &schema))
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+	assert.Contains(t, schema, "$defs")
+	assert.Contains(t, schema["properties"], "modules")
+}
+
+func TestSchemaCmd_RejectsArgs(t *testing.T) {
+	cmd := newSchemaCmd()
+	cmd.SetArgs([]string{"extra"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	assert.Error(t, cmd.Execute())
+}
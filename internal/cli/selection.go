@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/app"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+)
+
+// selectionFlags holds the module selection flags shared by build, list,
+// doctor, prepare and validate.
+type selectionFlags struct {
+	profile     string
+	tags        []string
+	excludeTags []string
+}
+
+// addSelectionFlags registers --profile, --tag and --exclude-tag on cmd.
+func addSelectionFlags(cmd *cobra.Command, f *selectionFlags, profileUsage string) {
+	cmd.Flags().StringVar(&f.profile, "profile", "", profileUsage)
+	cmd.Flags().StringArrayVar(&f.tags, "tag", nil, "Only modules with this tag, plus their dependencies (can be repeated)")
+	cmd.Flags().StringArrayVar(&f.excludeTags, "exclude-tag", nil, "Skip modules with this tag (can be repeated)")
+}
+
+// tagSelector converts --tag / --exclude-tag into a module selector.
+func (f *selectionFlags) tagSelector() domain.ModuleSelector {
+	return domain.ModuleSelector{IncludeTags: f.tags, ExcludeTags: f.excludeTags}
+}
+
+// selection builds the app selection; hostname enables profile auto-selection.
+func (f *selectionFlags) selection(hostname string) app.Selection {
+	return app.Selection{Profile: f.profile, Hostname: hostname, Tags: f.tagSelector()}
+}
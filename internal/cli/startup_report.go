@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/app"
+	clierrors "github.com/gizzahub/gzh-cli-shellforge/internal/cli/errors"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/cli/factory"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/cli/helpers"
+)
+
+// startupEnvVars are passed through from the caller's environment; the rest
+// of the sandbox shell's environment is clean.
+var startupEnvVars = []string{"PATH", "TERM", "USER", "LOGNAME", "LANG", "LC_ALL", "TMPDIR"}
+
+type startupReportFlags struct {
+	buildDir string
+	runs     int
+	login    bool
+	keepHome bool
+}
+
+func newStartupReportCmd() *cobra.Command {
+	flags := &startupReportFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "startup-report",
+		Short: "Rank modules by shell startup time",
+		Long: `Startup-report measures how long each module takes to load.
+
+It needs a build made with 'gz-shellforge build --profile-startup'. The build
+is deployed into a temporary home directory and the shell is started there
+as an interactive login shell with a clean environment (only PATH, TERM,
+USER, LOGNAME, LANG, LC_ALL and TMPDIR are passed through). The timing
+probes record each module's load time, and modules are listed slowest first.
+
+zsh and bash 5+ measure with $EPOCHREALTIME. fish calls date(1) for each
+probe, which adds a little time per module and needs a date supporting %N.
+
+Modules the shell never loaded (e.g. .bashrc when a login bash's
+.bash_profile does not source it; try --login=false) are listed separately.`,
+		Example: `  # Profile the default build
+  gz-shellforge build --profile-startup
+  gz-shellforge startup-report
+
+  # Average over 5 startups
+  gz-shellforge startup-report --runs 5
+
+  # Interactive non-login shell (bash: loads .bashrc only)
+  gz-shellforge startup-report --login=false`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStartupReport(cmd.OutOrStdout(), flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.buildDir, "build-dir", "d", "./build", "Build directory made with --profile-startup")
+	cmd.Flags().IntVarP(&flags.runs, "runs", "n", 1, "Number of shell startups to average")
+	cmd.Flags().BoolVar(&flags.login, "login", true, "Start a login shell (loads profile files as well as rc files)")
+	cmd.Flags().BoolVar(&flags.keepHome, "keep-home", false, "Keep the temporary home directory for inspection")
+
+	return cmd
+}
+
+func runStartupReport(w io.Writer, flags *startupReportFlags) error {
+	buildDir, err := helpers.ExpandHomePath(flags.buildDir)
+	if err != nil {
+		return clierrors.InvalidPath("build-dir", err)
+	}
+
+	home, err := os.MkdirTemp("", "shellforge-startup-")
+	if err != nil {
+		return fmt.Errorf("failed to create sandbox home: %w", err)
+	}
+	if flags.keepHome {
+		fmt.Fprintf(w, "Sandbox home: %s\n", home)
+	} else {
+		defer os.RemoveAll(home)
+	}
+
+	var env []string
+	for _, name := range startupEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	report, err := factory.NewServices().NewStartupReporter().Report(app.StartupReportOptions{
+		BuildDir: buildDir,
+		Home:     home,
+		Runs:     flags.runs,
+		Login:    flags.login,
+		Env:      env,
+	})
+	if err != nil {
+		return clierrors.WrapError("startup report", err)
+	}
+
+	printStartupReport(w, report)
+	return nil
+}
+
+func printStartupReport(w io.Writer, report *app.StartupReport) {
+	fmt.Fprintf(w, "Startup time by module (%s, %d run(s)):\n\n", report.Shell, report.Runs)
+	fmt.Fprintf(w, "  %4s  %-24s %-14s %10s %6s\n", "#", "MODULE", "TARGET", "TIME", "SHARE")
+	for i, t := range report.Modules {
+		share := 0.0
+		if report.Total > 0 {
+			share = 100 * float64(t.Duration) / float64(report.Total)
+		}
+		fmt.Fprintf(w, "  %4d  %-24s %-14s %10s %5.1f%%\n", i+1, t.Module, t.Target, formatMillis(t.Duration), share)
+	}
+	fmt.Fprintf(w, "\n  Modules total: %s\n", formatMillis(report.Total))
+	fmt.Fprintf(w, "  Shell startup: %s (including the shell itself)\n", formatMillis(report.Wall))
+
+	if len(report.Unmeasured) > 0 {
+		fmt.Fprintf(w, "\nNot loaded by this shell (%d):\n", len(report.Unmeasured))
+		for _, name := range report.Unmeasured {
+			fmt.Fprintf(w, "  - %s\n", name)
+		}
+	}
+}
+
+// formatMillis formats d in milliseconds with two decimals.
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+}
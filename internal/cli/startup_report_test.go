@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/app"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+)
+
+func TestStartupReportCmd_Flags(t *testing.T) {
+	cmd := newStartupReportCmd()
+	for name, def := range map[string]string{"build-dir": "./build", "runs": "1", "login": "true", "keep-home": "false"} {
+		flag := cmd.Flags().Lookup(name)
+		require.NotNil(t, flag, name)
+		assert.Equal(t, def, flag.DefValue, name)
+	}
+}
+
+func TestStartupReportCmd_RequiresProfiledBuild(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".shellforge-build.json"),
+		[]byte(`{"shell": "bash", "os": "Linux", "generated_at": "2024-01-01T00:00:00Z", "files": []}`), 0o644))
+
+	cmd := newStartupReportCmd()
+	cmd.SetArgs([]string{"--build-dir", dir})
+	cmd.SetOut(&bytes.Buffer{})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--profile-startup")
+}
+
+func TestPrintStartupReport(t *testing.T) {
+	var out bytes.Buffer
+	printStartupReport(&out, &app.StartupReport{
+		Shell: "zsh",
+		Runs:  3,
+		Modules: []domain.ModuleTiming{
+			{Module: "nvm", Target: "zshrc", Duration: 300 * time.Millisecond},
+			{Module: "aliases", Target: "zshrc", Duration: 100 * time.Millisecond},
+		},
+		Total:      400 * time.Millisecond,
+		Wall:       450 * time.Millisecond,
+		Unmeasured: []string{"logout"},
+	})
+
+	text := out.String()
+	assert.Contains(t, text, "(zsh, 3 run(s))")
+	assert.Regexp(t, `1\s+nvm\s+zshrc\s+300.00ms\s+75.0%`, text)
+	assert.Regexp(t, `2\s+aliases\s+zshrc\s+100.00ms\s+25.0%`, text)
+	assert.Contains(t, text, "Modules total: 400.00ms")
+	assert.Contains(t, text, "Shell startup: 450.00ms")
+	assert.Contains(t, text, "  - logout")
+}
@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
@@ -10,6 +12,8 @@ import (
 	"github.com/gizzahub/gzh-cli-shellforge/internal/cli/factory"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/cli/helpers"
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain/shellmeta"
+	"github.com/gizzahub/gzh-cli-shellforge/internal/infra/yamlparser"
 )
 
 type validateFlags struct {
@@ -17,6 +21,10 @@ type validateFlags struct {
 	manifest     string
 	verbose      bool
 	checkPrereqs bool
+	syntax       bool
+	tags         []string
+	excludeTags  []string
+	vars         []string
 }
 
 func newValidateCmd() *cobra.Command {
@@ -29,9 +37,40 @@ func newValidateCmd() *cobra.Command {
 definitions, checks for circular dependencies, and verifies that all
 referenced module files exist.
 
-This command performs validation without building the configuration,
-making it useful for quickly checking manifest correctness during
-development.`,
+Unknown keys (such as 'prority' or 'require'), values of the wrong type and
+invalid 'version' values are reported with file:line:column. The manifest
+JSON Schema is printed by 'shellforge schema'.
+
+For every OS named in the manifest, validate also reports modules whose
+'requires' point at a module excluded on that OS (an error) and
+'requires_optional' dependencies that will be skipped (a warning).
+
+Within a target, modules load after the modules they require and then by
+priority. A priority that would put a module before one it requires has no
+effect and is reported as a warning.
+
+'requires' between modules of different targets are checked against the
+order in which the shell reads its startup files in login, interactive
+non-login and non-interactive shells (from the shell profiles data): a
+dependency read after the module is an error, one that is not read at all
+in such a shell is a warning.
+
+Variables referenced as {{ .Vars.name }} in module paths, requires_path
+or 'interpolate: true' module bodies must be declared in vars, os_vars or a
+profile, or passed with --var.
+
+Every profile is checked for modules whose hard 'requires' the profile
+excludes. Pass --tag / --exclude-tag to check a tag selection the same way
+before building with it.
+
+With --syntax, validate also builds the configuration in memory for the
+current OS and parses each generated file with the target shell (zsh -n,
+bash -n or fish --no-execute), reporting parse errors against the module
+file and line. Nothing is written.
+
+Otherwise this command performs validation without building the
+configuration, making it useful for quickly checking manifest correctness
+during development.`,
 		Example: `  # Validate default manifest
   shellforge validate
 
@@ -42,7 +81,13 @@ development.`,
   shellforge validate --verbose
 
   # Also check external tool prerequisites (requires_bin / requires_path)
-  shellforge validate --check-prereqs`,
+  shellforge validate --check-prereqs
+
+  # Also check that the generated files are valid shell
+  shellforge validate --syntax
+
+  # Check that a tag selection keeps every required module
+  shellforge validate --tag core --exclude-tag gui`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runValidate(flags)
 		},
@@ -52,6 +97,10 @@ development.`,
 	cmd.Flags().StringVarP(&flags.manifest, "manifest", "m", "manifest.yaml", "Path to manifest file")
 	cmd.Flags().BoolVarP(&flags.verbose, "verbose", "v", false, "Show detailed validation output")
 	cmd.Flags().BoolVar(&flags.checkPrereqs, "check-prereqs", false, "Also check requires_bin / requires_path (warn only)")
+	cmd.Flags().BoolVar(&flags.syntax, "syntax", false, "Also parse the generated files with the target shell")
+	cmd.Flags().StringArrayVar(&flags.tags, "tag", nil, "Also check the selection of modules with this tag (can be repeated)")
+	cmd.Flags().StringArrayVar(&flags.excludeTags, "exclude-tag", nil, "Exclude modules with this tag from the checked selection (can be repeated)")
+	cmd.Flags().StringArrayVar(&flags.vars, "var", nil, "Set a manifest variable, name=value (can be repeated)")
 
 	return cmd
 }
@@ -66,10 +115,27 @@ func runValidate(flags *validateFlags) error {
 	services := factory.NewServices()
 
 	manifest, err := services.Parser.Parse(flags.manifest)
+	var schemaErr *yamlparser.SchemaError
+	if errors.As(err, &schemaErr) {
+		// Report every unknown key / wrong type with its location
+		findings := make([]app.Finding, len(schemaErr.Issues))
+		for i, issue := range schemaErr.Issues {
+			findings[i] = app.Finding{Severity: app.SeverityError, Message: issue.Error()}
+		}
+		printFindings(findings)
+		return fmt.Errorf("validation failed with %d error(s)", len(findings))
+	}
 	if err != nil {
 		return clierrors.WrapError("manifest parsing", err)
 	}
 
+	// --var values count as declared and take part in path interpolation
+	vars, err := helpers.ParseVars(flags.vars)
+	if err != nil {
+		return err
+	}
+	manifest.Vars = manifest.ResolveVars("", "", vars)
+
 	if flags.verbose {
 		fmt.Printf("✓ Manifest parsed (%d modules)\n\n", len(manifest.Modules))
 	}
@@ -78,8 +144,18 @@ func runValidate(flags *validateFlags) error {
 	validators := []app.Validator{
 		app.ManifestStructureValidator{},
 		app.CircularDependencyValidator{},
-		app.NewFileExistenceValidator(services.Reader),
+		app.PriorityConflictValidator{},
+		app.ExcludedDependencyValidator{},
+	}
+	modes, loadErr := loadShellLoadModes()
+	if loadErr == nil {
+		validators = append(validators, app.LoadOrderValidator{Modes: modes})
 	}
+	validators = append(validators,
+		app.SelectionValidator{Extra: domain.ModuleSelector{IncludeTags: flags.tags, ExcludeTags: flags.excludeTags}},
+		app.NewVariableValidator(services.Reader),
+		app.NewFileExistenceValidator(services.Reader),
+	)
 	if flags.checkPrereqs {
 		targetOS := helpers.DetectOS()
 		validators = append(validators, app.NewPrereqValidator(targetOS, domain.OsPrereqLookup{}))
@@ -87,6 +163,20 @@ func runValidate(flags *validateFlags) error {
 
 	pipeline := app.NewValidationPipeline(validators...)
 	findings := pipeline.Run(manifest, flags.configDir)
+	if loadErr != nil {
+		findings = append(findings, app.Finding{
+			Severity: app.SeverityWarn,
+			Message:  fmt.Sprintf("cross-target load order not checked: %v", loadErr),
+		})
+	}
+
+	if flags.syntax && !app.HasErrors(findings) {
+		syntaxFindings, err := checkSyntax(services, flags, vars)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, syntaxFindings...)
+	}
 
 	if len(findings) == 0 {
 		fmt.Printf("✓ Validation successful!\n")
@@ -109,6 +199,41 @@ func runValidate(flags *validateFlags) error {
 	return nil
 }
 
+// checkSyntax builds the configuration in memory and parses it with the
+// target shell.
+func checkSyntax(services *factory.Services, flags *validateFlags, vars map[string]string) ([]app.Finding, error) {
+	targetOS := helpers.DetectOS()
+	result, err := services.NewBuilder().Build(app.BuildOptions{
+		ConfigDir: flags.configDir,
+		Manifest:  flags.manifest,
+		OS:        targetOS,
+		DryRun:    true,
+		Tags:      domain.ModuleSelector{IncludeTags: flags.tags, ExcludeTags: flags.excludeTags},
+		Vars:      vars,
+		Host:      detectHostFacts(targetOS, ""),
+	})
+	if err != nil {
+		return nil, clierrors.WrapError("build", err)
+	}
+
+	vr, err := app.VerifySyntax(result, services.Syntax)
+	if err != nil {
+		return nil, clierrors.WrapError("syntax verification", err)
+	}
+	return app.SyntaxFindings(vr), nil
+}
+
+// loadShellLoadModes returns the startup file order of each shell from the
+// shell profiles data.
+func loadShellLoadModes() (map[string]shellmeta.LoadModes, error) {
+	fs, dataDir := profilesData("")
+	core, err := shellmeta.NewLoader(fs).LoadCore(filepath.Join(dataDir, "core.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shell profiles data: %w", err)
+	}
+	return core.LoadPriority.Modes, nil
+}
+
 func printFindings(findings []app.Finding) {
 	errCount := countBySeverity(findings, app.SeverityError)
 	warnCount := countBySeverity(findings, app.SeverityWarn)
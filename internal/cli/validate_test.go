@@ -1,6 +1,9 @@
 package cli
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -28,6 +31,10 @@ func TestValidateCmd_Flags(t *testing.T) {
 	checkPrereqsFlag := cmd.Flags().Lookup("check-prereqs")
 	require.NotNil(t, checkPrereqsFlag)
 	assert.Equal(t, "false", checkPrereqsFlag.DefValue)
+
+	syntaxFlag := cmd.Flags().Lookup("syntax")
+	require.NotNil(t, syntaxFlag)
+	assert.Equal(t, "false", syntaxFlag.DefValue)
 }
 
 func TestValidateCmd_Help(t *testing.T) {
@@ -106,6 +113,49 @@ func TestRunValidate_VerboseOutput(t *testing.T) {
 	assert.NoError(t, err, "validation should succeed in verbose mode")
 }
 
+func TestRunValidate_SchemaErrors(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`version: "3"
+modules:
+  - name: git
+    file: git.sh
+    prority: 10
+`), 0o644))
+
+	err := runValidate(&validateFlags{configDir: dir, manifest: manifestPath})
+	require.Error(t, err)
+	assert.Equal(t, "validation failed with 2 error(s)", err.Error())
+}
+
+func TestRunValidate_Syntax(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`shell:
+  type: bash
+modules:
+  - name: good
+    file: good.sh
+    target: bashrc
+  - name: broken
+    file: broken.sh
+    target: bashrc
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "good.sh"), []byte("echo ok\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.sh"), []byte("if true; then\n  echo\n"), 0o644))
+
+	// The manifest itself is fine
+	require.NoError(t, runValidate(&validateFlags{configDir: dir, manifest: manifestPath}))
+
+	err := runValidate(&validateFlags{configDir: dir, manifest: manifestPath, syntax: true})
+	require.Error(t, err)
+	assert.Equal(t, "validation failed with 1 error(s)", err.Error())
+}
+
 func TestValidateCmd_LongDescription(t *testing.T) {
 	cmd := newValidateCmd()
 	long := cmd.Long
@@ -130,3 +180,28 @@ func TestValidateCmd_UsageText(t *testing.T) {
 	assert.Contains(t, usage, "Flags:", "usage should list flags")
 	assert.Contains(t, usage, "--manifest", "usage should show --manifest flag")
 }
+
+func TestRunValidate_LoadOrderWithoutDataDir(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`modules:
+  - name: env
+    file: env.sh
+    target: zshenv
+    requires: [path]
+  - name: path
+    file: path.sh
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "env.sh"), []byte("echo env\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "path.sh"), []byte("echo path\n"), 0o644))
+
+	// No data/shell-profiles here: the embedded startup file order is used
+	t.Chdir(dir)
+	modes, err := loadShellLoadModes()
+	require.NoError(t, err)
+	assert.Contains(t, modes, "zsh")
+
+	err = runValidate(&validateFlags{configDir: dir, manifest: manifestPath})
+	require.Error(t, err, "zshenv is read before zshrc, so 'env' loads before 'path'")
+	assert.Equal(t, "validation failed with 1 error(s)", err.Error())
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+)
+
+// CacheFileName is the name of the build cache in the build directory,
+// written next to MetadataFileName.
+const CacheFileName = ".shellforge-cache.json"
+
+// BuildCache records what each generated file was built from, so a rebuild
+// can skip files whose inputs have not changed.
+type BuildCache struct {
+	// ManifestHash covers everything besides module file contents that
+	// shapes the output: resolved modules, shell, OS, profile, variables.
+	// A cache with a different hash is discarded as a whole.
+	ManifestHash string `json:"manifest_hash"`
+	// Files is keyed by the path within the build directory
+	// (BuildFileInfo.Source).
+	Files map[string]CachedFile `json:"files"`
+}
+
+// CachedFile is the cache entry of one generated file.
+type CachedFile struct {
+	Modules     []CachedModule `json:"modules"`      // inputs, in output order
+	ContentHash string         `json:"content_hash"` // SHA-256 of the generated file
+	Sources     SourceMap      `json:"sources,omitempty"`
+}
+
+// CachedModule is the SHA-256 of a module's content as included in a file.
+type CachedModule struct {
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+}
+
+// NewBuildCache creates an empty cache for the given manifest hash.
+func NewBuildCache(manifestHash string) *BuildCache {
+	return &BuildCache{ManifestHash: manifestHash, Files: make(map[string]CachedFile)}
+}
+
+// Lookup returns the entry for file if it was built from exactly modules.
+func (c *BuildCache) Lookup(file string, modules []CachedModule) (CachedFile, bool) {
+	entry, ok := c.Files[file]
+	if !ok || !slices.Equal(entry.Modules, modules) {
+		return CachedFile{}, false
+	}
+	return entry, true
+}
+
+// HashContent returns the hex SHA-256 of content.
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// ToJSON serializes the cache to JSON.
+func (c *BuildCache) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// ParseBuildCache deserializes a cache from JSON.
+func ParseBuildCache(data []byte) (*BuildCache, error) {
+	var cache BuildCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+	if cache.Files == nil {
+		cache.Files = make(map[string]CachedFile)
+	}
+	return &cache, nil
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCache_Lookup(t *testing.T) {
+	cache := NewBuildCache("abc")
+	modules := []CachedModule{{Name: "a", Hash: HashContent("echo a")}, {Name: "b", Hash: HashContent("echo b")}}
+	cache.Files[".zshrc"] = CachedFile{Modules: modules, ContentHash: HashContent("out")}
+
+	entry, ok := cache.Lookup(".zshrc", []CachedModule{modules[0], modules[1]})
+	require.True(t, ok)
+	assert.Equal(t, HashContent("out"), entry.ContentHash)
+
+	_, ok = cache.Lookup(".zshrc", []CachedModule{modules[1], modules[0]})
+	assert.False(t, ok, "module order is part of the key")
+	_, ok = cache.Lookup(".zshrc", []CachedModule{modules[0], {Name: "b", Hash: HashContent("echo B")}})
+	assert.False(t, ok, "changed content")
+	_, ok = cache.Lookup(".zprofile", modules)
+	assert.False(t, ok)
+}
+
+func TestBuildCache_RoundTrip(t *testing.T) {
+	cache := NewBuildCache("abc")
+	cache.Files["conf.d/git.fish"] = CachedFile{
+		Modules:     []CachedModule{{Name: "git", Hash: HashContent("x")}},
+		ContentHash: HashContent("y"),
+		Sources:     SourceMap{{Module: "git", File: "git.fish", StartLine: 9, Lines: 1, SourceLine: 1}},
+	}
+	data, err := cache.ToJSON()
+	require.NoError(t, err)
+
+	parsed, err := ParseBuildCache(data)
+	require.NoError(t, err)
+	assert.Equal(t, cache, parsed)
+
+	empty, err := ParseBuildCache([]byte(`{"manifest_hash": "abc"}`))
+	require.NoError(t, err)
+	assert.NotNil(t, empty.Files)
+
+	_, err = ParseBuildCache([]byte("not json"))
+	assert.Error(t, err)
+}
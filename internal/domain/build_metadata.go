@@ -2,6 +2,8 @@ package domain
 
 import (
 	"encoding/json"
+	"path"
+	"strings"
 	"time"
 )
 
@@ -9,7 +11,10 @@ import (
 type BuildMetadata struct {
 	Shell       string          `json:"shell"`
 	OS          string          `json:"os"`
+	Profile     string          `json:"profile,omitempty"`
+	ConfigDir   string          `json:"config_dir,omitempty"` // module directory, as passed to build
 	GeneratedAt time.Time       `json:"generated_at"`
+	Profiling   bool            `json:"profiling,omitempty"` // built with startup timing probes
 	Files       []BuildFileInfo `json:"files"`
 }
 
@@ -21,6 +26,30 @@ type BuildFileInfo struct {
 	Target string `json:"target"`
 	// DestPath is the relative path from home directory (e.g., ".zshrc", ".config/fish/config.fish")
 	DestPath string `json:"dest_path"`
+	// Directory marks a file of a directory target (conf.d, zshrc.d, a
+	// custom directory); deploy removes stale generated files next to it
+	Directory bool `json:"directory,omitempty"`
+	// Sources maps the file's lines back to module files
+	Sources SourceMap `json:"sources,omitempty"`
+}
+
+// FindFile returns the build file named by name: a target ("zshrc"), a path
+// in the build directory ("conf.d/git.fish") or a deployed path (".zshrc",
+// "~/.zshrc", "/home/me/.zshrc").
+func (m *BuildMetadata) FindFile(name string) (*BuildFileInfo, bool) {
+	for _, match := range []func(BuildFileInfo) bool{
+		func(f BuildFileInfo) bool { return f.Source == name || f.DestPath == name },
+		func(f BuildFileInfo) bool { return f.Target == name && !strings.Contains(f.Source, "/") },
+		func(f BuildFileInfo) bool { return strings.HasSuffix(name, "/"+f.DestPath) },
+		func(f BuildFileInfo) bool { return path.Base(f.DestPath) == path.Base(name) },
+	} {
+		for i := range m.Files {
+			if match(m.Files[i]) {
+				return &m.Files[i], true
+			}
+		}
+	}
+	return nil, false
 }
 
 // MetadataFileName is the name of the metadata file in the build directory.
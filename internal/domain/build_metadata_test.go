@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMetadata_FindFile(t *testing.T) {
+	meta := &BuildMetadata{Files: []BuildFileInfo{
+		{Source: ".zshrc", Target: "zshrc", DestPath: ".zshrc"},
+		{Source: ".zprofile", Target: "zprofile", DestPath: ".zprofile"},
+		{Source: "conf.d/git.fish", Target: "conf.d", DestPath: ".config/fish/conf.d/git.fish"},
+	}}
+
+	tests := map[string]string{
+		"zshrc":                                 ".zshrc",
+		".zshrc":                                ".zshrc",
+		"~/.zshrc":                              ".zshrc",
+		"/home/me/.zprofile":                    ".zprofile",
+		"conf.d/git.fish":                       "conf.d/git.fish",
+		"/home/me/.config/fish/conf.d/git.fish": "conf.d/git.fish",
+		"git.fish":                              "conf.d/git.fish",
+	}
+	for name, want := range tests {
+		file, ok := meta.FindFile(name)
+		require.True(t, ok, name)
+		assert.Equal(t, want, file.Source, name)
+	}
+
+	_, ok := meta.FindFile("conf.d")
+	assert.False(t, ok, "a directory target names no single file")
+	_, ok = meta.FindFile("bashrc")
+	assert.False(t, ok)
+}
+
+func TestBuildMetadata_SourcesRoundTrip(t *testing.T) {
+	meta := &BuildMetadata{Shell: "zsh", Files: []BuildFileInfo{{
+		Source: ".zshrc", Target: "zshrc", DestPath: ".zshrc",
+		Sources: SourceMap{{Module: "git", File: "git.zsh", StartLine: 10, Lines: 2, SourceLine: 1}},
+	}}}
+	data, err := meta.ToJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"start_line": 10`)
+
+	parsed, err := ParseBuildMetadata(data)
+	require.NoError(t, err)
+	assert.Equal(t, meta.Files[0].Sources, parsed.Files[0].Sources)
+}
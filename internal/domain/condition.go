@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// HostFacts describes the machine a build targets. It is the input of every
+// module selection predicate (see Module.Evaluate). Facts left empty are
+// unknown, and conditions on unknown facts never match.
+type HostFacts struct {
+	OS       string            // shellforge OS name (Mac, Linux, ...)
+	Arch     string            // CPU architecture, GOARCH style (amd64, arm64)
+	Distro   string            // Linux distribution ID (ubuntu, arch, ...)
+	Hostname string            // machine hostname
+	Shell    string            // shell being built for (zsh, bash, fish)
+	Env      map[string]string // environment variables
+}
+
+// Condition is a module's `when:` block. All leaf checks that are set must
+// hold; each leaf matches if any of its values match. All, Any and Not
+// combine nested conditions.
+type Condition struct {
+	Arch     []string `yaml:"arch,omitempty"`     // CPU architectures (amd64, arm64; x86_64/aarch64 accepted)
+	Distro   []string `yaml:"distro,omitempty"`   // Linux distribution IDs
+	Hostname []string `yaml:"hostname,omitempty"` // hostname glob patterns
+	Env      []string `yaml:"env,omitempty"`      // NAME (must be set) or NAME=value
+	Shell    []string `yaml:"shell,omitempty"`    // shell types
+
+	All []Condition `yaml:"all,omitempty"`
+	Any []Condition `yaml:"any,omitempty"`
+	Not *Condition  `yaml:"not,omitempty"`
+}
+
+// Evaluate reports whether the condition holds for facts, with a short
+// human-readable reason for the outcome.
+func (c *Condition) Evaluate(facts HostFacts) (bool, string) {
+	var matched []string
+
+	leaves := []struct {
+		name   string
+		values []string
+		actual string
+		match  func(want, actual string) bool
+	}{
+		{"arch", c.Arch, normalizeArch(facts.Arch), func(w, a string) bool { return normalizeArch(w) == a }},
+		{"distro", c.Distro, strings.ToLower(facts.Distro), func(w, a string) bool { return strings.ToLower(w) == a }},
+		{"hostname", c.Hostname, strings.ToLower(facts.Hostname), func(w, a string) bool {
+			ok, _ := path.Match(strings.ToLower(w), a)
+			return ok
+		}},
+		{"shell", c.Shell, strings.ToLower(facts.Shell), func(w, a string) bool { return strings.ToLower(w) == a }},
+	}
+	for _, leaf := range leaves {
+		if len(leaf.values) == 0 {
+			continue
+		}
+		if leaf.actual == "" {
+			return false, fmt.Sprintf("%s unknown, want %s", leaf.name, formatList(leaf.values))
+		}
+		if !slices.ContainsFunc(leaf.values, func(w string) bool { return leaf.match(w, leaf.actual) }) {
+			return false, fmt.Sprintf("%s %s not in %s", leaf.name, leaf.actual, formatList(leaf.values))
+		}
+		matched = append(matched, leaf.name+" "+leaf.actual)
+	}
+
+	if len(c.Env) > 0 {
+		if !slices.ContainsFunc(c.Env, func(spec string) bool { return envMatches(spec, facts.Env) }) {
+			return false, fmt.Sprintf("env %s not set", formatList(c.Env))
+		}
+		matched = append(matched, "env "+formatList(c.Env))
+	}
+
+	for i := range c.All {
+		ok, reason := c.All[i].Evaluate(facts)
+		if !ok {
+			return false, "all: " + reason
+		}
+		matched = append(matched, reason)
+	}
+
+	if len(c.Any) > 0 {
+		var reasons []string
+		anyMatched := false
+		for i := range c.Any {
+			ok, reason := c.Any[i].Evaluate(facts)
+			if ok {
+				matched = append(matched, reason)
+				anyMatched = true
+				break
+			}
+			reasons = append(reasons, reason)
+		}
+		if !anyMatched {
+			return false, "any: none matched (" + strings.Join(reasons, "; ") + ")"
+		}
+	}
+
+	if c.Not != nil {
+		ok, reason := c.Not.Evaluate(facts)
+		if ok {
+			return false, "not: " + reason
+		}
+		matched = append(matched, "not ("+reason+")")
+	}
+
+	return true, strings.Join(matched, ", ")
+}
+
+// Validate checks that glob patterns and env specs are well formed.
+func (c *Condition) Validate() error {
+	for _, pattern := range c.Hostname {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid hostname pattern '%s'", pattern)
+		}
+	}
+	for _, spec := range c.Env {
+		if name, _, _ := strings.Cut(spec, "="); name == "" {
+			return fmt.Errorf("invalid env condition '%s'", spec)
+		}
+	}
+	for _, nested := range slices.Concat(c.All, c.Any) {
+		if err := nested.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Not != nil {
+		return c.Not.Validate()
+	}
+	return nil
+}
+
+// envMatches checks a NAME or NAME=value spec against the environment.
+func envMatches(spec string, env map[string]string) bool {
+	name, want, hasValue := strings.Cut(spec, "=")
+	value, set := env[name]
+	if !set {
+		return false
+	}
+	return !hasValue || value == want
+}
+
+// normalizeArch maps common uname spellings onto GOARCH names.
+func normalizeArch(arch string) string {
+	switch strings.ToLower(arch) {
+	case "x86_64", "x64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	default:
+		return strings.ToLower(arch)
+	}
+}
+
+func formatList(values []string) string {
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+// Decision is the outcome of evaluating a module against host facts.
+type Decision struct {
+	Included bool
+	Reason   string
+}
+
+// Evaluate decides whether the module applies to the host described by facts,
+// combining the os: filter and the when: condition. This is the single
+// selection predicate used by the resolver, doctor and list.
+func (m *Module) Evaluate(facts HostFacts) Decision {
+	var reasons []string
+
+	if len(m.OS) > 0 {
+		if !m.AppliesTo(facts.OS) {
+			return Decision{Reason: fmt.Sprintf("os %s not in %s", facts.OS, formatList(m.OS))}
+		}
+		reasons = append(reasons, "os "+facts.OS)
+	}
+
+	if m.When != nil {
+		ok, reason := m.When.Evaluate(facts)
+		if !ok {
+			return Decision{Reason: "when: " + reason}
+		}
+		reasons = append(reasons, reason)
+	}
+
+	if len(reasons) == 0 {
+		return Decision{Included: true, Reason: "no conditions"}
+	}
+	return Decision{Included: true, Reason: strings.Join(reasons, ", ")}
+}
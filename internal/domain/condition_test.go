@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCondition_Evaluate(t *testing.T) {
+	mac := HostFacts{
+		OS:       "Mac",
+		Arch:     "arm64",
+		Hostname: "jane-corp-mbp",
+		Shell:    "zsh",
+		Env:      map[string]string{"WORK": "1", "PROXY": "http://proxy"},
+	}
+	linux := HostFacts{OS: "Linux", Arch: "amd64", Distro: "ubuntu", Hostname: "srv-01", Shell: "bash"}
+
+	tests := []struct {
+		name   string
+		when   string
+		facts  HostFacts
+		want   bool
+		reason string
+	}{
+		{name: "arch match", when: "arch: [arm64]", facts: mac, want: true, reason: "arch arm64"},
+		{name: "arch alias", when: "arch: [x86_64]", facts: linux, want: true},
+		{name: "arch mismatch", when: "arch: [arm64]", facts: linux, want: false, reason: "arch amd64 not in [arm64]"},
+		{name: "distro", when: "distro: [Ubuntu, debian]", facts: linux, want: true},
+		{name: "distro unknown", when: "distro: [ubuntu]", facts: mac, want: false, reason: "distro unknown, want [ubuntu]"},
+		{name: "hostname glob", when: "hostname: ['*-corp-*']", facts: mac, want: true},
+		{name: "env presence", when: "env: [WORK]", facts: mac, want: true},
+		{name: "env value", when: "env: [PROXY=http://other]", facts: mac, want: false, reason: "env [PROXY=http://other] not set"},
+		{name: "shell", when: "shell: [bash]", facts: linux, want: true},
+		{name: "leaves are and-ed", when: "{arch: [arm64], shell: [bash]}", facts: mac, want: false, reason: "shell zsh not in [bash]"},
+		{
+			name:  "any",
+			when:  "any: [{distro: [arch]}, {hostname: ['srv-*']}]",
+			facts: linux, want: true,
+		},
+		{
+			name:  "any none matched",
+			when:  "any: [{distro: [arch]}, {shell: [fish]}]",
+			facts: linux, want: false,
+			reason: "any: none matched (distro ubuntu not in [arch]; shell bash not in [fish])",
+		},
+		{name: "all", when: "all: [{arch: [amd64]}, {distro: [ubuntu]}]", facts: linux, want: true},
+		{name: "not", when: "not: {env: [CI]}", facts: mac, want: true},
+		{name: "not matched", when: "not: {arch: [arm64]}", facts: mac, want: false, reason: "not: arch arm64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cond Condition
+			if err := yaml.Unmarshal([]byte(tt.when), &cond); err != nil {
+				t.Fatalf("bad condition yaml: %v", err)
+			}
+			got, reason := cond.Evaluate(tt.facts)
+			assert.Equal(t, tt.want, got, reason)
+			if tt.reason != "" {
+				assert.Equal(t, tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestCondition_Validate(t *testing.T) {
+	assert.NoError(t, (&Condition{Hostname: []string{"work-*"}, Env: []string{"A", "B=c"}}).Validate())
+	assert.Error(t, (&Condition{Hostname: []string{"["}}).Validate())
+	assert.Error(t, (&Condition{Any: []Condition{{Env: []string{"=x"}}}}).Validate())
+	assert.Error(t, (&Condition{Not: &Condition{Hostname: []string{"["}}}).Validate())
+}
+
+func TestModule_Evaluate(t *testing.T) {
+	facts := HostFacts{OS: "Mac", Arch: "arm64"}
+
+	tests := []struct {
+		name     string
+		module   Module
+		included bool
+		reason   string
+	}{
+		{name: "no conditions", module: Module{Name: "a"}, included: true, reason: "no conditions"},
+		{name: "os only", module: Module{Name: "a", OS: []string{"Mac"}}, included: true, reason: "os Mac"},
+		{name: "os excluded", module: Module{Name: "a", OS: []string{"Linux"}}, included: false, reason: "os Mac not in [Linux]"},
+		{
+			name:     "os and when",
+			module:   Module{Name: "a", OS: []string{"Mac"}, When: &Condition{Arch: []string{"arm64"}}},
+			included: true, reason: "os Mac, arch arm64",
+		},
+		{
+			name:     "when excluded",
+			module:   Module{Name: "a", When: &Condition{Arch: []string{"amd64"}}},
+			included: false, reason: "when: arch arm64 not in [amd64]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.module.Evaluate(facts)
+			assert.Equal(t, tt.included, d.Included)
+			assert.Equal(t, tt.reason, d.Reason)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Custom target kinds.
+const (
+	TargetKindFile      = "file"      // all modules merged into one file (default)
+	TargetKindDirectory = "directory" // one file per module, like zshrc.d
+)
+
+// CustomTarget is a target declared in the manifest's 'targets:' section,
+// for files the built-in targets do not cover, such as ~/.config/shell/env.sh.
+type CustomTarget struct {
+	// Path is the file or directory, relative to the home directory.
+	Path string `yaml:"path"`
+	// Kind is "file" (default) or "directory".
+	Kind string `yaml:"kind,omitempty"`
+	// Comment starts the generated header lines (default "#").
+	Comment string `yaml:"comment,omitempty"`
+	// Extension is the suffix of module files in a directory (default ".sh").
+	Extension string `yaml:"extension,omitempty"`
+	// SourcedBy names the target whose file sources this one; the builder
+	// appends the source line (or loop, for a directory) to it. Only targets
+	// with SourcedBy get startup timing probes.
+	SourcedBy string `yaml:"sourced_by,omitempty"`
+}
+
+// IsDirectory reports whether the target gets one file per module.
+func (t CustomTarget) IsDirectory() bool {
+	return t.Kind == TargetKindDirectory
+}
+
+// RelativePath returns Path without a leading "~/", cleaned.
+func (t CustomTarget) RelativePath() string {
+	return filepath.Clean(strings.TrimPrefix(t.Path, "~/"))
+}
+
+// CommentPrefix returns the header comment prefix.
+func (t CustomTarget) CommentPrefix() string {
+	if t.Comment == "" {
+		return "#"
+	}
+	return t.Comment
+}
+
+// FileExtension returns the extension of module files in a directory target.
+func (t CustomTarget) FileExtension() string {
+	if t.Extension == "" {
+		return ".sh"
+	}
+	if !strings.HasPrefix(t.Extension, ".") {
+		return "." + t.Extension
+	}
+	return t.Extension
+}
+
+// Validate checks the parts of a custom target that do not depend on the
+// shell; TargetResolver.AddCustomTargets checks names and SourcedBy.
+func (t CustomTarget) Validate(name string) error {
+	if name == "" || name != strings.ToLower(name) {
+		return NewValidationError("target '%s' must have a lowercase name", name)
+	}
+	if IsSystemTarget(name) {
+		return NewValidationError("target '%s' cannot redefine a system target", name)
+	}
+	if t.Path == "" {
+		return NewValidationError("target '%s' is missing required field: path", name)
+	}
+	if rel := strings.TrimPrefix(t.Path, "~/"); !filepath.IsLocal(rel) {
+		return NewValidationError("target '%s' has invalid path '%s': must be relative to the home directory", name, t.Path)
+	}
+	if rel := t.RelativePath(); rel == "." || rel == "~" {
+		return NewValidationError("target '%s' has invalid path '%s': must name a file or directory inside the home directory, not the home directory itself", name, t.Path)
+	}
+	switch t.Kind {
+	case "", TargetKindFile, TargetKindDirectory:
+	default:
+		return NewValidationError("target '%s' has invalid kind '%s': must be '%s' or '%s'", name, t.Kind, TargetKindFile, TargetKindDirectory)
+	}
+	if t.Extension != "" && !t.IsDirectory() {
+		return NewValidationError("target '%s' sets extension but is not a directory", name)
+	}
+	if strings.ContainsAny(t.CommentPrefix(), "\n\r") {
+		return NewValidationError("target '%s' has invalid comment: must be a single line", name)
+	}
+	if strings.EqualFold(t.SourcedBy, name) {
+		return NewValidationError("target '%s' cannot be sourced by itself", name)
+	}
+	return nil
+}
+
+// CustomTargetNames returns the names of the manifest's custom targets in
+// sorted order.
+func (m *Manifest) CustomTargetNames() []string {
+	names := make([]string, 0, len(m.Targets))
+	for name := range m.Targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomTarget_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		def     CustomTarget
+		wantErr string
+	}{
+		{name: "file", target: "env", def: CustomTarget{Path: ".config/shell/env.sh", SourcedBy: "zshrc"}},
+		{name: "tilde path", target: "env", def: CustomTarget{Path: "~/.config/shell/env.sh"}},
+		{name: "directory", target: "snippets", def: CustomTarget{Path: ".config/shell/d", Kind: "directory", Extension: "zsh"}},
+		{name: "missing path", target: "env", def: CustomTarget{}, wantErr: "missing required field: path"},
+		{name: "absolute path", target: "env", def: CustomTarget{Path: "/etc/env.sh"}, wantErr: "must be relative to the home directory"},
+		{name: "escaping path", target: "env", def: CustomTarget{Path: "../env.sh"}, wantErr: "must be relative to the home directory"},
+		{name: "home directory", target: "d", def: CustomTarget{Path: ".", Kind: "directory"}, wantErr: "not the home directory itself"},
+		{name: "tilde home directory", target: "d", def: CustomTarget{Path: "~"}, wantErr: "not the home directory itself"},
+		{name: "bad kind", target: "env", def: CustomTarget{Path: "env.sh", Kind: "link"}, wantErr: "invalid kind 'link'"},
+		{name: "extension on file", target: "env", def: CustomTarget{Path: "env.sh", Extension: ".sh"}, wantErr: "not a directory"},
+		{name: "system name", target: "etc-profile", def: CustomTarget{Path: "profile"}, wantErr: "system target"},
+		{name: "uppercase name", target: "Env", def: CustomTarget{Path: "env.sh"}, wantErr: "lowercase name"},
+		{name: "sourced by itself", target: "env", def: CustomTarget{Path: "env.sh", SourcedBy: "env"}, wantErr: "sourced by itself"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.def.Validate(tt.target)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCustomTarget_Defaults(t *testing.T) {
+	def := CustomTarget{Path: "~/.inputrc.d/"}
+	assert.Equal(t, ".inputrc.d", def.RelativePath())
+	assert.Equal(t, "#", def.CommentPrefix())
+	assert.Equal(t, ".sh", def.FileExtension())
+	assert.False(t, def.IsDirectory())
+
+	def = CustomTarget{Path: "x", Kind: TargetKindDirectory, Comment: ";", Extension: "zsh"}
+	assert.Equal(t, ";", def.CommentPrefix())
+	assert.Equal(t, ".zsh", def.FileExtension())
+	assert.True(t, def.IsDirectory())
+}
+
+func TestTargetResolver_AddCustomTargets(t *testing.T) {
+	resolver := NewTargetResolver("zsh", "/home/user")
+	err := resolver.AddCustomTargets(map[string]CustomTarget{
+		"env":      {Path: ".config/shell/env.sh", SourcedBy: "zshenv"},
+		"snippets": {Path: "~/.config/shell/zsh.d", Kind: "directory", Extension: ".zsh", SourcedBy: "env"},
+		"inputrc":  {Path: ".inputrc.d/shellforge", Comment: "#"},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, resolver.IsValidTarget("env"))
+	assert.Contains(t, resolver.GetValidTargets(), "snippets")
+	require.NoError(t, resolver.ValidateTargets([]Module{{Name: "a", Target: "env"}}))
+
+	path, err := resolver.Resolve("env")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/home/user", ".config", "shell", "env.sh"), path)
+	rel, err := resolver.GetRelativePath("snippets")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(".config", "shell", "zsh.d"), rel)
+
+	assert.True(t, resolver.IsDirectoryTarget("snippets"))
+	assert.False(t, resolver.IsDirectoryTarget("env"))
+	assert.Equal(t, ".zsh", resolver.DirectoryFileExt("snippets"))
+	assert.Equal(t, ".zsh", resolver.DirectoryFileExt("zshrc.d"))
+	assert.Equal(t, "zshenv", resolver.SourcedBy("env"))
+	assert.Equal(t, "", resolver.SourcedBy("zshrc"))
+	assert.True(t, resolver.IsShellSourced("zshrc"))
+	assert.True(t, resolver.IsShellSourced("env"))
+	assert.False(t, resolver.IsShellSourced("inputrc"))
+
+	// Custom targets belong to the resolver they were added to
+	assert.False(t, NewTargetResolver("zsh", "/home/user").IsValidTarget("env"))
+}
+
+func TestTargetResolver_AddCustomTargets_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets map[string]CustomTarget
+		wantErr string
+	}{
+		{
+			name:    "built-in name",
+			targets: map[string]CustomTarget{"zshrc": {Path: ".zshrc2"}},
+			wantErr: "already a built-in target",
+		},
+		{
+			name:    "unknown sourced_by",
+			targets: map[string]CustomTarget{"env": {Path: "env.sh", SourcedBy: "bashrc"}},
+			wantErr: "sourced by 'bashrc', which is not a file target for shell type 'zsh'",
+		},
+		{
+			name:    "sourced by a directory",
+			targets: map[string]CustomTarget{"env": {Path: "env.sh", SourcedBy: "zshrc.d"}},
+			wantErr: "not a file target",
+		},
+		{
+			name:    "invalid definition",
+			targets: map[string]CustomTarget{"env": {}},
+			wantErr: "missing required field: path",
+		},
+		{
+			name: "file inside a custom directory",
+			targets: map[string]CustomTarget{
+				"shell": {Path: ".config/shell", Kind: "directory"},
+				"env":   {Path: ".config/shell/env.sh"},
+			},
+			wantErr: "target 'env' path '.config/shell/env.sh' overlaps target 'shell' (.config/shell)",
+		},
+		{
+			name:    "directory containing a built-in file",
+			targets: map[string]CustomTarget{"all": {Path: "~/.config", Kind: "directory"}, "x": {Path: ".config/x.sh"}},
+			wantErr: "overlaps target",
+		},
+		{
+			name:    "directory at a built-in directory",
+			targets: map[string]CustomTarget{"snippets": {Path: ".zshrc.d", Kind: "directory"}},
+			wantErr: "overlaps target 'zshrc.d'",
+		},
+		{
+			name:    "file inside a built-in directory",
+			targets: map[string]CustomTarget{"extra": {Path: ".zshrc.d/extra.zsh"}},
+			wantErr: "overlaps target 'zshrc.d'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewTargetResolver("zsh", "/home/user").AddCustomTargets(tt.targets)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestManifest_Validate_CustomTargets(t *testing.T) {
+	m := &Manifest{
+		Targets: map[string]CustomTarget{
+			"env": {Path: "env.sh"},
+			"bad": {Path: "/abs"},
+		},
+	}
+	errs := m.Validate()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "target 'bad'")
+}
+
+func TestManifest_ApplyLayer_Targets(t *testing.T) {
+	m := &Manifest{Targets: map[string]CustomTarget{"env": {Path: "a.sh"}, "keep": {Path: "k.sh"}}}
+	m.ApplyLayer(&Manifest{Targets: map[string]CustomTarget{"env": {Path: "b.sh"}}})
+	assert.Equal(t, map[string]CustomTarget{"env": {Path: "b.sh"}, "keep": {Path: "k.sh"}}, m.Targets)
+}
@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DependencyCycle is an ordered chain of modules in which each module
+// requires the next and the last requires the first.
+type DependencyCycle struct {
+	Modules   []string
+	Locations []SourceLocation // manifest location of each module, parallel to Modules
+}
+
+// String formats the cycle as "a → b → c → a".
+func (c DependencyCycle) String() string {
+	if len(c.Modules) == 0 {
+		return ""
+	}
+	return strings.Join(append(append([]string{}, c.Modules...), c.Modules[0]), " → ")
+}
+
+// Describe formats the cycle with manifest references when they are known,
+// e.g. "a (manifest.yaml:3) → b (manifest.yaml:7) → a".
+func (c DependencyCycle) Describe() string {
+	if len(c.Modules) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(c.Modules)+1)
+	for i, name := range c.Modules {
+		if i < len(c.Locations) && !c.Locations[i].IsZero() {
+			parts = append(parts, name+" ("+c.Locations[i].String()+")")
+		} else {
+			parts = append(parts, name)
+		}
+	}
+	parts = append(parts, c.Modules[0])
+	return strings.Join(parts, " → ")
+}
+
+// FindCycles reports the dependency cycles among modules, following both hard
+// and optional requires. Dependencies on modules outside the list are ignored.
+//
+// Strongly connected components are found with Tarjan's algorithm. Within each
+// component, a shortest cycle is reconstructed for every "requires" edge not
+// already covered by a reported cycle, so each distinct loop is reported once
+// without enumerating every elementary cycle. Results are deterministic and
+// follow the order of modules.
+func FindCycles(modules []Module) []DependencyCycle {
+	index := make(map[string]int, len(modules))
+	for i, mod := range modules {
+		if _, dup := index[mod.Name]; !dup {
+			index[mod.Name] = i
+		}
+	}
+
+	// adjacency in "requires" direction, restricted to known modules
+	adj := make([][]int, len(modules))
+	for i, mod := range modules {
+		if index[mod.Name] != i {
+			continue // duplicate name: the first definition wins
+		}
+		seen := make(map[int]bool)
+		for _, dep := range mod.AllRequires() {
+			if j, ok := index[dep]; ok && !seen[j] {
+				seen[j] = true
+				adj[i] = append(adj[i], j)
+			}
+		}
+	}
+
+	var cycles []DependencyCycle
+	for _, scc := range tarjanSCC(adj) {
+		if len(scc) == 1 && !slices.Contains(adj[scc[0]], scc[0]) {
+			continue
+		}
+		for _, path := range componentCycles(adj, scc) {
+			cycle := DependencyCycle{}
+			for _, n := range path {
+				cycle.Modules = append(cycle.Modules, modules[n].Name)
+				cycle.Locations = append(cycle.Locations, modules[n].Location)
+			}
+			cycles = append(cycles, cycle)
+		}
+	}
+	return cycles
+}
+
+// tarjanSCC returns the strongly connected components of adj. Each component
+// is sorted by node index and components are ordered by their smallest node.
+func tarjanSCC(adj [][]int) [][]int {
+	n := len(adj)
+	idx := make([]int, n)
+	low := make([]int, n)
+	onStack := make([]bool, n)
+	for i := range idx {
+		idx[i] = -1
+	}
+
+	var (
+		stack   []int
+		counter int
+		comps   [][]int
+	)
+
+	var strongConnect func(v int)
+	strongConnect = func(v int) {
+		idx[v] = counter
+		low[v] = counter
+		counter++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range adj[v] {
+			if idx[w] == -1 {
+				strongConnect(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], idx[w])
+			}
+		}
+
+		if low[v] == idx[v] {
+			var comp []int
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				comp = append(comp, w)
+				if w == v {
+					break
+				}
+			}
+			slices.Sort(comp)
+			comps = append(comps, comp)
+		}
+	}
+
+	for v := 0; v < n; v++ {
+		if idx[v] == -1 {
+			strongConnect(v)
+		}
+	}
+
+	// order components by their first (smallest) node
+	slices.SortFunc(comps, func(a, b []int) int { return a[0] - b[0] })
+	return comps
+}
+
+// componentCycles reconstructs cycles inside one strongly connected component
+// until every edge of the component lies on at least one reported cycle.
+func componentCycles(adj [][]int, comp []int) [][]int {
+	inComp := make(map[int]bool, len(comp))
+	for _, v := range comp {
+		inComp[v] = true
+	}
+
+	type edge struct{ from, to int }
+	covered := make(map[edge]bool)
+	seen := make(map[string]bool)
+	var cycles [][]int
+
+	for _, u := range comp {
+		for _, v := range adj[u] {
+			if !inComp[v] || covered[edge{u, v}] {
+				continue
+			}
+			// shortest path v → … → u closes the cycle through edge u → v
+			path := shortestPath(adj, inComp, v, u)
+			if path == nil {
+				continue
+			}
+			cycle := rotateToMin(append([]int{u}, path[:len(path)-1]...))
+			for i := range cycle {
+				covered[edge{cycle[i], cycle[(i+1)%len(cycle)]}] = true
+			}
+			if key := fmt.Sprint(cycle); !seen[key] {
+				seen[key] = true
+				cycles = append(cycles, cycle)
+			}
+		}
+	}
+	return cycles
+}
+
+// shortestPath returns the BFS path from → … → to within the component,
+// or nil if none exists. Neighbours are visited in adjacency order.
+func shortestPath(adj [][]int, inComp map[int]bool, from, to int) []int {
+	prev := map[int]int{from: -1}
+	queue := []int{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == to {
+			var path []int
+			for n := to; n != -1; n = prev[n] {
+				path = append([]int{n}, path...)
+			}
+			return path
+		}
+		for _, next := range adj[cur] {
+			if _, visited := prev[next]; visited || !inComp[next] {
+				continue
+			}
+			prev[next] = cur
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// rotateToMin rotates a cycle so it starts at its smallest node.
+func rotateToMin(cycle []int) []int {
+	start := 0
+	for i, v := range cycle {
+		if v < cycle[start] {
+			start = i
+		}
+	}
+	return append(append([]int{}, cycle[start:]...), cycle[:start]...)
+}
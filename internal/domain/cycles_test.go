@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cycleStrings(cycles []DependencyCycle) []string {
+	out := make([]string, len(cycles))
+	for i, c := range cycles {
+		out[i] = c.String()
+	}
+	return out
+}
+
+func TestFindCycles(t *testing.T) {
+	tests := []struct {
+		name    string
+		modules []Module
+		want    []string
+	}{
+		{
+			name: "acyclic",
+			modules: []Module{
+				{Name: "a"},
+				{Name: "b", Requires: []string{"a"}},
+			},
+			want: []string{},
+		},
+		{
+			name: "self dependency",
+			modules: []Module{
+				{Name: "a", Requires: []string{"a"}},
+			},
+			want: []string{"a → a"},
+		},
+		{
+			name: "three-node cycle in requires order",
+			modules: []Module{
+				{Name: "a", Requires: []string{"b"}},
+				{Name: "b", Requires: []string{"c"}},
+				{Name: "c", Requires: []string{"a"}},
+			},
+			want: []string{"a → b → c → a"},
+		},
+		{
+			name: "downstream modules are not part of the cycle",
+			modules: []Module{
+				{Name: "x", Requires: []string{"a"}},
+				{Name: "a", Requires: []string{"b"}},
+				{Name: "b", Requires: []string{"a"}},
+				{Name: "y", Requires: []string{"x"}},
+			},
+			want: []string{"a → b → a"},
+		},
+		{
+			name: "two independent cycles",
+			modules: []Module{
+				{Name: "a", Requires: []string{"b"}},
+				{Name: "b", Requires: []string{"a"}},
+				{Name: "c", Requires: []string{"d"}},
+				{Name: "d", Requires: []string{"c"}},
+			},
+			want: []string{"a → b → a", "c → d → c"},
+		},
+		{
+			name: "overlapping cycles in one component",
+			modules: []Module{
+				{Name: "a", Requires: []string{"b"}},
+				{Name: "b", Requires: []string{"a", "c"}},
+				{Name: "c", Requires: []string{"b"}},
+			},
+			want: []string{"a → b → a", "b → c → b"},
+		},
+		{
+			name: "optional dependencies participate",
+			modules: []Module{
+				{Name: "a", RequiresOptional: []string{"b"}},
+				{Name: "b", Requires: []string{"a"}},
+			},
+			want: []string{"a → b → a"},
+		},
+		{
+			name: "unknown dependencies are ignored",
+			modules: []Module{
+				{Name: "a", Requires: []string{"ghost"}},
+			},
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cycleStrings(FindCycles(tt.modules)))
+		})
+	}
+}
+
+func TestDependencyCycle_Describe(t *testing.T) {
+	modules := []Module{
+		{Name: "a", Requires: []string{"b"}, Location: SourceLocation{File: "manifest.yaml", Line: 2}},
+		{Name: "b", Requires: []string{"a"}, Location: SourceLocation{File: "manifest.yaml", Line: 5}},
+	}
+
+	cycles := FindCycles(modules)
+	require.Len(t, cycles, 1)
+	assert.Equal(t, "a (manifest.yaml:2) → b (manifest.yaml:5) → a", cycles[0].Describe())
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// generatedMarker ends the first line of every file shellforge builds.
+const generatedMarker = "Generated by shellforge"
+
+// GeneratedHeader is the first line of every file shellforge builds with
+// the default "#" comments. Only files starting with such a line are ever
+// removed as stale.
+const GeneratedHeader = "# " + generatedMarker
+
+// GeneratedHeaderLine returns the first line of a file whose comments start
+// with comment.
+func GeneratedHeaderLine(comment string) string {
+	return comment + " " + generatedMarker
+}
+
+// directoryTarget describes a target built as one file per module.
+type directoryTarget struct {
+	ext       string // extension of the module files
+	sourcedBy string // target whose file sources the directory; empty when the shell reads it itself
+}
+
+// directoryTargets lists the directory targets. fish reads conf.d on its
+// own; ~/.zshrc.d and ~/.bashrc.d are a convention the rc file has to
+// source (see DirectoryLoader).
+var directoryTargets = map[string]directoryTarget{
+	"conf.d":   {ext: ".fish"},
+	"zshrc.d":  {ext: ".zsh", sourcedBy: "zshrc"},
+	"bashrc.d": {ext: ".sh", sourcedBy: "bashrc"},
+}
+
+// DirectoryFileName returns the name of the file a module is built into in
+// a directory target whose files end in ext. Shells source these files in
+// lexical order, so the name is prefixed with the module's position in the
+// target's resolved order, zero-padded to the width of count:
+// "01-path.zsh", "02-git.zsh".
+func DirectoryFileName(ext string, position, count int, module string) string {
+	width := max(2, len(fmt.Sprint(count)))
+	return fmt.Sprintf("%0*d-%s%s", width, position, sanitizeFileName(module), ext)
+}
+
+// DirectorySourcedBy returns the target whose file has to source the
+// directory target, or "" when the shell loads it by itself.
+func DirectorySourcedBy(target string) string {
+	return directoryTargets[strings.ToLower(target)].sourcedBy
+}
+
+// DirectoryLoader returns the lines that source every file ending in ext
+// of a directory target, in lexical order, from the rc file that loads it.
+// dir is the directory's home-relative path.
+func DirectoryLoader(shell, target, dir, ext string) []string {
+	switch strings.ToLower(shell) {
+	case "fish":
+		// An unmatched glob in a for loop expands to nothing in fish
+		return []string{
+			fmt.Sprintf("# --- %s loader ---", target),
+			fmt.Sprintf(`for __shellforge_file in "$HOME/%s"/*%s`, path.Clean(dir), ext),
+			"    source $__shellforge_file",
+			"end",
+			"set -e __shellforge_file",
+		}
+	case "zsh":
+		// (N): expand to nothing when the directory is empty
+		ext += "(N)"
+	}
+	return []string{
+		fmt.Sprintf("# --- %s loader ---", target),
+		fmt.Sprintf(`for __shellforge_file in "$HOME/%s"/*%s; do`, path.Clean(dir), ext),
+		`  [ -r "$__shellforge_file" ] && . "$__shellforge_file"`,
+		"done",
+		"unset __shellforge_file",
+	}
+}
+
+// FileLoader returns the lines that source a file target from the rc file
+// that loads it. file is the file's home-relative path.
+func FileLoader(shell, target, file string) []string {
+	if strings.ToLower(shell) == "fish" {
+		return []string{
+			fmt.Sprintf("# --- %s loader ---", target),
+			fmt.Sprintf(`test -r "$HOME/%s"; and source "$HOME/%s"`, path.Clean(file), path.Clean(file)),
+		}
+	}
+	return []string{
+		fmt.Sprintf("# --- %s loader ---", target),
+		fmt.Sprintf(`[ -r "$HOME/%s" ] && . "$HOME/%s"`, path.Clean(file), path.Clean(file)),
+	}
+}
+
+// IsGeneratedFile reports whether content was written by shellforge, with
+// any comment style.
+func IsGeneratedFile(content string) bool {
+	first, _, found := strings.Cut(content, "\n")
+	return found && strings.HasSuffix(first, " "+generatedMarker)
+}
+
+// sanitizeFileName converts a module name to a safe filename.
+// Replaces spaces and special characters with underscores.
+func sanitizeFileName(name string) string {
+	name = strings.ReplaceAll(name, " ", "_")
+	name = strings.ReplaceAll(name, "/", "_")
+	name = strings.ReplaceAll(name, "\\", "_")
+	name = strings.ReplaceAll(name, ":", "_")
+	return strings.ToLower(name)
+}
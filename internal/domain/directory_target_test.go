@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectoryFileName(t *testing.T) {
+	tests := []struct {
+		ext      string
+		position int
+		count    int
+		module   string
+		want     string
+	}{
+		{".zsh", 1, 3, "git", "01-git.zsh"},
+		{".sh", 12, 12, "My Tools", "12-my_tools.sh"},
+		{".fish", 7, 150, "nvm/init", "007-nvm_init.fish"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, DirectoryFileName(tt.ext, tt.position, tt.count, tt.module))
+		})
+	}
+}
+
+func TestDirectorySourcedBy(t *testing.T) {
+	assert.Equal(t, "zshrc", DirectorySourcedBy("zshrc.d"))
+	assert.Equal(t, "bashrc", DirectorySourcedBy("bashrc.d"))
+	assert.Equal(t, "", DirectorySourcedBy("conf.d"), "fish reads conf.d itself")
+	assert.Equal(t, "", DirectorySourcedBy("zshrc"))
+}
+
+func TestDirectoryLoader(t *testing.T) {
+	assert.Equal(t, []string{
+		"# --- zshrc.d loader ---",
+		`for __shellforge_file in "$HOME/.zshrc.d"/*.zsh(N); do`,
+		`  [ -r "$__shellforge_file" ] && . "$__shellforge_file"`,
+		"done",
+		"unset __shellforge_file",
+	}, DirectoryLoader("zsh", "zshrc.d", ".zshrc.d", ".zsh"))
+
+	assert.Equal(t, `for __shellforge_file in "$HOME/.bashrc.d"/*.sh; do`,
+		DirectoryLoader("bash", "bashrc.d", ".bashrc.d/", ".sh")[1])
+
+	assert.Equal(t, []string{
+		"# --- snippets loader ---",
+		`for __shellforge_file in "$HOME/.config/shell/fish.d"/*.fish`,
+		"    source $__shellforge_file",
+		"end",
+		"set -e __shellforge_file",
+	}, DirectoryLoader("fish", "snippets", ".config/shell/fish.d", ".fish"))
+}
+
+func TestFileLoader(t *testing.T) {
+	assert.Equal(t, []string{
+		"# --- env loader ---",
+		`[ -r "$HOME/.config/shell/env.sh" ] && . "$HOME/.config/shell/env.sh"`,
+	}, FileLoader("zsh", "env", ".config/shell/env.sh"))
+
+	assert.Equal(t, `test -r "$HOME/.config/shell/env.fish"; and source "$HOME/.config/shell/env.fish"`,
+		FileLoader("fish", "env", ".config/shell/env.fish")[1])
+}
+
+func TestIsGeneratedFile(t *testing.T) {
+	assert.True(t, IsGeneratedFile("# Generated by shellforge\n# Shell: zsh\n"))
+	assert.True(t, IsGeneratedFile(GeneratedHeaderLine(";")+"\n"))
+	assert.False(t, IsGeneratedFile("# my own file\n"))
+	assert.False(t, IsGeneratedFile(""))
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ValidationError represents a validation failure.
 type ValidationError struct {
@@ -19,6 +22,7 @@ func NewValidationError(format string, args ...any) *ValidationError {
 // CircularDependencyError represents a circular dependency in the module graph.
 type CircularDependencyError struct {
 	Message string
+	Cycles  []DependencyCycle // each distinct cycle, when known
 }
 
 func (e *CircularDependencyError) Error() string {
@@ -30,6 +34,25 @@ func NewCircularDependencyError(format string, args ...any) *CircularDependencyE
 	return &CircularDependencyError{Message: fmt.Sprintf(format, args...)}
 }
 
+// NewCycleError creates a circular dependency error listing each cycle as an
+// ordered chain with its manifest references.
+func NewCycleError(cycles []DependencyCycle) *CircularDependencyError {
+	if len(cycles) == 1 {
+		return &CircularDependencyError{
+			Message: "circular dependency detected: " + cycles[0].Describe(),
+			Cycles:  cycles,
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d circular dependencies detected:", len(cycles))
+	for _, c := range cycles {
+		sb.WriteString("\n  ")
+		sb.WriteString(c.Describe())
+	}
+	return &CircularDependencyError{Message: sb.String(), Cycles: cycles}
+}
+
 // FileNotFoundError represents a missing file.
 type FileNotFoundError struct {
 	Path string
@@ -43,3 +66,31 @@ func (e *FileNotFoundError) Error() string {
 func NewFileNotFoundError(path string) *FileNotFoundError {
 	return &FileNotFoundError{Path: path}
 }
+
+// ExcludedDependencyError reports a hard dependency on a module that the OS
+// filter removed from the build.
+type ExcludedDependencyError struct {
+	Module     string   // module declaring the requirement
+	Dependency string   // required module that was excluded
+	OS         string   // target OS that excluded the dependency
+	DepOS      []string // OS list of the excluded dependency
+	Reason     string   // why the dependency was excluded, if not by OS alone
+}
+
+func (e *ExcludedDependencyError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf(
+			"module '%s' requires '%s', which is excluded on %s (%s); use requires_optional for a soft dependency",
+			e.Module, e.Dependency, e.OS, e.Reason,
+		)
+	}
+	return fmt.Sprintf(
+		"module '%s' requires '%s', which is excluded on %s ('%s' applies to: %s); use requires_optional for a soft dependency",
+		e.Module, e.Dependency, e.OS, e.Dependency, strings.Join(e.DepOS, ", "),
+	)
+}
+
+// NewExcludedDependencyError creates a new excluded dependency error.
+func NewExcludedDependencyError(module, dependency, targetOS string, depOS []string) *ExcludedDependencyError {
+	return &ExcludedDependencyError{Module: module, Dependency: dependency, OS: targetOS, DepOS: depOS}
+}
@@ -0,0 +1,364 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Generator names, in the order their blocks are emitted.
+const (
+	GeneratorEnv     = "env"
+	GeneratorPath    = "path"
+	GeneratorSource  = "source"
+	GeneratorAliases = "aliases"
+)
+
+var (
+	envNamePattern     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	aliasNamePattern   = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.:+-]*$`)
+	commandNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.+-]*$`)
+
+	// posixOnlyExpansion matches double-quoted syntax fish does not expand
+	// like a POSIX shell: ${...}, $(...), special parameters ($?, $1, ...),
+	// a bare $ and backquoted commands.
+	posixOnlyExpansion = regexp.MustCompile("\\$([^A-Za-z_]|$)|`")
+)
+
+// ModuleGenerator builds a module body from declarations instead of a file
+// (the module's 'generate:' field), rendered for the target shell, so one
+// manifest builds zsh, bash and fish. Each set generator adds a block: env,
+// then path, then source, then aliases; If wraps them in a runtime check.
+type ModuleGenerator struct {
+	// Env exports environment variables, in name order.
+	Env map[string]string `yaml:"env,omitempty"`
+
+	// Path puts directories at the front of PATH, the first entry first.
+	Path []string `yaml:"path,omitempty"`
+
+	// Source lists files to source, in order; a file that does not exist is
+	// skipped.
+	Source []string `yaml:"source,omitempty"`
+
+	// Aliases defines aliases, in name order.
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+
+	// If runs the generated body only when its conditions hold as the shell
+	// starts.
+	If *RuntimeCondition `yaml:"if,omitempty"`
+}
+
+// RuntimeCondition is checked by the generated code each time the shell
+// starts, unlike a module's 'when:', which is decided at build time. Every
+// listed check must pass.
+type RuntimeCondition struct {
+	// Command lists commands that must be found on PATH.
+	Command []string `yaml:"command,omitempty"`
+
+	// File lists files that must exist.
+	File []string `yaml:"file,omitempty"`
+
+	// Dir lists directories that must exist.
+	Dir []string `yaml:"dir,omitempty"`
+
+	// Env lists environment variables that must be set and non-empty.
+	Env []string `yaml:"env,omitempty"`
+}
+
+// Validate checks the runtime condition of module.
+func (c *RuntimeCondition) Validate(module string) error {
+	if len(c.Command)+len(c.File)+len(c.Dir)+len(c.Env) == 0 {
+		return NewValidationError("module '%s' has an empty 'if': set command, file, dir or env", module)
+	}
+	for _, name := range c.Command {
+		if !commandNamePattern.MatchString(name) {
+			return NewValidationError("module '%s' has invalid 'if' command '%s'", module, name)
+		}
+	}
+	for _, p := range append(append([]string{}, c.File...), c.Dir...) {
+		if p == "" || strings.ContainsAny(p, "\n\r") {
+			return NewValidationError("module '%s' has invalid 'if' path '%s'", module, p)
+		}
+	}
+	for _, name := range c.Env {
+		if !envNamePattern.MatchString(name) {
+			return NewValidationError("module '%s' has invalid 'if' env variable name '%s'", module, name)
+		}
+	}
+	return nil
+}
+
+// render returns the test expression of the condition in shell syntax.
+func (c *RuntimeCondition) render(fish bool) string {
+	var tests []string
+	for _, name := range c.Command {
+		if fish {
+			tests = append(tests, "type -q "+name)
+		} else {
+			tests = append(tests, "command -v "+name+" >/dev/null 2>&1")
+		}
+	}
+	for _, check := range []struct {
+		flag  string
+		paths []string
+	}{{"-f", c.File}, {"-d", c.Dir}} {
+		for _, p := range check.paths {
+			if fish {
+				tests = append(tests, fmt.Sprintf("test %s %s", check.flag, fishDoubleQuote(expandHome(p))))
+			} else {
+				tests = append(tests, fmt.Sprintf("[ %s %s ]", check.flag, shDoubleQuote(expandHome(p))))
+			}
+		}
+	}
+	for _, name := range c.Env {
+		if fish {
+			tests = append(tests, fmt.Sprintf(`test -n "$%s"`, name))
+		} else {
+			tests = append(tests, fmt.Sprintf(`[ -n "${%s:-}" ]`, name))
+		}
+	}
+	if fish {
+		return strings.Join(tests, "; and ")
+	}
+	return strings.Join(tests, " && ")
+}
+
+// Names returns the generators that are set.
+func (g *ModuleGenerator) Names() []string {
+	var names []string
+	if len(g.Env) > 0 {
+		names = append(names, GeneratorEnv)
+	}
+	if len(g.Path) > 0 {
+		names = append(names, GeneratorPath)
+	}
+	if len(g.Source) > 0 {
+		names = append(names, GeneratorSource)
+	}
+	if len(g.Aliases) > 0 {
+		names = append(names, GeneratorAliases)
+	}
+	return names
+}
+
+// Validate checks the generator declarations of module.
+func (g *ModuleGenerator) Validate(module string) error {
+	if len(g.Names()) == 0 {
+		return NewValidationError("module '%s' has an empty 'generate': set env, path, source or aliases", module)
+	}
+	for _, name := range sortedKeys(g.Env) {
+		if !envNamePattern.MatchString(name) {
+			return NewValidationError("module '%s' has invalid env variable name '%s'", module, name)
+		}
+		if strings.ContainsAny(g.Env[name], "\n\r") {
+			return NewValidationError("module '%s' env variable '%s' must be a single line", module, name)
+		}
+	}
+	for _, dir := range g.Path {
+		if dir == "" || strings.ContainsAny(dir, ":\n\r") {
+			return NewValidationError("module '%s' has invalid path entry '%s': must be non-empty and contain no ':' or newlines", module, dir)
+		}
+	}
+	for _, file := range g.Source {
+		if file == "" || strings.ContainsAny(file, "\n\r") {
+			return NewValidationError("module '%s' has invalid source entry '%s'", module, file)
+		}
+	}
+	for _, name := range sortedKeys(g.Aliases) {
+		if !aliasNamePattern.MatchString(name) {
+			return NewValidationError("module '%s' has invalid alias name '%s'", module, name)
+		}
+		if strings.ContainsAny(g.Aliases[name], "\n\r") {
+			return NewValidationError("module '%s' alias '%s' must be a single line", module, name)
+		}
+	}
+	if g.If != nil {
+		return g.If.Validate(module)
+	}
+	return nil
+}
+
+// Render returns the module body for shell: POSIX syntax for zsh, bash and
+// sh, native syntax for fish. Values are double-quoted, so $VAR references
+// expand; a leading ~ becomes $HOME. Sourcing a file written for another
+// shell (see CheckSourceable) is an error, and so is, for fish, a value using
+// POSIX-only expansions such as ${VAR:-x} or $(cmd).
+func (g *ModuleGenerator) Render(shell string) (string, error) {
+	fish := false
+	switch strings.ToLower(shell) {
+	case "zsh", "bash", "sh":
+	case "fish":
+		fish = true
+		if err := g.checkFish(); err != nil {
+			return "", err
+		}
+	default:
+		return "", NewValidationError("generate: unsupported shell type '%s'", shell)
+	}
+
+	var lines []string
+	for _, name := range sortedKeys(g.Env) {
+		value := expandHome(g.Env[name])
+		if fish {
+			lines = append(lines, fmt.Sprintf("set -gx %s %s", name, fishDoubleQuote(value)))
+		} else {
+			lines = append(lines, fmt.Sprintf("export %s=%s", name, shDoubleQuote(value)))
+		}
+	}
+	if len(g.Path) > 0 {
+		dirs := make([]string, len(g.Path))
+		for i, dir := range g.Path {
+			dirs[i] = expandHome(dir)
+		}
+		if fish {
+			for i, dir := range dirs {
+				dirs[i] = fishDoubleQuote(dir)
+			}
+			lines = append(lines, fmt.Sprintf("set -gx PATH %s $PATH", strings.Join(dirs, " ")))
+		} else {
+			lines = append(lines, fmt.Sprintf("export PATH=%s", shDoubleQuote(strings.Join(dirs, ":")+":$PATH")))
+		}
+	}
+	for _, file := range g.Source {
+		if err := CheckSourceable(shell, file, false); err != nil {
+			return "", NewValidationError("generate: %v; source it from a shell-specific module (when: {shell: ...})", err)
+		}
+		quoted := expandHome(file)
+		if fish {
+			quoted = fishDoubleQuote(quoted)
+			lines = append(lines, fmt.Sprintf("if test -f %s; source %s; end", quoted, quoted))
+		} else {
+			quoted = shDoubleQuote(quoted)
+			lines = append(lines, fmt.Sprintf("if [ -f %s ]; then . %s; fi", quoted, quoted))
+		}
+	}
+	for _, name := range sortedKeys(g.Aliases) {
+		if fish {
+			lines = append(lines, fmt.Sprintf("alias %s %s", name, fishSingleQuote(g.Aliases[name])))
+		} else {
+			lines = append(lines, fmt.Sprintf("alias %s=%s", name, shSingleQuote(g.Aliases[name])))
+		}
+	}
+
+	if g.If != nil {
+		indent := "  "
+		if fish {
+			indent = "    "
+		}
+		for i, line := range lines {
+			lines[i] = indent + line
+		}
+		if fish {
+			lines = append([]string{"if " + g.If.render(true)}, append(lines, "end")...)
+		} else {
+			lines = append([]string{"if " + g.If.render(false) + "; then"}, append(lines, "fi")...)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// checkFish returns an error naming the first value that fish would not
+// expand the way zsh and bash do.
+func (g *ModuleGenerator) checkFish() error {
+	type field struct{ name, value string }
+	var fields []field
+	for _, name := range sortedKeys(g.Env) {
+		fields = append(fields, field{"env '" + name + "'", g.Env[name]})
+	}
+	for _, dir := range g.Path {
+		fields = append(fields, field{"path entry", dir})
+	}
+	for _, file := range g.Source {
+		fields = append(fields, field{"source entry", file})
+	}
+	if g.If != nil {
+		for _, file := range g.If.File {
+			fields = append(fields, field{"'if' file", file})
+		}
+		for _, dir := range g.If.Dir {
+			fields = append(fields, field{"'if' dir", dir})
+		}
+	}
+
+	for _, f := range fields {
+		if m := posixOnlyExpansion.FindString(f.value); m != "" {
+			return NewValidationError("generate: %s '%s' uses '%s', which fish does not support; use plain $NAME references or a shell-specific module (when: {shell: ...})", f.name, f.value, m)
+		}
+	}
+	return nil
+}
+
+// shellFileExts maps script extensions to the only shells that can source
+// them; ".sh" is POSIX shell, which fish cannot read.
+var shellFileExts = map[string][]string{
+	".sh":   {"zsh", "bash", "sh"},
+	".bash": {"bash"},
+	".zsh":  {"zsh"},
+	".fish": {"fish"},
+}
+
+// CheckSourceable returns an error when file is, by its extension, a script
+// for another shell than shell. Fish cannot read POSIX scripts, so for fish
+// a file without the .fish extension is an error too, unless declared is
+// set: the author restricted the module to the shell (when: {shell: ...}).
+// Zsh, bash and sh accept files with other extensions (or none) unchecked.
+func CheckSourceable(shell, file string, declared bool) error {
+	ext := strings.ToLower(filepath.Ext(file))
+	shells, ok := shellFileExts[ext]
+	if !ok {
+		if strings.EqualFold(shell, "fish") && !declared {
+			return NewValidationError("'%s' has no .fish extension, so it is not known to be a fish script", file)
+		}
+		return nil
+	}
+	for _, s := range shells {
+		if strings.EqualFold(s, shell) {
+			return nil
+		}
+	}
+	return NewValidationError("'%s' is a %s script, which %s cannot load", file, strings.TrimPrefix(ext, "."), shell)
+}
+
+// expandHome replaces a leading ~ with $HOME, which expands inside double
+// quotes where ~ does not.
+func expandHome(s string) string {
+	if s == "~" || strings.HasPrefix(s, "~/") {
+		return "$HOME" + s[1:]
+	}
+	return s
+}
+
+// shDoubleQuote quotes s for a POSIX shell, keeping $ expansions.
+func shDoubleQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`")
+	return `"` + r.Replace(s) + `"`
+}
+
+// shSingleQuote quotes s literally for a POSIX shell.
+func shSingleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishDoubleQuote quotes s for fish, keeping $ expansions.
+func fishDoubleQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// fishSingleQuote quotes s literally for fish.
+func fishSingleQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(s) + "'"
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleGenerator_Render(t *testing.T) {
+	gen := &ModuleGenerator{
+		Env:     map[string]string{"GOPATH": "~/go", "PAGER": `less -R "x"`},
+		Path:    []string{"~/bin", "/opt/tools/bin"},
+		Aliases: map[string]string{"ll": "ls -la", "gs": "git status 'short'"},
+	}
+
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{
+			shell: "zsh",
+			want: `export GOPATH="$HOME/go"
+export PAGER="less -R \"x\""
+export PATH="$HOME/bin:/opt/tools/bin:$PATH"
+alias gs='git status '\''short'\'''
+alias ll='ls -la'
+`,
+		},
+		{
+			shell: "fish",
+			want: `set -gx GOPATH "$HOME/go"
+set -gx PAGER "less -R \"x\""
+set -gx PATH "$HOME/bin" "/opt/tools/bin" $PATH
+alias gs 'git status \'short\''
+alias ll 'ls -la'
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			got, err := gen.Render(tt.shell)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	bash, err := gen.Render("bash")
+	require.NoError(t, err)
+	assert.Equal(t, tests[0].want, bash)
+
+	_, err = gen.Render("tcsh")
+	assert.ErrorContains(t, err, "unsupported shell type 'tcsh'")
+}
+
+func TestModuleGenerator_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		gen     ModuleGenerator
+		wantErr string
+	}{
+		{name: "valid", gen: ModuleGenerator{Env: map[string]string{"EDITOR": "vim"}, Aliases: map[string]string{"g.": "git"}}},
+		{name: "empty", gen: ModuleGenerator{Env: map[string]string{}}, wantErr: "empty 'generate'"},
+		{name: "bad env name", gen: ModuleGenerator{Env: map[string]string{"MY-VAR": "x"}}, wantErr: "invalid env variable name 'MY-VAR'"},
+		{name: "multi-line env", gen: ModuleGenerator{Env: map[string]string{"A": "1\n2"}}, wantErr: "single line"},
+		{name: "path with colon", gen: ModuleGenerator{Path: []string{"/a:/b"}}, wantErr: "invalid path entry '/a:/b'"},
+		{name: "empty path", gen: ModuleGenerator{Path: []string{""}}, wantErr: "invalid path entry"},
+		{name: "bad alias name", gen: ModuleGenerator{Aliases: map[string]string{"l l": "ls"}}, wantErr: "invalid alias name 'l l'"},
+		{name: "source", gen: ModuleGenerator{Source: []string{"~/.cargo/env"}, If: &RuntimeCondition{Command: []string{"cargo"}}}},
+		{name: "empty source", gen: ModuleGenerator{Source: []string{""}}, wantErr: "invalid source entry"},
+		{name: "only if", gen: ModuleGenerator{If: &RuntimeCondition{Command: []string{"cargo"}}}, wantErr: "empty 'generate'"},
+		{name: "empty if", gen: ModuleGenerator{Source: []string{"a"}, If: &RuntimeCondition{}}, wantErr: "empty 'if'"},
+		{name: "bad if command", gen: ModuleGenerator{Source: []string{"a"}, If: &RuntimeCondition{Command: []string{"rm -rf"}}}, wantErr: "invalid 'if' command 'rm -rf'"},
+		{name: "bad if env", gen: ModuleGenerator{Source: []string{"a"}, If: &RuntimeCondition{Env: []string{"A-B"}}}, wantErr: "invalid 'if' env variable name 'A-B'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.gen.Validate("mod")
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestModuleGenerator_Render_SourceAndIf(t *testing.T) {
+	gen := &ModuleGenerator{
+		Env:    map[string]string{"CARGO_HOME": "~/.cargo"},
+		Source: []string{"~/.cargo/env.conf"},
+		If: &RuntimeCondition{
+			Command: []string{"cargo"},
+			File:    []string{"~/.cargo/env.conf"},
+			Dir:     []string{"/opt/x y"},
+			Env:     []string{"HOME"},
+		},
+	}
+
+	zsh, err := gen.Render("zsh")
+	require.NoError(t, err)
+	assert.Equal(t, `if command -v cargo >/dev/null 2>&1 && [ -f "$HOME/.cargo/env.conf" ] && [ -d "/opt/x y" ] && [ -n "${HOME:-}" ]; then
+  export CARGO_HOME="$HOME/.cargo"
+  if [ -f "$HOME/.cargo/env.conf" ]; then . "$HOME/.cargo/env.conf"; fi
+fi
+`, zsh)
+
+	// Fish only sources fish scripts
+	gen.Source = []string{"~/.cargo/env.fish"}
+	fish, err := gen.Render("fish")
+	require.NoError(t, err)
+	assert.Equal(t, `if type -q cargo; and test -f "$HOME/.cargo/env.conf"; and test -d "/opt/x y"; and test -n "$HOME"
+    set -gx CARGO_HOME "$HOME/.cargo"
+    if test -f "$HOME/.cargo/env.fish"; source "$HOME/.cargo/env.fish"; end
+end
+`, fish)
+
+	_, err = (&ModuleGenerator{Source: []string{"~/.fzf.zsh"}}).Render("fish")
+	assert.ErrorContains(t, err, "'~/.fzf.zsh' is a zsh script, which fish cannot load")
+	_, err = (&ModuleGenerator{Source: []string{"~/.cargo/env"}}).Render("fish")
+	assert.ErrorContains(t, err, "'~/.cargo/env' has no .fish extension")
+}
+
+func TestModuleGenerator_Render_PosixOnlyExpansions(t *testing.T) {
+	tests := []struct {
+		name    string
+		gen     ModuleGenerator
+		wantErr string
+	}{
+		{name: "braced variable", gen: ModuleGenerator{Env: map[string]string{"GOBIN": "${GOPATH}/bin"}}, wantErr: "env 'GOBIN' '${GOPATH}/bin' uses '${'"},
+		{name: "default value", gen: ModuleGenerator{Env: map[string]string{"PAGER": "${PAGER:-less}"}}, wantErr: "uses '${'"},
+		{name: "command substitution", gen: ModuleGenerator{Path: []string{"$(go env GOPATH)/bin"}}, wantErr: "path entry '$(go env GOPATH)/bin' uses '$('"},
+		{name: "backquotes", gen: ModuleGenerator{Source: []string{"`brew --prefix`/env"}}, wantErr: "source entry"},
+		{name: "special parameter", gen: ModuleGenerator{Env: map[string]string{"X": "$1"}}, wantErr: "uses '$1'"},
+		{name: "if file", gen: ModuleGenerator{Env: map[string]string{"A": "1"}, If: &RuntimeCondition{File: []string{"${XDG_CONFIG_HOME}/a"}}}, wantErr: "'if' file"},
+		{name: "if dir", gen: ModuleGenerator{Env: map[string]string{"A": "1"}, If: &RuntimeCondition{Dir: []string{"$(brew --prefix)"}}}, wantErr: "'if' dir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.gen.Render("fish")
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.ErrorContains(t, err, "which fish does not support")
+
+			_, err = tt.gen.Render("zsh")
+			assert.NoError(t, err, "POSIX shells expand it")
+		})
+	}
+
+	// Plain $NAME references work in both
+	_, err := (&ModuleGenerator{Env: map[string]string{"GOBIN": "$GOPATH/bin", "EDITOR": "vim"}}).Render("fish")
+	assert.NoError(t, err)
+}
+
+func TestCheckSourceable(t *testing.T) {
+	tests := []struct {
+		shell    string
+		file     string
+		declared bool
+		ok       bool
+	}{
+		{shell: "zsh", file: "git.sh", ok: true},
+		{shell: "bash", file: "git.sh", ok: true},
+		{shell: "fish", file: "git.sh"},
+		{shell: "fish", file: "git.fish", ok: true},
+		{shell: "zsh", file: "git.fish"},
+		{shell: "bash", file: "prompt.zsh"},
+		{shell: "zsh", file: "prompt.ZSH", ok: true},
+		{shell: "zsh", file: "completion.bash"},
+		{shell: "zsh", file: "~/.cargo/env", ok: true},
+		{shell: "fish", file: "~/.cargo/env"},
+		{shell: "fish", file: "~/.config/fish/env", declared: true, ok: true},
+		{shell: "fish", file: "git.sh", declared: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell+"/"+tt.file, func(t *testing.T) {
+			err := CheckSourceable(tt.shell, tt.file, tt.declared)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -4,12 +4,14 @@ package domain
 type Graph struct {
 	nodes map[string]*Node
 	edges map[string][]string // node -> list of dependents
+	order []string            // node names in insertion (manifest) order
 }
 
 // Node represents a node in the dependency graph.
 type Node struct {
 	Module   *Module
 	InDegree int
+	Index    int // insertion position, used as a stable tie-breaker
 }
 
 // NewGraph creates a new empty graph.
@@ -21,11 +23,18 @@ func NewGraph() *Graph {
 }
 
 // AddNode adds a module as a node in the graph.
+// Re-adding an existing name replaces its module but keeps its original position.
 func (g *Graph) AddNode(module *Module) {
+	if existing, ok := g.nodes[module.Name]; ok {
+		existing.Module = module
+		return
+	}
 	g.nodes[module.Name] = &Node{
 		Module:   module,
 		InDegree: 0,
+		Index:    len(g.order),
 	}
+	g.order = append(g.order, module.Name)
 }
 
 // AddEdge adds a directed edge from dependency to dependent.
@@ -59,11 +68,9 @@ func (g *Graph) GetDependents(name string) []string {
 	return g.edges[name]
 }
 
-// GetAllNodes returns all node names in the graph.
+// GetAllNodes returns all node names in the graph, in insertion order.
 func (g *Graph) GetAllNodes() []string {
-	names := make([]string, 0, len(g.nodes))
-	for name := range g.nodes {
-		names = append(names, name)
-	}
+	names := make([]string, len(g.order))
+	copy(names, g.order)
 	return names
 }
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IsIsolated reports whether the module's body is wrapped in an error guard.
+func (m *Module) IsIsolated() bool {
+	return m.Isolate != nil && *m.Isolate
+}
+
+// ModuleGuard is the code wrapped around an isolated module's body. The
+// body itself is emitted unchanged between Before and After, so heredocs
+// and source map line numbers are unaffected.
+type ModuleGuard struct {
+	Before []string
+	After  []string
+}
+
+// IsolationGuard returns the guard for module in the given shell. The body
+// runs in a function, so a failing command, a 'return' or a missing sourced
+// file stops at most that module; failures are reported on stderr as
+// "shellforge: module <name> failed (status N)" and the rest of the
+// configuration still loads.
+//
+//   - zsh and bash: an ERR trap records the status of any failing command,
+//     with the usual errexit exceptions (conditions, && and || lists). An
+//     ERR trap set before the module is restored afterwards.
+//   - fish has no error trap: the module fails when its last command or an
+//     explicit 'return' has a non-zero status.
+//   - other shells get the POSIX sh wrapper, which also checks only the
+//     final status.
+//
+// Since the body is a function, variables declared with local, typeset or
+// declare (zsh/bash) or 'set' without -g (fish) are local to the module.
+func IsolationGuard(shell, module string) ModuleGuard {
+	fn := "__shellforge_module_" + shellIdentifier(module)
+	failed := fmt.Sprintf("shellforge: module %s failed", module)
+
+	switch strings.ToLower(shell) {
+	case "fish":
+		return ModuleGuard{
+			Before: []string{"function " + fn},
+			After: []string{
+				"end",
+				fn,
+				fmt.Sprintf(`or echo "%s (status $status)" >&2`, escapeFishQuoted(failed)),
+				"functions -e " + fn,
+			},
+		}
+	case "zsh":
+		// With LOCAL_TRAPS set while the trap is set, zsh restores the
+		// previous ZERR trap when the function returns; the option itself
+		// is put back at once so the module's own traps behave as usual.
+		return ModuleGuard{
+			Before: []string{
+				fn + "() {",
+				"local __shellforge_localtraps=off",
+				"[[ -o localtraps ]] && __shellforge_localtraps=on",
+				"setopt localtraps",
+				"trap '__shellforge_status=$?' ZERR",
+				"[[ $__shellforge_localtraps == on ]] || unsetopt localtraps",
+			},
+			After: []string{
+				"}",
+				"__shellforge_status=0",
+				fn,
+				`if [ "$__shellforge_status" -ne 0 ]; then`,
+				fmt.Sprintf(`  echo "%s (status $__shellforge_status)" >&2`, escapeDoubleQuoted(failed)),
+				"fi",
+				"unset -f " + fn,
+				"unset __shellforge_status",
+			},
+		}
+	case "bash":
+		// The ERR trap in place before the module is saved and restored after
+		// it runs; bash has no equivalent of LOCAL_TRAPS.
+		return ModuleGuard{
+			Before: []string{
+				fn + "() {",
+				"trap '__shellforge_status=$?' ERR",
+			},
+			After: []string{
+				"}",
+				"__shellforge_status=0",
+				"__shellforge_err_trap=$(trap -p ERR)",
+				fn,
+				"trap - ERR",
+				`eval "$__shellforge_err_trap"`,
+				`if [ "$__shellforge_status" -ne 0 ]; then`,
+				fmt.Sprintf(`  echo "%s (status $__shellforge_status)" >&2`, escapeDoubleQuoted(failed)),
+				"fi",
+				"unset -f " + fn,
+				"unset __shellforge_status __shellforge_err_trap",
+			},
+		}
+	default:
+		return ModuleGuard{
+			Before: []string{fn + "() {"},
+			After: []string{
+				"}",
+				fn + ` || echo "` + escapeDoubleQuoted(failed) + ` (status $?)" >&2`,
+				"unset -f " + fn,
+			},
+		}
+	}
+}
+
+// shellIdentifier turns a module name into a function name suffix valid in
+// every supported shell.
+func shellIdentifier(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// escapeDoubleQuoted escapes s for a POSIX double-quoted string, leaving
+// nothing for the shell to expand.
+func escapeDoubleQuoted(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(s)
+}
+
+// escapeFishQuoted escapes s for a fish double-quoted string.
+func escapeFishQuoted(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`).Replace(s)
+}
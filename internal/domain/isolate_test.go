@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsolationGuard(t *testing.T) {
+	tests := []struct {
+		shell  string
+		before []string
+		after  []string
+	}{
+		{
+			shell: "zsh",
+			before: []string{
+				"__shellforge_module_git_prompt() {",
+				"local __shellforge_localtraps=off",
+				"[[ -o localtraps ]] && __shellforge_localtraps=on",
+				"setopt localtraps",
+				"trap '__shellforge_status=$?' ZERR",
+				"[[ $__shellforge_localtraps == on ]] || unsetopt localtraps",
+			},
+			after: []string{
+				"}", "__shellforge_status=0", "__shellforge_module_git_prompt",
+				`if [ "$__shellforge_status" -ne 0 ]; then`,
+				`  echo "shellforge: module git-prompt failed (status $__shellforge_status)" >&2`,
+				"fi", "unset -f __shellforge_module_git_prompt", "unset __shellforge_status",
+			},
+		},
+		{
+			shell:  "bash",
+			before: []string{"__shellforge_module_git_prompt() {", "trap '__shellforge_status=$?' ERR"},
+			after: []string{
+				"}", "__shellforge_status=0", "__shellforge_err_trap=$(trap -p ERR)", "__shellforge_module_git_prompt",
+				"trap - ERR", `eval "$__shellforge_err_trap"`,
+				`if [ "$__shellforge_status" -ne 0 ]; then`,
+				`  echo "shellforge: module git-prompt failed (status $__shellforge_status)" >&2`,
+				"fi", "unset -f __shellforge_module_git_prompt", "unset __shellforge_status __shellforge_err_trap",
+			},
+		},
+		{
+			shell:  "fish",
+			before: []string{"function __shellforge_module_git_prompt"},
+			after: []string{
+				"end", "__shellforge_module_git_prompt",
+				`or echo "shellforge: module git-prompt failed (status $status)" >&2`,
+				"functions -e __shellforge_module_git_prompt",
+			},
+		},
+		{
+			shell:  "sh",
+			before: []string{"__shellforge_module_git_prompt() {"},
+			after: []string{
+				"}",
+				`__shellforge_module_git_prompt || echo "shellforge: module git-prompt failed (status $?)" >&2`,
+				"unset -f __shellforge_module_git_prompt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			guard := IsolationGuard(tt.shell, "git-prompt")
+			assert.Equal(t, tt.before, guard.Before)
+			assert.Equal(t, tt.after, guard.After)
+		})
+	}
+}
+
+func TestIsolationGuard_EscapesModuleName(t *testing.T) {
+	guard := IsolationGuard("bash", `we"ird $name`)
+	assert.Equal(t, "__shellforge_module_we_ird__name() {", guard.Before[0])
+	assert.Contains(t, strings.Join(guard.After, "\n"), `module we\"ird \$name failed`)
+
+	guard = IsolationGuard("fish", `we"ird $name`)
+	assert.Contains(t, strings.Join(guard.After, "\n"), `module we\"ird \$name failed (status $status)`)
+}
+
+func TestModuleDefaults_Apply(t *testing.T) {
+	off, on := false, true
+	explicit := Module{Name: "a", Isolate: &off}
+	inherited := Module{Name: "b"}
+
+	defaults := ModuleDefaults{Isolate: &on}
+	defaults.Apply(&explicit)
+	defaults.Apply(&inherited)
+
+	assert.False(t, explicit.IsIsolated(), "module setting wins")
+	assert.True(t, inherited.IsIsolated())
+	assert.False(t, (&Module{}).IsIsolated())
+}
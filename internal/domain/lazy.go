@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// lazyTriggerPattern matches command names a lazy stub can be defined for.
+var lazyTriggerPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_-]*$`)
+
+// IsLazy reports whether the module body is loaded on first use of one of
+// its trigger commands instead of at startup.
+func (m *Module) IsLazy() bool {
+	return len(m.Lazy) > 0
+}
+
+// LazyLoader returns the code wrapped around a lazy module's body. The body
+// moves into a loader function; each trigger command gets a stub that
+// removes all stubs and the loader, runs the loader once and then re-runs
+// the command with its arguments, now resolved to whatever the body
+// defined (a function, or the binary on PATH).
+//
+// As with IsolationGuard the body runs inside a function, so variables it
+// declares with local, typeset or declare (zsh/bash) or 'set' without -g
+// (fish) do not outlive the loader.
+func LazyLoader(shell, module string, triggers []string) ModuleGuard {
+	loader := "__shellforge_lazy_" + shellIdentifier(module)
+	erase := strings.Join(append(append([]string{}, triggers...), loader), " ")
+
+	if strings.ToLower(shell) == "fish" {
+		guard := ModuleGuard{
+			Before: []string{"function " + loader, "functions -e " + erase},
+			After:  []string{"end"},
+		}
+		for _, cmd := range triggers {
+			guard.After = append(guard.After,
+				"function "+cmd,
+				"    "+loader,
+				"    "+cmd+" $argv",
+				"end")
+		}
+		return guard
+	}
+
+	guard := ModuleGuard{
+		Before: []string{loader + "() {", "unset -f " + erase},
+		After:  []string{"}"},
+	}
+	for _, cmd := range triggers {
+		guard.After = append(guard.After, fmt.Sprintf(`%s() { %s; %s "$@"; }`, cmd, loader, cmd))
+	}
+	return guard
+}
+
+// validateLazy checks that every trigger is a plain command name.
+func (m *Module) validateLazy() error {
+	for _, cmd := range m.Lazy {
+		if !lazyTriggerPattern.MatchString(cmd) {
+			return NewValidationError("module '%s' has invalid lazy trigger '%s': must be a command name", m.Name, cmd)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazyLoader(t *testing.T) {
+	tests := []struct {
+		shell  string
+		before []string
+		after  []string
+	}{
+		{
+			shell:  "zsh",
+			before: []string{"__shellforge_lazy_nvm_init() {", "unset -f nvm node __shellforge_lazy_nvm_init"},
+			after: []string{
+				"}",
+				`nvm() { __shellforge_lazy_nvm_init; nvm "$@"; }`,
+				`node() { __shellforge_lazy_nvm_init; node "$@"; }`,
+			},
+		},
+		{
+			shell:  "bash",
+			before: []string{"__shellforge_lazy_nvm_init() {", "unset -f nvm node __shellforge_lazy_nvm_init"},
+			after: []string{
+				"}",
+				`nvm() { __shellforge_lazy_nvm_init; nvm "$@"; }`,
+				`node() { __shellforge_lazy_nvm_init; node "$@"; }`,
+			},
+		},
+		{
+			shell:  "fish",
+			before: []string{"function __shellforge_lazy_nvm_init", "functions -e nvm node __shellforge_lazy_nvm_init"},
+			after: []string{
+				"end",
+				"function nvm", "    __shellforge_lazy_nvm_init", "    nvm $argv", "end",
+				"function node", "    __shellforge_lazy_nvm_init", "    node $argv", "end",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			guard := LazyLoader(tt.shell, "nvm-init", []string{"nvm", "node"})
+			assert.Equal(t, tt.before, guard.Before)
+			assert.Equal(t, tt.after, guard.After)
+		})
+	}
+}
+
+func TestModule_Validate_Lazy(t *testing.T) {
+	mod := Module{Name: "nvm", File: "nvm.sh", Lazy: []string{"nvm", "node", "docker-compose"}}
+	assert.NoError(t, mod.Validate())
+	assert.True(t, mod.IsLazy())
+
+	for _, trigger := range []string{"", "rm -rf", "$(x)", "a;b"} {
+		mod.Lazy = []string{trigger}
+		err := mod.Validate()
+		assert.Error(t, err, "trigger %q", trigger)
+		assert.IsType(t, &ValidationError{}, err)
+	}
+
+	assert.False(t, (&Module{}).IsLazy())
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "fmt"
+
+// SourceLocation points at a position in a manifest file.
+// It is filled in by the manifest parser and is never read from YAML.
+type SourceLocation struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// IsZero returns true if the location is unknown.
+func (l SourceLocation) IsZero() bool {
+	return l.File == "" && l.Line == 0
+}
+
+// String formats the location as "file:line", or "line N" without a file.
+func (l SourceLocation) String() string {
+	switch {
+	case l.IsZero():
+		return ""
+	case l.File == "":
+		return fmt.Sprintf("line %d", l.Line)
+	default:
+		return fmt.Sprintf("%s:%d", l.File, l.Line)
+	}
+}
@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"path"
+	"strings"
+)
+
 // ShellConfig configures shell type for manifest v2.
 type ShellConfig struct {
 	Type string `yaml:"type"` // zsh, bash, fish
@@ -7,8 +12,18 @@ type ShellConfig struct {
 
 // OutputConfig configures output settings for manifest v2.
 type OutputConfig struct {
-	Directory string `yaml:"directory,omitempty"` // Output directory (defaults to ~)
-	Backup    bool   `yaml:"backup,omitempty"`    // Create backup of existing files
+	Directory string `yaml:"directory,omitempty"`  // Output directory (defaults to ~)
+	Backup    *bool  `yaml:"backup,omitempty"`     // Create backup of existing files
+	DirLoader *bool  `yaml:"dir_loader,omitempty"` // Source zshrc.d/bashrc.d from the main rc file
+
+	// PathTarget is the target that gets the PATH block merged from module
+	// 'path' declarations (defaults to the shell's main rc file).
+	PathTarget string `yaml:"path_target,omitempty"`
+}
+
+// LoadsDirTargets reports whether the main rc file sources zshrc.d/bashrc.d.
+func (o OutputConfig) LoadsDirTargets() bool {
+	return o.DirLoader != nil && *o.DirLoader
 }
 
 // Manifest represents a collection of shell modules.
@@ -17,6 +32,149 @@ type Manifest struct {
 	Shell   ShellConfig  `yaml:"shell,omitempty"`   // Shell configuration (v2)
 	Output  OutputConfig `yaml:"output,omitempty"`  // Output configuration (v2)
 	Modules []Module     `yaml:"modules"`
+
+	// Defaults apply to every module that does not set the field itself.
+	Defaults ModuleDefaults `yaml:"defaults,omitempty"`
+
+	// Targets declares custom targets modules can use besides the built-in
+	// ones, keyed by target name.
+	Targets map[string]CustomTarget `yaml:"targets,omitempty"`
+
+	// Profiles are named module selections, chosen with --profile or
+	// automatically by hostname.
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+
+	// Vars are values interpolated as {{ .Vars.name }} into module file
+	// paths, requires_path and 'interpolate: true' module bodies.
+	Vars map[string]string `yaml:"vars,omitempty"`
+
+	// OSVars override Vars for a target OS, keyed by OS name.
+	OSVars map[string]map[string]string `yaml:"os_vars,omitempty"`
+
+	// Include lists other manifests merged before this one's modules.
+	// Paths are relative to this manifest's directory and may be globs.
+	Include []string `yaml:"include,omitempty"`
+
+	// Path is the file the manifest was parsed from (set by the parser).
+	Path string `yaml:"-"`
+
+	// Sources lists every manifest file that contributed, in load order
+	// (included files first, Path last). Set by the parser.
+	Sources []string `yaml:"-"`
+}
+
+// ModuleDefaults are manifest-wide defaults for module fields (the
+// manifest's 'defaults:' section). A module setting the field wins.
+type ModuleDefaults struct {
+	// Isolate wraps every module in an error guard (see Module.Isolate).
+	Isolate *bool `yaml:"isolate,omitempty"`
+}
+
+// Apply fills in the fields mod leaves unset.
+func (d ModuleDefaults) Apply(mod *Module) {
+	if mod.Isolate == nil && d.Isolate != nil {
+		isolate := *d.Isolate
+		mod.Isolate = &isolate
+	}
+}
+
+// ApplyLayer merges layer on top of m, as done for each included manifest
+// and finally for the including manifest itself:
+//
+//   - a module whose name is new is appended;
+//   - a module with the same name as an earlier one replaces it in place;
+//   - 'extends: true' patches only the fields it sets (see Module.Patch);
+//   - 'disabled: true' removes the earlier module.
+//
+// Names repeated within a single layer are not overrides; they are kept so
+// Validate reports them as duplicates. Non-empty settings (including an
+// explicit false) and same-named profiles, variables and targets in layer win.
+func (m *Manifest) ApplyLayer(layer *Manifest) {
+	if layer.Version != "" {
+		m.Version = layer.Version
+	}
+	if layer.Shell.Type != "" {
+		m.Shell.Type = layer.Shell.Type
+	}
+	if layer.Output.Directory != "" {
+		m.Output.Directory = layer.Output.Directory
+	}
+	if layer.Output.Backup != nil {
+		m.Output.Backup = layer.Output.Backup
+	}
+	if layer.Output.DirLoader != nil {
+		m.Output.DirLoader = layer.Output.DirLoader
+	}
+	if layer.Output.PathTarget != "" {
+		m.Output.PathTarget = layer.Output.PathTarget
+	}
+	if layer.Defaults.Isolate != nil {
+		m.Defaults.Isolate = layer.Defaults.Isolate
+	}
+	for name, value := range layer.Vars {
+		if m.Vars == nil {
+			m.Vars = make(map[string]string)
+		}
+		m.Vars[name] = value
+	}
+	for osName, osVars := range layer.OSVars {
+		if m.OSVars == nil {
+			m.OSVars = make(map[string]map[string]string)
+		}
+		if m.OSVars[osName] == nil {
+			m.OSVars[osName] = make(map[string]string)
+		}
+		for name, value := range osVars {
+			m.OSVars[osName][name] = value
+		}
+	}
+	for name, profile := range layer.Profiles {
+		if m.Profiles == nil {
+			m.Profiles = make(map[string]Profile)
+		}
+		m.Profiles[name] = profile
+	}
+	for name, target := range layer.Targets {
+		if m.Targets == nil {
+			m.Targets = make(map[string]CustomTarget)
+		}
+		m.Targets[name] = target
+	}
+
+	inherited := make(map[string]bool, len(m.Modules))
+	for _, mod := range m.Modules {
+		inherited[mod.Name] = true
+	}
+
+	for _, mod := range layer.Modules {
+		if !inherited[mod.Name] {
+			if !mod.Disabled {
+				m.Modules = append(m.Modules, mod)
+			}
+			continue
+		}
+
+		i := m.moduleIndex(mod.Name)
+		switch {
+		case i < 0:
+			// already disabled by an earlier entry of this layer
+		case mod.Disabled:
+			m.Modules = append(m.Modules[:i], m.Modules[i+1:]...)
+		case mod.Extends:
+			m.Modules[i].Patch(&mod)
+		default:
+			m.Modules[i] = mod
+		}
+	}
+}
+
+func (m *Manifest) moduleIndex(name string) int {
+	for i := range m.Modules {
+		if m.Modules[i].Name == name {
+			return i
+		}
+	}
+	return -1
 }
 
 // IsLegacy returns true if this is a v1 (legacy) manifest without version or target fields.
@@ -33,12 +191,12 @@ func (m *Manifest) IsLegacy() bool {
 	return true
 }
 
-// GetShellType returns the shell type, defaulting to "zsh".
+// GetShellType returns the shell type in lower case, defaulting to "zsh".
 func (m *Manifest) GetShellType() string {
 	if m.Shell.Type == "" {
 		return "zsh"
 	}
-	return m.Shell.Type
+	return strings.ToLower(m.Shell.Type)
 }
 
 // GetOutputDirectory returns the output directory, defaulting to "~".
@@ -60,6 +218,74 @@ func (m *Manifest) FindModule(name string) (*Module, bool) {
 	return nil, false
 }
 
+// HasTag reports whether any module carries tag.
+func (m *Manifest) HasTag(tag string) bool {
+	for i := range m.Modules {
+		if m.Modules[i].HasAnyTag([]string{tag}) {
+			return true
+		}
+	}
+	return false
+}
+
+// OSNames returns the distinct OS values mentioned by any module, in manifest
+// order. Values differing only by case are reported once.
+func (m *Manifest) OSNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, mod := range m.Modules {
+		for _, os := range mod.OS {
+			key := strings.ToLower(os)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			names = append(names, os)
+		}
+	}
+	return names
+}
+
+// ExcludedDependencies reports every dependency edge broken by the OS filter
+// for targetOS: an applicable module requiring a module that does not apply.
+// Hard requirements are returned as errors; soft (requires_optional) ones are
+// only reported through the optional list, since the build simply skips them.
+func (m *Manifest) ExcludedDependencies(targetOS string) (hard []*ExcludedDependencyError, optional []*ExcludedDependencyError) {
+	for _, mod := range m.Modules {
+		if !mod.AppliesTo(targetOS) {
+			continue
+		}
+		for _, dep := range mod.Requires {
+			if depMod, found := m.FindModule(dep); found && !depMod.AppliesTo(targetOS) {
+				hard = append(hard, NewExcludedDependencyError(mod.Name, dep, targetOS, depMod.OS))
+			}
+		}
+		for _, dep := range mod.RequiresOptional {
+			if depMod, found := m.FindModule(dep); found && !depMod.AppliesTo(targetOS) {
+				optional = append(optional, NewExcludedDependencyError(mod.Name, dep, targetOS, depMod.OS))
+			}
+		}
+	}
+	return hard, optional
+}
+
+// UnknownOptionalDependencies reports every requires_optional naming a module
+// the manifest does not define. These are not errors: a layer or profile may
+// leave the module out, and the resolver simply ignores the edge.
+func (m *Manifest) UnknownOptionalDependencies() []error {
+	var unknown []error
+	for _, mod := range m.Modules {
+		for _, dep := range mod.RequiresOptional {
+			if _, found := m.FindModule(dep); !found {
+				unknown = append(unknown, NewValidationError(
+					"module '%s' optionally requires non-existent module '%s', which is ignored", mod.Name, dep,
+				))
+			}
+		}
+	}
+	return unknown
+}
+
 // Validate checks the manifest for errors.
 // Returns a slice of all validation errors found.
 func (m *Manifest) Validate() []error {
@@ -90,5 +316,30 @@ func (m *Manifest) Validate() []error {
 		}
 	}
 
+	// Check that profiles reference existing modules and valid host patterns
+	for _, name := range m.ProfileNames() {
+		profile := m.Profiles[name]
+		for _, ref := range append(append([]string{}, profile.Include...), profile.Exclude...) {
+			if _, found := m.FindModule(ref); !found {
+				errors = append(errors, NewValidationError(
+					"profile '%s' references non-existent module '%s'", name, ref,
+				))
+			}
+		}
+		for _, pattern := range profile.Hosts {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errors = append(errors, NewValidationError(
+					"profile '%s' has invalid host pattern '%s'", name, pattern,
+				))
+			}
+		}
+	}
+
+	for _, name := range m.CustomTargetNames() {
+		if err := m.Targets[name].Validate(name); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
 	return errors
 }
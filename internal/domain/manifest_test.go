@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest_ApplyLayer(t *testing.T) {
+	base := &Manifest{
+		Shell: ShellConfig{Type: "zsh"},
+		Modules: []Module{
+			{Name: "a", File: "a.sh", Description: "A", OS: []string{"Mac"}},
+			{Name: "b", File: "b.sh"},
+			{Name: "c", File: "c.sh"},
+		},
+	}
+
+	base.ApplyLayer(&Manifest{
+		Shell: ShellConfig{Type: "bash"},
+		Modules: []Module{
+			{Name: "a", Extends: true, Priority: 5},
+			{Name: "b", File: "b2.sh"},
+			{Name: "c", Disabled: true},
+			{Name: "d", File: "d.sh"},
+			{Name: "ghost", Disabled: true},
+		},
+	})
+
+	assert.Equal(t, "bash", base.Shell.Type)
+	require.Len(t, base.Modules, 3)
+
+	assert.Equal(t, "a", base.Modules[0].Name)
+	assert.Equal(t, "a.sh", base.Modules[0].File)
+	assert.Equal(t, "A", base.Modules[0].Description)
+	assert.Equal(t, []string{"Mac"}, base.Modules[0].OS)
+	assert.Equal(t, 5, base.Modules[0].Priority)
+
+	assert.Equal(t, Module{Name: "b", File: "b2.sh"}, base.Modules[1])
+	assert.Equal(t, "d", base.Modules[2].Name)
+}
+
+func TestManifest_ApplyLayer_Vars(t *testing.T) {
+	base := &Manifest{
+		Vars:   map[string]string{"brew": "/usr/local", "editor": "vim"},
+		OSVars: map[string]map[string]string{"Mac": {"brew": "/opt/homebrew"}},
+	}
+
+	base.ApplyLayer(&Manifest{
+		Vars:   map[string]string{"editor": "nvim"},
+		OSVars: map[string]map[string]string{"Mac": {"gnu": "/opt/homebrew/opt"}, "Linux": {"brew": "/home/linuxbrew"}},
+	})
+
+	assert.Equal(t, map[string]string{"brew": "/usr/local", "editor": "nvim"}, base.Vars)
+	assert.Equal(t, map[string]map[string]string{
+		"Mac":   {"brew": "/opt/homebrew", "gnu": "/opt/homebrew/opt"},
+		"Linux": {"brew": "/home/linuxbrew"},
+	}, base.OSVars)
+}
+
+func TestManifest_ApplyLayer_OverridesToFalse(t *testing.T) {
+	on, off := true, false
+	m := &Manifest{
+		Output:   OutputConfig{Backup: &on, DirLoader: &on},
+		Defaults: ModuleDefaults{Isolate: &on},
+		Modules:  []Module{{Name: "proxy", File: "proxy.sh", Interpolate: &on}},
+	}
+
+	// A layer that leaves the settings unset keeps them
+	m.ApplyLayer(&Manifest{})
+	assert.True(t, m.Output.LoadsDirTargets())
+	assert.True(t, *m.Output.Backup)
+	assert.True(t, *m.Defaults.Isolate)
+
+	m.ApplyLayer(&Manifest{
+		Output:   OutputConfig{Backup: &off, DirLoader: &off},
+		Defaults: ModuleDefaults{Isolate: &off},
+		Modules:  []Module{{Name: "proxy", Extends: true, Interpolate: &off}},
+	})
+	assert.False(t, *m.Output.Backup)
+	assert.False(t, m.Output.LoadsDirTargets())
+	assert.False(t, *m.Defaults.Isolate)
+	assert.False(t, m.Modules[0].IsInterpolated())
+	assert.Equal(t, "proxy.sh", m.Modules[0].File)
+
+	mod := Module{Name: "a"}
+	m.Defaults.Apply(&mod)
+	assert.False(t, mod.IsIsolated())
+}
+
+func TestManifest_ApplyLayer_SameLayerDuplicatesStayDuplicates(t *testing.T) {
+	m := &Manifest{}
+	m.ApplyLayer(&Manifest{Modules: []Module{
+		{Name: "a", File: "a.sh"},
+		{Name: "a", File: "a2.sh"},
+	}})
+
+	require.Len(t, m.Modules, 2)
+	assert.NotEmpty(t, m.Validate())
+}
+
+func TestManifest_Validate_UnmatchedExtends(t *testing.T) {
+	m := &Manifest{}
+	m.ApplyLayer(&Manifest{Modules: []Module{{Name: "a", Extends: true, Priority: 10}}})
+
+	errs := m.Validate()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "uses 'extends'")
+}
+
+func TestManifest_UnknownOptionalDependencies(t *testing.T) {
+	m := &Manifest{Modules: []Module{
+		{Name: "tools", File: "tools.sh", RequiresOptional: []string{"brew", "ghost"}},
+		{Name: "brew", File: "brew.sh"},
+	}}
+
+	assert.Empty(t, m.Validate())
+	unknown := m.UnknownOptionalDependencies()
+	require.Len(t, unknown, 1)
+	assert.Contains(t, unknown[0].Error(), "module 'tools' optionally requires non-existent module 'ghost'")
+}
@@ -1,17 +1,34 @@
 package domain
 
-import "strings"
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
 
 // Module represents a shell module with dependencies and OS filtering.
 type Module struct {
 	Name        string   `yaml:"name"`
-	File        string   `yaml:"file"`
+	File        string   `yaml:"file,omitempty"`
 	Requires    []string `yaml:"requires,omitempty"`
 	OS          []string `yaml:"os,omitempty"`
 	Description string   `yaml:"description,omitempty"`
 
+	// When holds extra host conditions (arch, distro, hostname, env, shell)
+	// evaluated together with OS; see Module.Evaluate.
+	When *Condition `yaml:"when,omitempty"`
+
+	// Tags categorise the module for profile and --tag selection.
+	Tags []string `yaml:"tags,omitempty"`
+
+	// RequiresOptional lists soft dependencies: they order this module after
+	// the named modules when those are built, but are ignored when the OS
+	// filter excludes them (e.g. a Linux module that uses a Mac helper if present).
+	RequiresOptional []string `yaml:"requires_optional,omitempty"`
+
 	// Target specifies the destination RC file (e.g., zshrc, zprofile, bashrc).
-	// Defaults to "zshrc" if not specified.
+	// The builder defaults it to the shell's main rc file (zshrc, bashrc or
+	// fish config); GetTarget, which has no shell, defaults to "zshrc".
 	Target string `yaml:"target,omitempty"`
 
 	// Priority determines the order within a target file (0-100, lower = earlier).
@@ -28,6 +45,51 @@ type Module struct {
 	// manager name (e.g. "brew", "cask", "apt"). Consumed by `prepare` to
 	// check/install prerequisites; ignored by build/deploy.
 	Packages map[string][]string `yaml:"packages,omitempty"`
+
+	// Content is the module body written inline in the manifest, for short
+	// modules; used instead of File.
+	Content string `yaml:"content,omitempty"`
+
+	// Generate builds the module body from built-in generators instead of
+	// File (see ModuleGenerator).
+	Generate *ModuleGenerator `yaml:"generate,omitempty"`
+
+	// Path declares directories this module adds to PATH. The builder merges
+	// the rules of all modules into one PATH block (see MergePath); a module
+	// may declare only 'path', without a body.
+	Path []PathRule `yaml:"path,omitempty"`
+
+	// Interpolate renders the module file's body as a template against the
+	// manifest vars ({{ .Vars.name }}) at build time. Off by default so
+	// shell code containing "{{" is copied verbatim.
+	Interpolate *bool `yaml:"interpolate,omitempty"`
+
+	// Isolate wraps the module body in a shell-specific error guard so a
+	// failure is reported instead of breaking the rest of the shell (see
+	// IsolationGuard). Unset means the manifest's defaults.isolate.
+	Isolate *bool `yaml:"isolate,omitempty"`
+
+	// Lazy lists trigger commands (e.g. nvm, node, npm): instead of running
+	// at startup, the body is loaded the first time one of them is run
+	// (see LazyLoader).
+	Lazy []string `yaml:"lazy,omitempty"`
+
+	// Extends marks this entry as a patch of the same-named module from an
+	// included manifest: only the fields set here replace the inherited ones.
+	Extends bool `yaml:"extends,omitempty"`
+
+	// Disabled removes the same-named module inherited from an included manifest.
+	Disabled bool `yaml:"disabled,omitempty"`
+
+	// Location is where the module is declared in the manifest (set by the parser).
+	Location SourceLocation `yaml:"-"`
+
+	// BodyLocation is where Content (its first line) or Generate is
+	// declared in the manifest (set by the parser).
+	BodyLocation SourceLocation `yaml:"-"`
+
+	// PatchedAt lists the locations of 'extends' entries applied to this module.
+	PatchedAt []SourceLocation `yaml:"-"`
 }
 
 // GetTarget returns the target RC file, defaulting to "zshrc".
@@ -38,6 +100,37 @@ func (m *Module) GetTarget() string {
 	return m.Target
 }
 
+// IsInline reports whether the module body is declared in the manifest
+// (content or generate) rather than read from File.
+func (m *Module) IsInline() bool {
+	return m.Content != "" || m.Generate != nil
+}
+
+// HasBody reports whether the module has a body to write to its target:
+// a file, inline content or generators. Modules that only declare 'path'
+// have none.
+func (m *Module) HasBody() bool {
+	return m.File != "" || m.IsInline()
+}
+
+// IsInterpolated reports whether the module body is rendered as a template.
+func (m *Module) IsInterpolated() bool {
+	return m.Interpolate != nil && *m.Interpolate
+}
+
+// BodySource describes where the module body comes from: its file, or
+// "inline content" / "generated (env, path)" for inline modules.
+func (m *Module) BodySource() string {
+	switch {
+	case m.Generate != nil:
+		return fmt.Sprintf("generated (%s)", strings.Join(m.Generate.Names(), ", "))
+	case m.Content != "":
+		return "inline content"
+	default:
+		return m.File
+	}
+}
+
 // GetPriority returns the priority, defaulting to 50.
 func (m *Module) GetPriority() int {
 	if m.Priority == 0 {
@@ -46,6 +139,23 @@ func (m *Module) GetPriority() int {
 	return m.Priority
 }
 
+// AllRequires returns hard and optional dependencies, hard ones first.
+func (m *Module) AllRequires() []string {
+	deps := make([]string, 0, len(m.Requires)+len(m.RequiresOptional))
+	deps = append(deps, m.Requires...)
+	return append(deps, m.RequiresOptional...)
+}
+
+// HasAnyTag reports whether the module carries any of the given tags.
+func (m *Module) HasAnyTag(tags []string) bool {
+	for _, tag := range tags {
+		if slices.Contains(m.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
 // AppliesTo checks if this module applies to the target OS.
 // If OS field is empty, module applies to all operating systems.
 func (m *Module) AppliesTo(targetOS string) bool {
@@ -62,13 +172,96 @@ func (m *Module) AppliesTo(targetOS string) bool {
 	return false
 }
 
+// Patch overwrites the fields that are set in patch, keeping the rest.
+// List and map fields are replaced as a whole, not appended to.
+func (m *Module) Patch(patch *Module) {
+	// File, Content and Generate are one body: setting any replaces it
+	if patch.File != "" || patch.IsInline() {
+		m.File, m.Content, m.Generate = patch.File, patch.Content, patch.Generate
+		m.BodyLocation = patch.BodyLocation
+	}
+	if patch.Requires != nil {
+		m.Requires = patch.Requires
+	}
+	if patch.RequiresOptional != nil {
+		m.RequiresOptional = patch.RequiresOptional
+	}
+	if patch.OS != nil {
+		m.OS = patch.OS
+	}
+	if patch.Description != "" {
+		m.Description = patch.Description
+	}
+	if patch.When != nil {
+		m.When = patch.When
+	}
+	if patch.Tags != nil {
+		m.Tags = patch.Tags
+	}
+	if patch.Target != "" {
+		m.Target = patch.Target
+	}
+	if patch.Priority != 0 {
+		m.Priority = patch.Priority
+	}
+	if patch.RequiresBin != nil {
+		m.RequiresBin = patch.RequiresBin
+	}
+	if patch.RequiresPath != nil {
+		m.RequiresPath = patch.RequiresPath
+	}
+	if patch.Packages != nil {
+		m.Packages = patch.Packages
+	}
+	if patch.Interpolate != nil {
+		m.Interpolate = patch.Interpolate
+	}
+	if patch.Isolate != nil {
+		m.Isolate = patch.Isolate
+	}
+	if patch.Lazy != nil {
+		m.Lazy = patch.Lazy
+	}
+	if patch.Path != nil {
+		m.Path = patch.Path
+	}
+	m.PatchedAt = append(m.PatchedAt, patch.Location)
+}
+
 // Validate checks if the module has required fields.
 func (m *Module) Validate() error {
 	if m.Name == "" {
 		return NewValidationError("module missing 'name' field")
 	}
-	if m.File == "" {
-		return NewValidationError("module '%s' missing 'file' field", m.Name)
+	if m.Extends {
+		return NewValidationError("module '%s' uses 'extends' but no included manifest defines it", m.Name)
+	}
+	sources := 0
+	for _, set := range []bool{m.File != "", m.Content != "", m.Generate != nil} {
+		if set {
+			sources++
+		}
+	}
+	switch {
+	case sources == 0 && len(m.Path) == 0:
+		return NewValidationError("module '%s' missing 'file' field (or inline 'content' / 'generate', or 'path')", m.Name)
+	case sources > 1:
+		return NewValidationError("module '%s' sets more than one of 'file', 'content' and 'generate'", m.Name)
+	}
+	if m.Generate != nil {
+		if err := m.Generate.Validate(m.Name); err != nil {
+			return err
+		}
+	}
+	for _, rule := range m.Path {
+		if err := rule.Validate(m.Name); err != nil {
+			return err
+		}
+	}
+	if m.When != nil {
+		if err := m.When.Validate(); err != nil {
+			return NewValidationError("module '%s' has invalid 'when': %v", m.Name, err)
+		}
 	}
-	return nil
+	return m.validateLazy()
 }
@@ -86,6 +86,42 @@ func TestModule_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "missing 'file' field",
 		},
+		{
+			name:   "inline content",
+			module: Module{Name: "test", Content: "alias ll='ls -la'"},
+		},
+		{
+			name:   "generated",
+			module: Module{Name: "test", Generate: &ModuleGenerator{Path: []string{"~/bin"}}},
+		},
+		{
+			name:    "file and content",
+			module:  Module{Name: "test", File: "test.sh", Content: "alias ll='ls -la'"},
+			wantErr: true,
+			errMsg:  "more than one of 'file', 'content' and 'generate'",
+		},
+		{
+			name:    "invalid generator",
+			module:  Module{Name: "test", Generate: &ModuleGenerator{}},
+			wantErr: true,
+			errMsg:  "empty 'generate'",
+		},
+		{
+			name:   "path only",
+			module: Module{Name: "test", Path: []PathRule{{Prepend: []string{"~/bin"}}}},
+		},
+		{
+			name:    "empty path rule",
+			module:  Module{Name: "test", Path: []PathRule{{OS: []string{"Mac"}}}},
+			wantErr: true,
+			errMsg:  "without 'prepend' or 'append'",
+		},
+		{
+			name:    "path entry with colon",
+			module:  Module{Name: "test", File: "test.sh", Path: []PathRule{{Append: []string{"/a:/b"}}}},
+			wantErr: true,
+			errMsg:  "invalid path entry '/a:/b'",
+		},
 	}
 
 	for _, tt := range tests {
@@ -189,3 +225,19 @@ func TestModule_GetPriority(t *testing.T) {
 		})
 	}
 }
+
+func TestModule_Patch_ReplacesBody(t *testing.T) {
+	m := Module{Name: "editor", File: "editor.sh"}
+	m.Patch(&Module{Content: "export EDITOR=vim", BodyLocation: SourceLocation{File: "local.yaml", Line: 4}})
+	assert.Equal(t, "", m.File)
+	assert.Equal(t, "export EDITOR=vim", m.Content)
+	assert.Equal(t, SourceLocation{File: "local.yaml", Line: 4}, m.BodyLocation)
+
+	m.Patch(&Module{Priority: 10})
+	assert.Equal(t, "export EDITOR=vim", m.Content, "a patch without a body keeps it")
+
+	m.Patch(&Module{File: "editor.sh"})
+	assert.Equal(t, "editor.sh", m.File)
+	assert.Equal(t, "", m.Content)
+	assert.False(t, m.IsInline())
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "strings"
+
+// KnownOSNames are the OS names produced by OS detection, in the spelling
+// manifests should use.
+var KnownOSNames = []string{"Mac", "Linux", "FreeBSD", "OpenBSD", "NetBSD", "Windows"}
+
+// CanonicalOSName returns the known spelling of name, matched
+// case-insensitively ("mac" → "Mac"). Unknown names are returned unchanged.
+func CanonicalOSName(name string) string {
+	for _, known := range KnownOSNames {
+		if strings.EqualFold(known, name) {
+			return known
+		}
+	}
+	return name
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalOSName(t *testing.T) {
+	tests := map[string]string{
+		"mac":     "Mac",
+		"LINUX":   "Linux",
+		"freebsd": "FreeBSD",
+		"Mac":     "Mac",
+		"darwin":  "darwin", // aliases are not rewritten, only casing
+		"":        "",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, CanonicalOSName(in), in)
+	}
+}
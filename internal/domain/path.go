@@ -0,0 +1,221 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PathRule is one entry of a module's 'path:' list: directories to put in
+// front of or behind PATH, optionally only on some operating systems or only
+// when they exist.
+type PathRule struct {
+	// Prepend lists directories to put in front of PATH, the first entry first.
+	Prepend []string `yaml:"prepend,omitempty"`
+
+	// Append lists directories to add at the end of PATH, in order.
+	Append []string `yaml:"append,omitempty"`
+
+	// OS limits the rule to these operating systems; empty means all.
+	OS []string `yaml:"os,omitempty"`
+
+	// IfExists adds the directories only when they exist as the shell starts.
+	IfExists bool `yaml:"if_exists,omitempty"`
+}
+
+// AppliesTo reports whether the rule applies to the target OS.
+func (r *PathRule) AppliesTo(targetOS string) bool {
+	if len(r.OS) == 0 {
+		return true
+	}
+	for _, os := range r.OS {
+		if strings.EqualFold(os, targetOS) {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the rule, e.g. "prepend ~/bin, ~/.local/bin (os: Mac; if exists)".
+func (r PathRule) String() string {
+	var parts, conds []string
+	if len(r.Prepend) > 0 {
+		parts = append(parts, "prepend "+strings.Join(r.Prepend, ", "))
+	}
+	if len(r.Append) > 0 {
+		parts = append(parts, "append "+strings.Join(r.Append, ", "))
+	}
+	if len(r.OS) > 0 {
+		conds = append(conds, "os: "+strings.Join(r.OS, ", "))
+	}
+	if r.IfExists {
+		conds = append(conds, "if exists")
+	}
+	s := strings.Join(parts, "; ")
+	if len(conds) > 0 {
+		s += " (" + strings.Join(conds, "; ") + ")"
+	}
+	return s
+}
+
+// Validate checks the rule declared by module.
+func (r *PathRule) Validate(module string) error {
+	if len(r.Prepend) == 0 && len(r.Append) == 0 {
+		return NewValidationError("module '%s' has a 'path' entry without 'prepend' or 'append'", module)
+	}
+	for _, dir := range append(append([]string{}, r.Prepend...), r.Append...) {
+		if dir == "" || strings.ContainsAny(dir, ":\n\r") {
+			return NewValidationError("module '%s' has invalid path entry '%s': must be non-empty and contain no ':' or newlines", module, dir)
+		}
+	}
+	return nil
+}
+
+// PathEntry is one directory of a merged PATH block.
+type PathEntry struct {
+	Dir      string // as declared; a leading ~ is not expanded
+	Append   bool   // added at the end of PATH instead of the front
+	IfExists bool   // added only when the directory exists
+	Module   string // module that declared it last
+}
+
+// MergePath merges the path rules of modules that apply to targetOS into
+// the entries of one PATH block: the front entries in the order they end up
+// in PATH, then the appended ones. Modules are taken in load order and the
+// result is the PATH their rules would produce run one after another: each
+// rule moves its directories to the front or the end, so a later module's
+// prepends go before an earlier one's, and a directory declared more than
+// once keeps only the position of its last declaration.
+func MergePath(modules []Module, targetOS string) []PathEntry {
+	var front, back []PathEntry
+	for _, mod := range modules {
+		for _, rule := range mod.Path {
+			if !rule.AppliesTo(targetOS) {
+				continue
+			}
+			if len(rule.Prepend) > 0 {
+				added := pathEntries(rule.Prepend, false, rule.IfExists, mod.Name)
+				front = append(added, withoutPathEntries(front, added)...)
+				back = withoutPathEntries(back, added)
+			}
+			if len(rule.Append) > 0 {
+				added := pathEntries(rule.Append, true, rule.IfExists, mod.Name)
+				front = withoutPathEntries(front, added)
+				back = append(withoutPathEntries(back, added), added...)
+			}
+		}
+	}
+	return append(front, back...)
+}
+
+// PathBlock returns the lines that apply entries to PATH in shell. Bash,
+// zsh and sh get a helper that moves each directory to its place, so a
+// directory already in the inherited PATH is not duplicated when the file
+// is sourced again; fish gets fish_add_path --move, which also skips
+// directories that do not exist.
+func PathBlock(shell string, entries []PathEntry) ([]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	var front, back []PathEntry
+	for _, e := range entries {
+		if e.Append {
+			back = append(back, e)
+		} else {
+			front = append(front, e)
+		}
+	}
+
+	lines := []string{"# --- PATH (merged from module 'path' declarations) ---"}
+	switch strings.ToLower(shell) {
+	case "zsh", "bash", "sh":
+		lines = append(lines,
+			"__shellforge_path() {",
+			`  [ "$3" = if_exists ] && [ ! -d "$2" ] && return 0`,
+			`  local __dir __rest="$PATH:" __kept=`,
+			`  while [ -n "$__rest" ]; do`,
+			`    __dir="${__rest%%:*}"`,
+			`    __rest="${__rest#*:}"`,
+			`    [ "$__dir" = "$2" ] || __kept="${__kept:+$__kept:}$__dir"`,
+			"  done",
+			`  case "$1" in`,
+			`    prepend) PATH="$2${__kept:+:$__kept}" ;;`,
+			`    *) PATH="${__kept:+$__kept:}$2" ;;`,
+			"  esac",
+			"}",
+		)
+		// Prepending one at a time puts the last call first
+		for i := len(front) - 1; i >= 0; i-- {
+			lines = append(lines, shPathCall("prepend", front[i]))
+		}
+		for _, e := range back {
+			lines = append(lines, shPathCall("append", e))
+		}
+		lines = append(lines, "unset -f __shellforge_path", "export PATH")
+	case "fish":
+		if len(front) > 0 {
+			lines = append(lines, "fish_add_path --move --path "+fishPathArgs(front))
+		}
+		if len(back) > 0 {
+			lines = append(lines, "fish_add_path --move --path --append "+fishPathArgs(back))
+		}
+	default:
+		return nil, NewValidationError("path: unsupported shell type '%s'", shell)
+	}
+	return lines, nil
+}
+
+// pathEntries returns the entries of one rule list, without repeats.
+func pathEntries(dirs []string, appended, ifExists bool, module string) []PathEntry {
+	entries := make([]PathEntry, 0, len(dirs))
+	for _, dir := range dirs {
+		entry := PathEntry{Dir: dir, Append: appended, IfExists: ifExists, Module: module}
+		if len(withoutPathEntries(entries, []PathEntry{entry})) == len(entries) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// withoutPathEntries returns entries without the directories of removed.
+func withoutPathEntries(entries, removed []PathEntry) []PathEntry {
+	kept := make([]PathEntry, 0, len(entries))
+	for _, e := range entries {
+		found := false
+		for _, r := range removed {
+			if pathKey(e.Dir) == pathKey(r.Dir) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// pathKey normalises dir for comparison: "~/bin/" and "$HOME/bin" are the
+// same directory.
+func pathKey(dir string) string {
+	return path.Clean(expandHome(dir))
+}
+
+// shPathCall returns the helper call that adds one entry.
+func shPathCall(mode string, e PathEntry) string {
+	call := fmt.Sprintf("__shellforge_path %s %s", mode, shDoubleQuote(expandHome(e.Dir)))
+	if e.IfExists {
+		call += " if_exists"
+	}
+	return call
+}
+
+// fishPathArgs returns the quoted directories of entries.
+func fishPathArgs(entries []PathEntry) string {
+	args := make([]string, len(entries))
+	for i, e := range entries {
+		args[i] = fishDoubleQuote(expandHome(e.Dir))
+	}
+	return strings.Join(args, " ")
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pathDirs(entries []PathEntry) []string {
+	dirs := make([]string, len(entries))
+	for i, e := range entries {
+		dirs[i] = e.Dir
+		if e.Append {
+			dirs[i] = "+" + e.Dir
+		}
+	}
+	return dirs
+}
+
+func TestMergePath(t *testing.T) {
+	modules := []Module{
+		{Name: "base", Path: []PathRule{
+			{Prepend: []string{"/usr/local/bin", "~/bin"}},
+			{Append: []string{"/usr/games"}},
+		}},
+		{Name: "brew", Path: []PathRule{
+			{Prepend: []string{"/opt/homebrew/bin"}, OS: []string{"Mac"}},
+			{Prepend: []string{"/home/linuxbrew/.linuxbrew/bin"}, OS: []string{"Linux"}},
+		}},
+		{Name: "local", Path: []PathRule{
+			// Moves ~/bin (same directory as declared by base) to the front
+			{Prepend: []string{"$HOME/bin/", "~/.local/bin", "$HOME/bin"}, IfExists: true},
+			// Moves /usr/local/bin from the front to the end
+			{Append: []string{"/usr/local/bin"}},
+		}},
+	}
+
+	t.Run("Linux", func(t *testing.T) {
+		entries := MergePath(modules, "Linux")
+		assert.Equal(t, []string{
+			"$HOME/bin/", "~/.local/bin", "/home/linuxbrew/.linuxbrew/bin",
+			"+/usr/games", "+/usr/local/bin",
+		}, pathDirs(entries))
+		assert.Equal(t, PathEntry{Dir: "$HOME/bin/", IfExists: true, Module: "local"}, entries[0])
+		assert.Equal(t, "brew", entries[2].Module)
+	})
+
+	t.Run("Mac", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"$HOME/bin/", "~/.local/bin", "/opt/homebrew/bin",
+			"+/usr/games", "+/usr/local/bin",
+		}, pathDirs(MergePath(modules, "mac")))
+	})
+
+	assert.Empty(t, MergePath([]Module{{Name: "plain", File: "plain.sh"}}, "Linux"))
+}
+
+func TestPathBlock(t *testing.T) {
+	entries := []PathEntry{
+		{Dir: "~/bin", IfExists: true},
+		{Dir: "/opt/x y/bin"},
+		{Dir: "/usr/games", Append: true},
+	}
+
+	sh, err := PathBlock("zsh", entries)
+	require.NoError(t, err)
+	calls := sh[len(sh)-5:]
+	assert.Equal(t, []string{
+		`__shellforge_path prepend "/opt/x y/bin"`,
+		`__shellforge_path prepend "$HOME/bin" if_exists`,
+		`__shellforge_path append "/usr/games"`,
+		"unset -f __shellforge_path",
+		"export PATH",
+	}, calls)
+
+	bash, err := PathBlock("bash", entries)
+	require.NoError(t, err)
+	assert.Equal(t, sh, bash)
+
+	fish, err := PathBlock("fish", entries)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"# --- PATH (merged from module 'path' declarations) ---",
+		`fish_add_path --move --path "$HOME/bin" "/opt/x y/bin"`,
+		`fish_add_path --move --path --append "/usr/games"`,
+	}, fish)
+
+	empty, err := PathBlock("zsh", nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	_, err = PathBlock("tcsh", entries)
+	assert.ErrorContains(t, err, "unsupported shell type 'tcsh'")
+}
+
+func TestPathRule_String(t *testing.T) {
+	rule := PathRule{Prepend: []string{"~/bin", "~/.local/bin"}, Append: []string{"/usr/games"}, OS: []string{"Mac"}, IfExists: true}
+	assert.Equal(t, "prepend ~/bin, ~/.local/bin; append /usr/games (os: Mac; if exists)", rule.String())
+	assert.Equal(t, "append /usr/games", PathRule{Append: []string{"/usr/games"}}.String())
+}
+
+func TestModule_Patch_Path(t *testing.T) {
+	m := Module{Name: "brew", File: "brew.sh", Path: []PathRule{{Prepend: []string{"/usr/local/bin"}}}}
+	m.Patch(&Module{Priority: 10})
+	assert.Len(t, m.Path, 1, "a patch without 'path' keeps it")
+
+	m.Patch(&Module{Path: []PathRule{{Prepend: []string{"/opt/homebrew/bin"}}}})
+	assert.Equal(t, []PathRule{{Prepend: []string{"/opt/homebrew/bin"}}}, m.Path)
+	assert.Equal(t, "brew.sh", m.File)
+}
@@ -0,0 +1,142 @@
+package domain
+
+import "fmt"
+
+// PriorityConflict is a module whose priority would place it before a module
+// of the same target that it requires. Dependency order wins, so the
+// priority has no effect.
+type PriorityConflict struct {
+	Module      string
+	Priority    int
+	Dependency  string
+	DepPriority int
+	Target      string
+}
+
+// Error implements the error interface.
+func (c PriorityConflict) Error() string {
+	return fmt.Sprintf(
+		"priority %d is lower than priority %d of '%s', which it requires in target '%s'; '%s' still loads first",
+		c.Priority, c.DepPriority, c.Dependency, c.Target, c.Dependency,
+	)
+}
+
+// OrderTarget returns the modules of one target in load order: every module
+// comes after the modules of the target it requires, directly or through
+// modules of other targets in all, and among the modules whose dependencies
+// have loaded the lowest priority goes first. Equal priorities keep the
+// order of group.
+func OrderTarget(group, all []Module) []Module {
+	deps := targetDependencies(group, all)
+
+	pending := make(map[string]int, len(group))
+	for _, mod := range group {
+		pending[mod.Name] = len(deps[mod.Name])
+	}
+
+	ordered := make([]Module, 0, len(group))
+	done := make([]bool, len(group))
+	for len(ordered) < len(group) {
+		next := -1
+		for i, mod := range group {
+			if done[i] || pending[mod.Name] > 0 {
+				continue
+			}
+			if next < 0 || mod.GetPriority() < group[next].GetPriority() {
+				next = i
+			}
+		}
+		if next < 0 {
+			// Unreachable once the global sort has rejected cycles; keep the
+			// remaining modules rather than drop them.
+			for i, mod := range group {
+				if !done[i] {
+					ordered = append(ordered, mod)
+				}
+			}
+			break
+		}
+
+		done[next] = true
+		ordered = append(ordered, group[next])
+		for _, mod := range group {
+			for _, dep := range deps[mod.Name] {
+				if dep == group[next].Name {
+					pending[mod.Name]--
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+// PriorityConflicts returns every pair of modules of the same target where a
+// module's priority is lower than the priority of a module it requires,
+// directly or through modules of other targets, in manifest order.
+func (m *Manifest) PriorityConflicts() []PriorityConflict {
+	groups := make(map[string][]Module)
+	for _, mod := range m.Modules {
+		groups[mod.GetTarget()] = append(groups[mod.GetTarget()], mod)
+	}
+
+	byName := make(map[string]Module, len(m.Modules))
+	for _, mod := range m.Modules {
+		byName[mod.Name] = mod
+	}
+	deps := make(map[string][]string, len(m.Modules))
+	for _, group := range groups {
+		for name, names := range targetDependencies(group, m.Modules) {
+			deps[name] = names
+		}
+	}
+
+	var conflicts []PriorityConflict
+	for _, mod := range m.Modules {
+		for _, name := range deps[mod.Name] {
+			dep := byName[name]
+			if mod.GetPriority() < dep.GetPriority() {
+				conflicts = append(conflicts, PriorityConflict{
+					Module:      mod.Name,
+					Priority:    mod.GetPriority(),
+					Dependency:  dep.Name,
+					DepPriority: dep.GetPriority(),
+					Target:      mod.GetTarget(),
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+// targetDependencies returns, for each module of group, the other modules of
+// group it requires directly or transitively through any module in all, in
+// group order.
+func targetDependencies(group, all []Module) map[string][]string {
+	byName := make(map[string]Module, len(all))
+	for _, mod := range all {
+		byName[mod.Name] = mod
+	}
+	deps := make(map[string][]string, len(group))
+	for _, mod := range group {
+		seen := map[string]bool{mod.Name: true}
+		var visit func(Module)
+		visit = func(m Module) {
+			for _, name := range m.AllRequires() {
+				dep, ok := byName[name]
+				if !ok || seen[name] {
+					continue
+				}
+				seen[name] = true
+				visit(dep)
+			}
+		}
+		visit(mod)
+
+		for _, other := range group {
+			if other.Name != mod.Name && seen[other.Name] {
+				deps[mod.Name] = append(deps[mod.Name], other.Name)
+			}
+		}
+	}
+	return deps
+}
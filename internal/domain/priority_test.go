@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func moduleNames(modules []Module) []string {
+	names := make([]string, len(modules))
+	for i, mod := range modules {
+		names[i] = mod.Name
+	}
+	return names
+}
+
+func TestOrderTarget(t *testing.T) {
+	tests := []struct {
+		name  string
+		group []Module
+		all   []Module
+		want  []string
+	}{
+		{
+			name:  "priority orders independent modules",
+			group: []Module{{Name: "a", Priority: 30}, {Name: "b", Priority: 10}, {Name: "c"}},
+			want:  []string{"b", "a", "c"},
+		},
+		{
+			name:  "equal priorities keep group order",
+			group: []Module{{Name: "a", Priority: 20}, {Name: "b", Priority: 20}},
+			want:  []string{"a", "b"},
+		},
+		{
+			name: "dependency wins over priority",
+			group: []Module{
+				{Name: "path", Priority: 60},
+				{Name: "prompt", Priority: 10, Requires: []string{"path"}},
+				{Name: "aliases", Priority: 20},
+			},
+			want: []string{"aliases", "path", "prompt"},
+		},
+		{
+			name: "optional dependencies order too",
+			group: []Module{
+				{Name: "nvm", Priority: 80},
+				{Name: "completion", Priority: 10, RequiresOptional: []string{"nvm"}},
+			},
+			want: []string{"nvm", "completion"},
+		},
+		{
+			name: "dependency through another target",
+			group: []Module{
+				{Name: "path", Priority: 60},
+				{Name: "prompt", Priority: 10, Requires: []string{"env"}},
+			},
+			all: []Module{
+				{Name: "path", Priority: 60},
+				{Name: "env", Target: "zshenv", Requires: []string{"path"}},
+				{Name: "prompt", Priority: 10, Requires: []string{"env"}},
+			},
+			want: []string{"path", "prompt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all := tt.all
+			if all == nil {
+				all = tt.group
+			}
+			assert.Equal(t, tt.want, moduleNames(OrderTarget(tt.group, all)))
+		})
+	}
+}
+
+func TestManifest_PriorityConflicts(t *testing.T) {
+	m := &Manifest{Modules: []Module{
+		{Name: "path", Priority: 60},
+		{Name: "brew", Priority: 70, Requires: []string{"path"}},
+		{Name: "prompt", Priority: 10, Requires: []string{"brew"}},
+		{Name: "env", Target: "zshenv", Priority: 5, Requires: []string{"path"}},
+	}}
+
+	// prompt contradicts both brew and (through brew) path; env is in
+	// another target
+	assert.Equal(t, []PriorityConflict{
+		{Module: "prompt", Priority: 10, Dependency: "path", DepPriority: 60, Target: "zshrc"},
+		{Module: "prompt", Priority: 10, Dependency: "brew", DepPriority: 70, Target: "zshrc"},
+	}, m.PriorityConflicts())
+}
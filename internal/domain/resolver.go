@@ -19,34 +19,91 @@ func (r *Resolver) BuildGraph(manifest *Manifest) (*Graph, error) {
 		graph.AddNode(&manifest.Modules[i])
 	}
 
-	// Add edges for dependencies
+	// Add edges for hard and optional dependencies. An optional dependency
+	// on a module the manifest does not define has no edge.
 	for _, module := range manifest.Modules {
 		for _, dep := range module.Requires {
 			if err := graph.AddEdge(dep, module.Name); err != nil {
 				return nil, err
 			}
 		}
+		for _, dep := range module.RequiresOptional {
+			if _, found := graph.GetNode(dep); !found {
+				continue
+			}
+			if err := graph.AddEdge(dep, module.Name); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return graph, nil
 }
 
 // TopologicalSort performs Kahn's algorithm to sort modules by dependencies.
-// Only includes modules that apply to the target OS.
+// Only includes modules that apply to the target OS. It is TopologicalSortFor
+// with no host facts other than the OS.
 func (r *Resolver) TopologicalSort(graph *Graph, targetOS string) ([]Module, error) {
-	// Create working copy of in-degrees, filtering by OS
+	return r.TopologicalSortFor(graph, HostFacts{OS: targetOS})
+}
+
+// TopologicalSortFor performs Kahn's algorithm to sort modules by dependencies.
+// Only includes modules whose os: filter and when: condition hold for facts
+// (see Module.Evaluate).
+//
+// Dependencies on modules excluded by the filter are resolved explicitly:
+// a soft (requires_optional) dependency is dropped, while a hard one fails
+// with an ExcludedDependencyError naming the dependency and the OS.
+//
+// The result is deterministic: whenever several modules are ready at once,
+// they are emitted in manifest order, so the same manifest always produces
+// the same module order.
+func (r *Resolver) TopologicalSortFor(graph *Graph, facts HostFacts) ([]Module, error) {
+	// Create working copy of in-degrees, filtering by host. Only edges whose
+	// both ends survive the filter count, so excluded soft dependencies never
+	// block their dependents.
 	inDegree := make(map[string]int)
+	decisions := make(map[string]Decision)
+	for _, name := range graph.GetAllNodes() {
+		node, _ := graph.GetNode(name)
+		decisions[name] = node.Module.Evaluate(facts)
+		if decisions[name].Included {
+			inDegree[name] = 0
+		}
+	}
+	for _, name := range graph.GetAllNodes() {
+		if _, ok := inDegree[name]; !ok {
+			continue
+		}
+		for _, dependent := range graph.GetDependents(name) {
+			if _, ok := inDegree[dependent]; ok {
+				inDegree[dependent]++
+			}
+		}
+	}
+
+	// Hard dependencies on excluded modules cannot be satisfied
 	for _, name := range graph.GetAllNodes() {
+		if _, ok := inDegree[name]; !ok {
+			continue
+		}
 		node, _ := graph.GetNode(name)
-		if node.Module.AppliesTo(targetOS) {
-			inDegree[name] = node.InDegree
+		for _, dep := range node.Module.Requires {
+			if _, included := inDegree[dep]; included {
+				continue
+			}
+			if depNode, exists := graph.GetNode(dep); exists {
+				excluded := NewExcludedDependencyError(name, dep, facts.OS, depNode.Module.OS)
+				excluded.Reason = decisions[dep].Reason
+				return nil, excluded
+			}
 		}
 	}
 
-	// Find all nodes with in-degree 0
+	// Seed the queue with all nodes of in-degree 0, in manifest order
 	queue := []string{}
-	for name, degree := range inDegree {
-		if degree == 0 {
+	for _, name := range graph.GetAllNodes() {
+		if degree, ok := inDegree[name]; ok && degree == 0 {
 			queue = append(queue, name)
 		}
 	}
@@ -69,7 +126,7 @@ func (r *Resolver) TopologicalSort(graph *Graph, targetOS string) ([]Module, err
 
 			inDegree[dependent]--
 			if inDegree[dependent] == 0 {
-				queue = append(queue, dependent)
+				queue = enqueueByIndex(graph, queue, dependent)
 			}
 		}
 	}
@@ -82,17 +139,41 @@ func (r *Resolver) TopologicalSort(graph *Graph, targetOS string) ([]Module, err
 	return result, nil
 }
 
-// detectCycle finds and reports a circular dependency.
+// enqueueByIndex inserts name into queue keeping it ordered by manifest position.
+func enqueueByIndex(graph *Graph, queue []string, name string) []string {
+	node, _ := graph.GetNode(name)
+	pos := len(queue)
+	for i, queued := range queue {
+		other, _ := graph.GetNode(queued)
+		if node.Index < other.Index {
+			pos = i
+			break
+		}
+	}
+	queue = append(queue, "")
+	copy(queue[pos+1:], queue[pos:])
+	queue[pos] = name
+	return queue
+}
+
+// detectCycle reports every dependency cycle among the modules left unsorted.
 func (r *Resolver) detectCycle(graph *Graph, inDegree map[string]int) error {
-	// Find nodes still in graph (part of cycle)
-	var cycleNodes []string
-	for name, degree := range inDegree {
-		if degree > 0 {
-			cycleNodes = append(cycleNodes, name)
+	var remaining []Module
+	for _, name := range graph.GetAllNodes() {
+		if degree, ok := inDegree[name]; ok && degree > 0 {
+			node, _ := graph.GetNode(name)
+			remaining = append(remaining, *node.Module)
 		}
 	}
 
-	// Build cycle path (simplified - just show nodes in cycle)
-	cyclePath := strings.Join(cycleNodes, " → ")
-	return NewCircularDependencyError("circular dependency detected: %s", cyclePath)
+	cycles := FindCycles(remaining)
+	if len(cycles) == 0 {
+		// Unreachable for a consistent graph; keep a useful message regardless.
+		names := make([]string, len(remaining))
+		for i, mod := range remaining {
+			names[i] = mod.Name
+		}
+		return NewCircularDependencyError("circular dependency detected among: %s", strings.Join(names, ", "))
+	}
+	return NewCycleError(cycles)
 }
@@ -118,6 +118,156 @@ func TestResolver_TopologicalSort(t *testing.T) {
 	}
 }
 
+func TestResolver_TopologicalSort_CycleError(t *testing.T) {
+	manifest := &Manifest{Modules: []Module{
+		{Name: "downstream", File: "d.sh", Requires: []string{"a"}},
+		{Name: "a", File: "a.sh", Requires: []string{"b"}, Location: SourceLocation{File: "manifest.yaml", Line: 4}},
+		{Name: "b", File: "b.sh", Requires: []string{"c"}, Location: SourceLocation{File: "manifest.yaml", Line: 7}},
+		{Name: "c", File: "c.sh", Requires: []string{"a"}, Location: SourceLocation{File: "manifest.yaml", Line: 10}},
+	}}
+	resolver := NewResolver()
+	graph, err := resolver.BuildGraph(manifest)
+	require.NoError(t, err)
+
+	_, err = resolver.TopologicalSort(graph, "Mac")
+	require.Error(t, err)
+
+	var cycleErr *CircularDependencyError
+	require.ErrorAs(t, err, &cycleErr)
+	require.Len(t, cycleErr.Cycles, 1)
+	assert.Equal(t, "a → b → c → a", cycleErr.Cycles[0].String())
+	assert.Equal(t,
+		"circular dependency detected: a (manifest.yaml:4) → b (manifest.yaml:7) → c (manifest.yaml:10) → a",
+		err.Error())
+	assert.NotContains(t, err.Error(), "downstream")
+}
+
+func TestResolver_TopologicalSort_ExcludedDependencies(t *testing.T) {
+	t.Run("hard dependency on excluded module is a clear error", func(t *testing.T) {
+		manifest := &Manifest{Modules: []Module{
+			{Name: "brew", File: "brew.sh", OS: []string{"Mac"}},
+			{Name: "tools", File: "tools.sh", OS: []string{"Linux"}, Requires: []string{"brew"}},
+		}}
+		resolver := NewResolver()
+		graph, err := resolver.BuildGraph(manifest)
+		require.NoError(t, err)
+
+		_, err = resolver.TopologicalSort(graph, "Linux")
+		require.Error(t, err)
+
+		var excluded *ExcludedDependencyError
+		require.ErrorAs(t, err, &excluded)
+		assert.Equal(t, "tools", excluded.Module)
+		assert.Equal(t, "brew", excluded.Dependency)
+		assert.Equal(t, "Linux", excluded.OS)
+		assert.Equal(t, []string{"Mac"}, excluded.DepOS)
+		assert.NotContains(t, err.Error(), "circular")
+	})
+
+	t.Run("optional dependency on excluded module is dropped", func(t *testing.T) {
+		manifest := &Manifest{Modules: []Module{
+			{Name: "brew", File: "brew.sh", OS: []string{"Mac"}},
+			{Name: "tools", File: "tools.sh", RequiresOptional: []string{"brew"}},
+			{Name: "after", File: "after.sh", Requires: []string{"tools"}},
+		}}
+		resolver := NewResolver()
+		graph, err := resolver.BuildGraph(manifest)
+		require.NoError(t, err)
+
+		result, err := resolver.TopologicalSort(graph, "Linux")
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "tools", result[0].Name)
+		assert.Equal(t, "after", result[1].Name)
+	})
+
+	t.Run("optional dependency still orders when present", func(t *testing.T) {
+		manifest := &Manifest{Modules: []Module{
+			{Name: "tools", File: "tools.sh", RequiresOptional: []string{"brew"}},
+			{Name: "brew", File: "brew.sh", OS: []string{"Mac"}},
+		}}
+		resolver := NewResolver()
+		graph, err := resolver.BuildGraph(manifest)
+		require.NoError(t, err)
+
+		result, err := resolver.TopologicalSort(graph, "Mac")
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "brew", result[0].Name)
+		assert.Equal(t, "tools", result[1].Name)
+	})
+
+	t.Run("optional dependency on unknown module is ignored", func(t *testing.T) {
+		manifest := &Manifest{Modules: []Module{
+			{Name: "tools", File: "tools.sh", RequiresOptional: []string{"ghost"}},
+			{Name: "after", File: "after.sh", Requires: []string{"tools"}},
+		}}
+		resolver := NewResolver()
+		graph, err := resolver.BuildGraph(manifest)
+		require.NoError(t, err)
+		assert.Equal(t, 2, graph.Size())
+
+		result, err := resolver.TopologicalSort(graph, "Linux")
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "tools", result[0].Name)
+		assert.Equal(t, "after", result[1].Name)
+	})
+}
+
+func TestResolver_TopologicalSortFor_Conditions(t *testing.T) {
+	manifest := &Manifest{Modules: []Module{
+		{Name: "brew-arm", File: "a.sh", OS: []string{"Mac"}, When: &Condition{Arch: []string{"arm64"}}},
+		{Name: "brew-intel", File: "i.sh", OS: []string{"Mac"}, When: &Condition{Arch: []string{"amd64"}}},
+		{Name: "tools", File: "t.sh", RequiresOptional: []string{"brew-arm", "brew-intel"}},
+		{Name: "arm-only", File: "x.sh", Requires: []string{"brew-arm"}},
+	}}
+	resolver := NewResolver()
+	graph, err := resolver.BuildGraph(manifest)
+	require.NoError(t, err)
+
+	result, err := resolver.TopologicalSortFor(graph, HostFacts{OS: "Mac", Arch: "arm64"})
+	require.NoError(t, err)
+	names := make([]string, len(result))
+	for i, mod := range result {
+		names[i] = mod.Name
+	}
+	assert.Equal(t, []string{"brew-arm", "tools", "arm-only"}, names)
+
+	_, err = resolver.TopologicalSortFor(graph, HostFacts{OS: "Mac", Arch: "amd64"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "module 'arm-only' requires 'brew-arm', which is excluded on Mac (when: arch amd64 not in [arm64])")
+}
+
+func TestResolver_TopologicalSort_ManifestOrderTieBreak(t *testing.T) {
+	// Independent modules and modules released at the same time must keep
+	// manifest order so repeated builds produce identical output.
+	manifest := &Manifest{Modules: []Module{
+		{Name: "zeta", File: "zeta.sh"},
+		{Name: "base", File: "base.sh"},
+		{Name: "mid", File: "mid.sh", Requires: []string{"base"}},
+		{Name: "alpha", File: "alpha.sh"},
+		{Name: "late", File: "late.sh", Requires: []string{"base"}},
+		{Name: "early", File: "early.sh", Requires: []string{"zeta"}},
+	}}
+	want := []string{"zeta", "base", "mid", "alpha", "late", "early"}
+
+	resolver := NewResolver()
+	for i := 0; i < 20; i++ {
+		graph, err := resolver.BuildGraph(manifest)
+		require.NoError(t, err)
+
+		result, err := resolver.TopologicalSort(graph, "Mac")
+		require.NoError(t, err)
+
+		names := make([]string, len(result))
+		for j, mod := range result {
+			names[j] = mod.Name
+		}
+		require.Equal(t, want, names, "run %d", i)
+	}
+}
+
 func TestResolver_BuildGraph(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"errors"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ModuleSelector narrows a manifest to a subset of modules by name or tag.
+//
+// With no include criteria every module is a candidate; otherwise only
+// modules named in Include or carrying one of IncludeTags are. Excludes are
+// then removed, and the hard dependencies of what remains are pulled in
+// transitively so the selection always builds.
+type ModuleSelector struct {
+	Include     []string `yaml:"include,omitempty"`
+	IncludeTags []string `yaml:"include_tags,omitempty"`
+	Exclude     []string `yaml:"exclude,omitempty"`
+	ExcludeTags []string `yaml:"exclude_tags,omitempty"`
+}
+
+// IsEmpty returns true if the selector keeps every module.
+func (s ModuleSelector) IsEmpty() bool {
+	return len(s.Include) == 0 && len(s.IncludeTags) == 0 &&
+		len(s.Exclude) == 0 && len(s.ExcludeTags) == 0
+}
+
+func (s ModuleSelector) included(mod *Module) bool {
+	if len(s.Include) == 0 && len(s.IncludeTags) == 0 {
+		return true
+	}
+	return slices.Contains(s.Include, mod.Name) || mod.HasAnyTag(s.IncludeTags)
+}
+
+func (s ModuleSelector) excluded(mod *Module) bool {
+	return slices.Contains(s.Exclude, mod.Name) || mod.HasAnyTag(s.ExcludeTags)
+}
+
+// Select returns a copy of m restricted to the selected modules, in manifest
+// order. Optional dependencies on unselected modules are dropped. It fails if
+// a named module does not exist or a selected module hard-requires an
+// excluded one (see Check).
+func (s ModuleSelector) Select(m *Manifest) (*Manifest, error) {
+	if s.IsEmpty() {
+		return m, nil
+	}
+
+	for _, name := range slices.Concat(s.Include, s.Exclude) {
+		if _, found := m.FindModule(name); !found {
+			return nil, NewValidationError("selection references unknown module '%s'", name)
+		}
+	}
+
+	selected, conflicts := s.resolve(m)
+	if len(conflicts) > 0 {
+		return nil, errors.Join(conflicts...)
+	}
+
+	out := *m
+	out.Modules = nil
+	for _, mod := range m.Modules {
+		if !selected[mod.Name] {
+			continue
+		}
+		if len(mod.RequiresOptional) > 0 {
+			var kept []string
+			for _, dep := range mod.RequiresOptional {
+				if selected[dep] {
+					kept = append(kept, dep)
+				}
+			}
+			mod.RequiresOptional = kept
+		}
+		out.Modules = append(out.Modules, mod)
+	}
+	return &out, nil
+}
+
+// Check reports every selected module whose hard requirement the selection
+// leaves out because it is excluded. An empty result means Select succeeds
+// as far as dependencies are concerned.
+func (s ModuleSelector) Check(m *Manifest) []error {
+	if s.IsEmpty() {
+		return nil
+	}
+	_, conflicts := s.resolve(m)
+	return conflicts
+}
+
+// resolve computes the selected module names: included minus excluded, plus
+// the transitive hard dependencies of those, following the dependency graph.
+func (s ModuleSelector) resolve(m *Manifest) (map[string]bool, []error) {
+	selected := make(map[string]bool)
+	var queue []string
+	for i := range m.Modules {
+		mod := &m.Modules[i]
+		if s.included(mod) && !s.excluded(mod) {
+			selected[mod.Name] = true
+			queue = append(queue, mod.Name)
+		}
+	}
+
+	var conflicts []error
+	for len(queue) > 0 {
+		mod, _ := m.FindModule(queue[0])
+		queue = queue[1:]
+		for _, dep := range mod.Requires {
+			depMod, found := m.FindModule(dep)
+			if !found || selected[dep] {
+				continue
+			}
+			if s.excluded(depMod) {
+				conflicts = append(conflicts, NewValidationError(
+					"module '%s' requires '%s', which is excluded by the selection", mod.Name, dep))
+				continue
+			}
+			selected[dep] = true
+			queue = append(queue, dep)
+		}
+	}
+	return selected, conflicts
+}
+
+// Profile is a named module selection for a class of machines.
+// Hosts holds hostname glob patterns used for automatic selection.
+type Profile struct {
+	Hosts          []string `yaml:"hosts,omitempty"`
+	ModuleSelector `yaml:",inline"`
+
+	// Vars override the manifest's vars when the profile is applied.
+	Vars map[string]string `yaml:"vars,omitempty"`
+}
+
+// MatchesHost reports whether hostname matches any of the profile's patterns.
+// Matching is case-insensitive and uses path.Match glob syntax.
+func (p *Profile) MatchesHost(hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, pattern := range p.Hosts {
+		if ok, _ := path.Match(strings.ToLower(pattern), hostname); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveProfile picks the profile to apply. An explicit name must exist.
+// Without one, the profile whose hosts match hostname is chosen; no match
+// means no profile (an empty name), several matches are an error.
+func (m *Manifest) ResolveProfile(name, hostname string) (string, *Profile, error) {
+	if name != "" {
+		profile, ok := m.Profiles[name]
+		if !ok {
+			return "", nil, NewValidationError("unknown profile '%s' (available: %s)",
+				name, strings.Join(m.ProfileNames(), ", "))
+		}
+		return name, &profile, nil
+	}
+
+	if hostname == "" {
+		return "", nil, nil
+	}
+
+	var matches []string
+	for _, candidate := range m.ProfileNames() {
+		profile := m.Profiles[candidate]
+		if profile.MatchesHost(hostname) {
+			matches = append(matches, candidate)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", nil, nil
+	case 1:
+		profile := m.Profiles[matches[0]]
+		return matches[0], &profile, nil
+	default:
+		return "", nil, NewValidationError("hostname '%s' matches several profiles (%s); choose one with --profile",
+			hostname, strings.Join(matches, ", "))
+	}
+}
+
+// ProfileNames returns the declared profile names in sorted order.
+func (m *Manifest) ProfileNames() []string {
+	names := make([]string, 0, len(m.Profiles))
+	for name := range m.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
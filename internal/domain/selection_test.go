@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func selectionManifest() *Manifest {
+	return &Manifest{Modules: []Module{
+		{Name: "path", File: "path.sh", Tags: []string{"core"}},
+		{Name: "prompt", File: "prompt.sh", Tags: []string{"ui"}, Requires: []string{"path"}},
+		{Name: "k8s", File: "k8s.sh", Tags: []string{"work"}, Requires: []string{"path"}, RequiresOptional: []string{"prompt"}},
+		{Name: "proxy", File: "proxy.sh", Tags: []string{"work", "network"}},
+		{Name: "games", File: "games.sh", Tags: []string{"personal"}},
+	}}
+}
+
+func selectedNames(t *testing.T, m *Manifest) []string {
+	t.Helper()
+	names := make([]string, len(m.Modules))
+	for i, mod := range m.Modules {
+		names[i] = mod.Name
+	}
+	return names
+}
+
+func TestModuleSelector_Select(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector ModuleSelector
+		want     []string
+		errMsg   string
+	}{
+		{
+			name: "empty selector keeps everything",
+			want: []string{"path", "prompt", "k8s", "proxy", "games"},
+		},
+		{
+			name:     "include tags pull in hard dependencies",
+			selector: ModuleSelector{IncludeTags: []string{"work"}},
+			want:     []string{"path", "k8s", "proxy"},
+		},
+		{
+			name:     "include by name",
+			selector: ModuleSelector{Include: []string{"prompt"}},
+			want:     []string{"path", "prompt"},
+		},
+		{
+			name:     "exclude tags without includes",
+			selector: ModuleSelector{ExcludeTags: []string{"personal", "network"}},
+			want:     []string{"path", "prompt", "k8s"},
+		},
+		{
+			name:     "excluding a required module is an error",
+			selector: ModuleSelector{IncludeTags: []string{"ui"}, Exclude: []string{"path"}},
+			errMsg:   "module 'prompt' requires 'path', which is excluded by the selection",
+		},
+		{
+			name:     "unknown module name is an error",
+			selector: ModuleSelector{Exclude: []string{"nope"}},
+			errMsg:   "unknown module 'nope'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.selector.Select(selectionManifest())
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, selectedNames(t, result))
+		})
+	}
+}
+
+func TestModuleSelector_Select_DropsUnselectedOptionalDeps(t *testing.T) {
+	result, err := ModuleSelector{IncludeTags: []string{"work"}}.Select(selectionManifest())
+	require.NoError(t, err)
+
+	k8s, found := result.FindModule("k8s")
+	require.True(t, found)
+	assert.Empty(t, k8s.RequiresOptional)
+
+	// The selected manifest must still resolve
+	graph, err := NewResolver().BuildGraph(result)
+	require.NoError(t, err)
+	_, err = NewResolver().TopologicalSort(graph, "Linux")
+	require.NoError(t, err)
+}
+
+func TestModuleSelector_Check(t *testing.T) {
+	m := selectionManifest()
+
+	assert.Empty(t, ModuleSelector{}.Check(m))
+	assert.Empty(t, ModuleSelector{IncludeTags: []string{"work"}}.Check(m))
+
+	errs := ModuleSelector{ExcludeTags: []string{"core"}}.Check(m)
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], "module 'prompt' requires 'path', which is excluded by the selection")
+	assert.EqualError(t, errs[1], "module 'k8s' requires 'path', which is excluded by the selection")
+}
+
+func TestManifest_ResolveProfile(t *testing.T) {
+	m := &Manifest{Profiles: map[string]Profile{
+		"work":   {Hosts: []string{"*-corp-*", "WORK-MBP"}},
+		"server": {Hosts: []string{"srv-*"}},
+		"home":   {},
+	}}
+
+	tests := []struct {
+		name     string
+		profile  string
+		hostname string
+		want     string
+		errMsg   string
+	}{
+		{name: "explicit profile", profile: "home", hostname: "srv-1", want: "home"},
+		{name: "unknown explicit profile", profile: "nope", errMsg: "unknown profile 'nope' (available: home, server, work)"},
+		{name: "hostname glob", hostname: "jane-corp-laptop", want: "work"},
+		{name: "case-insensitive hostname", hostname: "work-mbp", want: "work"},
+		{name: "no match", hostname: "desktop", want: ""},
+		{name: "no hostname", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, profile, err := m.ResolveProfile(tt.profile, tt.hostname)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, name)
+			assert.Equal(t, tt.want == "", profile == nil)
+		})
+	}
+}
+
+func TestManifest_ResolveProfile_Ambiguous(t *testing.T) {
+	m := &Manifest{Profiles: map[string]Profile{
+		"a": {Hosts: []string{"box-*"}},
+		"b": {Hosts: []string{"*-1"}},
+	}}
+
+	_, _, err := m.ResolveProfile("", "box-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "matches several profiles (a, b)")
+}
+
+func TestManifest_Validate_Profiles(t *testing.T) {
+	m := &Manifest{
+		Modules: []Module{{Name: "a", File: "a.sh"}},
+		Profiles: map[string]Profile{
+			"bad": {Hosts: []string{"["}, ModuleSelector: ModuleSelector{Exclude: []string{"ghost"}}},
+		},
+	}
+
+	errs := m.Validate()
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "profile 'bad' references non-existent module 'ghost'")
+	assert.Contains(t, errs[1].Error(), "invalid host pattern")
+}
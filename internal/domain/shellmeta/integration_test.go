@@ -62,6 +62,13 @@ func TestIntegration_LoadRealYAMLFiles(t *testing.T) {
 	if profiles.Automation == nil {
 		t.Error("Automation should not be nil")
 	}
+
+	// Verify every supported shell has load modes
+	for _, shell := range []string{"zsh", "bash", "fish"} {
+		if len(profiles.Core.LoadPriority.Modes[shell].Interactive) == 0 {
+			t.Errorf("load_priority.modes.%s.interactive should not be empty", shell)
+		}
+	}
 }
 
 func TestIntegration_QueryRealData(t *testing.T) {
@@ -1,6 +1,7 @@
 package shellmeta
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -61,6 +62,13 @@ func TestLoader_LoadCore(t *testing.T) {
 	if _, ok := core.OSProfiles.Linux.Distributions["ubuntu"]; !ok {
 		t.Error("ubuntu distribution should exist")
 	}
+
+	// Check load modes: a path is one step, a list is alternatives
+	login := core.LoadPriority.Modes["bash"].Login
+	want := []LoadStep{{"/etc/profile"}, {"~/.bash_profile", "~/.profile"}}
+	if !reflect.DeepEqual(login, want) {
+		t.Errorf("bash login mode = %v, want %v", login, want)
+	}
 }
 
 func TestLoader_LoadDev(t *testing.T) {
@@ -273,6 +281,12 @@ load_priority:
     - ~/.zshrc
   fish:
     - ~/.config/fish/config.fish
+  modes:
+    bash:
+      login:
+        - /etc/profile
+        - [~/.bash_profile, ~/.profile]
+      interactive: [~/.bashrc]
 
 shell_detection:
   bash:
@@ -1,5 +1,7 @@
 package shellmeta
 
+import "gopkg.in/yaml.v3"
+
 // ShellProfiles aggregates all shell profile metadata from multiple YAML files.
 type ShellProfiles struct {
 	Core       *CoreProfiles       `yaml:"-"`
@@ -43,6 +45,35 @@ type LoadPriority struct {
 	Bash             []string `yaml:"bash,omitempty"`
 	Zsh              []string `yaml:"zsh"`
 	Fish             []string `yaml:"fish"`
+	// Modes lists, per shell, the files read at startup in each mode.
+	Modes map[string]LoadModes `yaml:"modes,omitempty"`
+}
+
+// LoadModes lists the files a shell reads at startup, in order, for each
+// kind of shell session.
+type LoadModes struct {
+	Login          []LoadStep `yaml:"login"`           // Interactive login shell
+	Interactive    []LoadStep `yaml:"interactive"`     // Interactive non-login shell
+	NonInteractive []LoadStep `yaml:"non_interactive"` // Scripts and -c commands
+}
+
+// LoadStep is one step of a load order: a single file, or alternatives of
+// which the shell reads only the first that exists. It is written in YAML
+// as a path or a list of paths.
+type LoadStep []string
+
+// UnmarshalYAML accepts a single path or a list of paths.
+func (s *LoadStep) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = LoadStep{node.Value}
+		return nil
+	}
+	var files []string
+	if err := node.Decode(&files); err != nil {
+		return err
+	}
+	*s = files
+	return nil
 }
 
 // ShellDetection defines how to detect a shell and its version.
@@ -243,16 +274,17 @@ type DesktopWorkaround struct {
 // LanguageVersionMgr defines a language version manager configuration.
 // InitCommand and InitFiles can be strings, []string, or nested maps depending on complexity.
 type LanguageVersionMgr struct {
-	InitCommand           any    `yaml:"init_command"` // string or map[string]string
-	InitFiles             any    `yaml:"init_files"`   // []string or map[string]interface{}
-	ShimsLocation         string `yaml:"shims_location,omitempty"`
-	PathModification      bool   `yaml:"path_modification,omitempty"`
-	FunctionDefinitions   bool   `yaml:"function_definitions,omitempty"`
-	LazyLoad              bool   `yaml:"lazy_load,omitempty"`
-	EnvironmentActivation bool   `yaml:"environment_activation,omitempty"`
-	EnvFile               string `yaml:"env_file,omitempty"`
-	TypicalProblem        string `yaml:"typical_problem,omitempty"`
-	Note                  string `yaml:"note,omitempty"`
+	InitCommand           any      `yaml:"init_command"` // string or map[string]string
+	InitFiles             any      `yaml:"init_files"`   // []string or map[string]interface{}
+	ShimsLocation         string   `yaml:"shims_location,omitempty"`
+	PathModification      bool     `yaml:"path_modification,omitempty"`
+	FunctionDefinitions   bool     `yaml:"function_definitions,omitempty"`
+	LazyLoad              bool     `yaml:"lazy_load,omitempty"`
+	LazyTriggers          []string `yaml:"lazy_triggers,omitempty"` // commands that load it when LazyLoad (default: its name)
+	EnvironmentActivation bool     `yaml:"environment_activation,omitempty"`
+	EnvFile               string   `yaml:"env_file,omitempty"`
+	TypicalProblem        string   `yaml:"typical_problem,omitempty"`
+	Note                  string   `yaml:"note,omitempty"`
 }
 
 // AutomationProfiles contains automation and isolated environment information.
@@ -0,0 +1,43 @@
+package domain
+
+// SourceSpan records which output lines of a generated file hold a module's
+// content, so diagnostics against the output can be traced back to the
+// module file.
+type SourceSpan struct {
+	Module     string `json:"module"`
+	File       string `json:"file"`             // module file, as written in the manifest
+	StartLine  int    `json:"start_line"`       // first output line (1-based)
+	Lines      int    `json:"lines"`            // number of output lines
+	SourceLine int    `json:"source_line"`      // module file line at StartLine (1-based)
+	Inline     bool   `json:"inline,omitempty"` // File is the manifest declaring the body inline
+}
+
+// EndLine returns the last output line of the span.
+func (s SourceSpan) EndLine() int {
+	return s.StartLine + s.Lines - 1
+}
+
+// Contains reports whether output line falls inside the span.
+func (s SourceSpan) Contains(line int) bool {
+	return line >= s.StartLine && line <= s.EndLine()
+}
+
+// ModuleLine converts an output line inside the span to a module file line.
+func (s SourceSpan) ModuleLine(line int) int {
+	return s.SourceLine + line - s.StartLine
+}
+
+// SourceMap lists the module spans of one generated file in output order.
+type SourceMap []SourceSpan
+
+// Locate returns the span covering output line and the corresponding line in
+// the module file. ok is false for lines outside any module (headers,
+// separators).
+func (m SourceMap) Locate(line int) (span SourceSpan, moduleLine int, ok bool) {
+	for _, s := range m {
+		if s.Contains(line) {
+			return s, s.ModuleLine(line), true
+		}
+	}
+	return SourceSpan{}, 0, false
+}
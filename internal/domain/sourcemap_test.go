@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceMap_Locate(t *testing.T) {
+	m := SourceMap{
+		{Module: "a", File: "a.sh", StartLine: 10, Lines: 3, SourceLine: 1},
+		{Module: "b", File: "b.sh", StartLine: 16, Lines: 1, SourceLine: 5},
+	}
+
+	tests := []struct {
+		line       int
+		wantModule string
+		wantLine   int
+		wantOK     bool
+	}{
+		{line: 1, wantOK: false},
+		{line: 10, wantModule: "a", wantLine: 1, wantOK: true},
+		{line: 12, wantModule: "a", wantLine: 3, wantOK: true},
+		{line: 13, wantOK: false},
+		{line: 16, wantModule: "b", wantLine: 5, wantOK: true},
+		{line: 17, wantOK: false},
+	}
+	for _, tt := range tests {
+		span, line, ok := m.Locate(tt.line)
+		assert.Equal(t, tt.wantOK, ok, "line %d", tt.line)
+		assert.Equal(t, tt.wantModule, span.Module, "line %d", tt.line)
+		assert.Equal(t, tt.wantLine, line, "line %d", tt.line)
+	}
+}
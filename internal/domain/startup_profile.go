@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartupProfileEnv names the environment variable profiling probes append
+// their measurements to. When it is unset the probes record nothing, so a
+// profiled build stays quiet if deployed by accident.
+const StartupProfileEnv = "SHELLFORGE_PROFILE_LOG"
+
+// startupProbeTag starts every line a probe writes.
+const startupProbeTag = "shellforge-profile"
+
+// StartupPreamble returns the lines a profiled file needs before its first
+// probe: zsh loads zsh/datetime for $EPOCHREALTIME.
+func StartupPreamble(shell string) []string {
+	if strings.ToLower(shell) == "zsh" {
+		return []string{"zmodload zsh/datetime 2>/dev/null"}
+	}
+	return nil
+}
+
+// StartupProbe returns the timing probe placed around a module: before
+// records the start time, after appends "module, target, start, end" to
+// $SHELLFORGE_PROFILE_LOG.
+//
+// zsh uses $EPOCHREALTIME from zsh/datetime and bash 5+ its own
+// $EPOCHREALTIME (older bash falls back to whole $SECONDS). fish has no
+// clock builtin and runs date(1), which costs a process per probe and needs
+// a date supporting %N.
+func StartupProbe(shell, module, target string) (before, after []string) {
+	format := `'` + startupProbeTag + `\t%s\t%s\t%s\t%s\n'`
+	name, file := shellSingleQuote(module), shellSingleQuote(target)
+
+	if strings.ToLower(shell) == "fish" {
+		return []string{"set -g __shellforge_t0 (date +%s.%N)"},
+			[]string{
+				"if set -q " + StartupProfileEnv,
+				fmt.Sprintf("    printf %s %s %s $__shellforge_t0 (date +%%s.%%N) >>$%s", format, name, file, StartupProfileEnv),
+				"end",
+			}
+	}
+
+	clock := "$EPOCHREALTIME"
+	if strings.ToLower(shell) != "zsh" {
+		clock = "${EPOCHREALTIME:-$SECONDS}"
+	}
+	return []string{"__shellforge_t0=" + clock},
+		[]string{fmt.Sprintf(`if [ -n "${%s:-}" ]; then printf %s %s %s "$__shellforge_t0" "%s" >>"$%s"; fi`,
+			StartupProfileEnv, format, name, file, clock, StartupProfileEnv)}
+}
+
+// ModuleTiming is the measured load time of one module.
+type ModuleTiming struct {
+	Module   string
+	Target   string
+	Duration time.Duration
+}
+
+// ParseStartupProfile reads the lines probes wrote to the profile log.
+// Repeated measurements of a module (several runs, or a file sourced twice)
+// are averaged. Timings are sorted slowest first.
+func ParseStartupProfile(log string) ([]ModuleTiming, error) {
+	type key struct{ module, target string }
+	totals := make(map[key]time.Duration)
+	counts := make(map[key]int)
+	var order []key
+
+	for i, line := range strings.Split(log, "\n") {
+		if !strings.HasPrefix(line, startupProbeTag+"\t") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("profile log line %d: expected 5 fields, got %d", i+1, len(fields))
+		}
+		start, err := parseProbeTime(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("profile log line %d: %w", i+1, err)
+		}
+		end, err := parseProbeTime(fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("profile log line %d: %w", i+1, err)
+		}
+
+		k := key{fields[1], fields[2]}
+		if counts[k] == 0 {
+			order = append(order, k)
+		}
+		totals[k] += max(end-start, 0)
+		counts[k]++
+	}
+
+	timings := make([]ModuleTiming, 0, len(order))
+	for _, k := range order {
+		timings = append(timings, ModuleTiming{Module: k.module, Target: k.target, Duration: totals[k] / time.Duration(counts[k])})
+	}
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].Duration > timings[j].Duration
+	})
+	return timings, nil
+}
+
+// parseProbeTime parses a probe timestamp in seconds. $EPOCHREALTIME uses
+// the locale's decimal separator.
+func parseProbeTime(s string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q (does the shell's clock support sub-second time?)", s)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// shellSingleQuote quotes s for zsh, bash and fish.
+func shellSingleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupProbe(t *testing.T) {
+	before, after := StartupProbe("zsh", "nvm", "zshrc")
+	assert.Equal(t, []string{"__shellforge_t0=$EPOCHREALTIME"}, before)
+	assert.Equal(t, []string{`if [ -n "${SHELLFORGE_PROFILE_LOG:-}" ]; then printf 'shellforge-profile\t%s\t%s\t%s\t%s\n' 'nvm' 'zshrc' "$__shellforge_t0" "$EPOCHREALTIME" >>"$SHELLFORGE_PROFILE_LOG"; fi`}, after)
+	assert.Equal(t, []string{"zmodload zsh/datetime 2>/dev/null"}, StartupPreamble("zsh"))
+
+	before, after = StartupProbe("bash", "it's", "bashrc")
+	assert.Equal(t, []string{"__shellforge_t0=${EPOCHREALTIME:-$SECONDS}"}, before)
+	assert.Contains(t, after[0], `'it'\''s' 'bashrc' "$__shellforge_t0" "${EPOCHREALTIME:-$SECONDS}"`)
+	assert.Empty(t, StartupPreamble("bash"))
+
+	before, after = StartupProbe("fish", "nvm", "config")
+	assert.Equal(t, []string{"set -g __shellforge_t0 (date +%s.%N)"}, before)
+	assert.Equal(t, []string{
+		"if set -q SHELLFORGE_PROFILE_LOG",
+		`    printf 'shellforge-profile\t%s\t%s\t%s\t%s\n' 'nvm' 'config' $__shellforge_t0 (date +%s.%N) >>$SHELLFORGE_PROFILE_LOG`,
+		"end",
+	}, after)
+	assert.Empty(t, StartupPreamble("fish"))
+}
+
+func TestParseStartupProfile(t *testing.T) {
+	log := "unrelated output\n" +
+		"shellforge-profile\tfast\tzshrc\t10.000\t10.001\n" +
+		"shellforge-profile\tslow\tzshrc\t10.001\t10.201\n" +
+		"shellforge-profile\tfast\tzshrc\t20.000\t20.003\n" +
+		"shellforge-profile\tcomma\tzshrc\t30,000\t30,050\n"
+
+	timings, err := ParseStartupProfile(log)
+	require.NoError(t, err)
+	require.Len(t, timings, 3)
+	assert.Equal(t, "slow", timings[0].Module)
+	assert.Equal(t, "comma", timings[1].Module)
+	assert.Equal(t, "fast", timings[2].Module)
+	assert.Equal(t, "zshrc", timings[2].Target)
+	assert.InDelta(t, 2*time.Millisecond, timings[2].Duration, float64(10*time.Microsecond), "runs are averaged")
+
+	_, err = ParseStartupProfile("shellforge-profile\tx\tzshrc\t1.0\n")
+	assert.ErrorContains(t, err, "expected 5 fields")
+	_, err = ParseStartupProfile("shellforge-profile\tx\tconfig\t1.N\t2.N\n")
+	assert.ErrorContains(t, err, "sub-second")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -28,6 +29,7 @@ type TargetResolver struct {
 	shellType string
 	homeDir   string
 	pathMaps  map[string]map[string]string // shell -> target -> path
+	custom    map[string]CustomTarget      // targets added by AddCustomTargets
 }
 
 // NewTargetResolver creates a new resolver for the given shell type and home directory.
@@ -54,6 +56,7 @@ func (r *TargetResolver) initPathMaps() {
 			"zlogin":   ".zlogin",
 			"zlogout":  ".zlogout",
 			"profile":  ".profile",
+			"zshrc.d":  ".zshrc.d",
 		},
 		"bash": {
 			"bashrc":       ".bashrc",
@@ -61,6 +64,7 @@ func (r *TargetResolver) initPathMaps() {
 			"profile":      ".profile",
 			"bash_login":   ".bash_login",
 			"bash_logout":  ".bash_logout",
+			"bashrc.d":     ".bashrc.d",
 		},
 		"fish": {
 			"config": filepath.Join(fishConfigBase, "fish", "config.fish"),
@@ -69,6 +73,81 @@ func (r *TargetResolver) initPathMaps() {
 	}
 }
 
+// AddCustomTargets makes the manifest's custom targets valid for the
+// current shell type. A custom target may not reuse a built-in target name,
+// and SourcedBy must name a home file target.
+func (r *TargetResolver) AddCustomTargets(targets map[string]CustomTarget) error {
+	shellMap, ok := r.pathMaps[r.shellType]
+	if !ok {
+		if len(targets) == 0 {
+			return nil
+		}
+		return NewValidationError("unsupported shell type: %s", r.shellType)
+	}
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		target := targets[name]
+		if err := target.Validate(name); err != nil {
+			return err
+		}
+		if _, ok := shellMap[name]; ok {
+			return NewValidationError("target '%s' is already a built-in target for shell type '%s'", name, r.shellType)
+		}
+		shellMap[name] = target.RelativePath()
+		if r.custom == nil {
+			r.custom = make(map[string]CustomTarget)
+		}
+		r.custom[name] = target
+	}
+
+	// A directory target owns its directory: stale file cleanup and the
+	// directory loader would also pick up another target's file in it
+	all := make([]string, 0, len(shellMap))
+	for name := range shellMap {
+		all = append(all, name)
+	}
+	sort.Strings(all)
+	for _, name := range names {
+		for _, other := range all {
+			if other == name || !(r.IsDirectoryTarget(name) || r.IsDirectoryTarget(other)) {
+				continue
+			}
+			if pathsOverlap(shellMap[name], shellMap[other]) {
+				return NewValidationError(
+					"target '%s' path '%s' overlaps target '%s' (%s); a directory target needs a directory of its own",
+					name, targets[name].Path, other, shellMap[other],
+				)
+			}
+		}
+	}
+
+	for _, name := range names {
+		sourcedBy := strings.ToLower(targets[name].SourcedBy)
+		if sourcedBy == "" {
+			continue
+		}
+		if _, ok := shellMap[sourcedBy]; !ok || r.IsDirectoryTarget(sourcedBy) {
+			return NewValidationError(
+				"target '%s' is sourced by '%s', which is not a file target for shell type '%s'",
+				name, targets[name].SourcedBy, r.shellType,
+			)
+		}
+	}
+	return nil
+}
+
+// pathsOverlap reports whether two home-relative paths are the same or one
+// lies inside the other.
+func pathsOverlap(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
 // resolveFishConfigBase returns the base config directory for Fish shell.
 // Respects XDG_CONFIG_HOME environment variable (XDG Base Directory Specification).
 func (r *TargetResolver) resolveFishConfigBase() string {
@@ -168,14 +247,48 @@ func (r *TargetResolver) GetShellType() string {
 	return r.shellType
 }
 
-// IsDirectoryTarget returns true if the target is a directory (e.g., conf.d).
+// IsDirectoryTarget returns true if the target is a directory that gets one
+// file per module (conf.d, zshrc.d, bashrc.d or a custom directory).
 func (r *TargetResolver) IsDirectoryTarget(target string) bool {
 	target = strings.ToLower(target)
-	// Directory targets that generate multiple files
-	directoryTargets := map[string]bool{
-		"conf.d": true,
+	if custom, ok := r.custom[target]; ok {
+		return custom.IsDirectory()
+	}
+	_, ok := directoryTargets[target]
+	return ok
+}
+
+// DirectoryFileExt returns the extension of module files in a directory
+// target.
+func (r *TargetResolver) DirectoryFileExt(target string) string {
+	target = strings.ToLower(target)
+	if custom, ok := r.custom[target]; ok {
+		return custom.FileExtension()
+	}
+	return directoryTargets[target].ext
+}
+
+// CommentPrefix returns the prefix of the header comments in files built
+// for target: "#" unless a custom target sets its own.
+func (r *TargetResolver) CommentPrefix(target string) string {
+	if custom, ok := r.custom[strings.ToLower(target)]; ok {
+		return custom.CommentPrefix()
+	}
+	return "#"
+}
+
+// SourcedBy returns the target whose file sources a custom target, or "".
+func (r *TargetResolver) SourcedBy(target string) string {
+	return strings.ToLower(r.custom[strings.ToLower(target)].SourcedBy)
+}
+
+// IsShellSourced reports whether a shell runs the files of target: true
+// for built-in targets and custom targets with SourcedBy.
+func (r *TargetResolver) IsShellSourced(target string) bool {
+	if _, ok := r.custom[strings.ToLower(target)]; ok {
+		return r.SourcedBy(target) != ""
 	}
-	return directoryTargets[target]
+	return true
 }
 
 // GetDefaultTarget returns the default target for the current shell type.
@@ -231,6 +344,8 @@ func GetTargetDescription(target string) string {
 		"bash_logout":  "Login shell exit",
 		"config":       "Fish shell configuration",
 		"conf.d":       "Fish modular configs (auto-sourced .fish files in conf.d/)",
+		"zshrc.d":      "Zsh modular configs (.zsh files in ~/.zshrc.d/, sourced from .zshrc)",
+		"bashrc.d":     "Bash modular configs (.sh files in ~/.bashrc.d/, sourced from .bashrc)",
 		// System-wide targets (require elevated privileges)
 		"etc-profile": "System-wide login shell config (/etc/profile) — affects all users, requires sudo",
 		"etc-zshrc":   "System-wide zsh interactive config (/etc/zshrc) — affects all users, requires sudo",
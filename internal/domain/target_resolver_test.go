@@ -124,12 +124,12 @@ func TestTargetResolver_GetValidTargets(t *testing.T) {
 		{
 			name:      "zsh targets",
 			shellType: "zsh",
-			want:      append([]string{"zshrc", "zprofile", "zshenv", "zlogin", "zlogout", "profile"}, systemTargets...),
+			want:      append([]string{"zshrc", "zprofile", "zshenv", "zlogin", "zlogout", "profile", "zshrc.d"}, systemTargets...),
 		},
 		{
 			name:      "bash targets",
 			shellType: "bash",
-			want:      append([]string{"bashrc", "bash_profile", "profile", "bash_login", "bash_logout"}, systemTargets...),
+			want:      append([]string{"bashrc", "bash_profile", "profile", "bash_login", "bash_logout", "bashrc.d"}, systemTargets...),
 		},
 		{
 			name:      "fish targets",
@@ -339,6 +339,8 @@ func TestTargetResolver_IsDirectoryTarget(t *testing.T) {
 		isDir  bool
 	}{
 		{"conf.d", true},
+		{"zshrc.d", true},
+		{"bashrc.d", true},
 		{"config", false},
 		{"zshrc", false},
 		{"bashrc", false},
@@ -0,0 +1,207 @@
+package domain
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// templateData is the value module fields and bodies are rendered against,
+// so variables are referenced explicitly as {{ .Vars.name }}.
+type templateData struct {
+	Vars map[string]string
+}
+
+// ResolveVars returns the variables in effect for a build, merging in
+// increasing precedence: the manifest's vars, os_vars for targetOS (matched
+// case-insensitively), the named profile's vars, then overrides (--var).
+func (m *Manifest) ResolveVars(targetOS, profile string, overrides map[string]string) map[string]string {
+	vars := make(map[string]string)
+	maps.Copy(vars, m.Vars)
+	for osName, osVars := range m.OSVars {
+		if strings.EqualFold(osName, targetOS) {
+			maps.Copy(vars, osVars)
+		}
+	}
+	if p, ok := m.Profiles[profile]; ok {
+		maps.Copy(vars, p.Vars)
+	}
+	maps.Copy(vars, overrides)
+	return vars
+}
+
+// DeclaredVars returns every variable name defined anywhere in the manifest
+// (vars, any os_vars entry, any profile), sorted.
+func (m *Manifest) DeclaredVars() []string {
+	seen := make(map[string]bool)
+	for name := range m.Vars {
+		seen[name] = true
+	}
+	for _, osVars := range m.OSVars {
+		for name := range osVars {
+			seen[name] = true
+		}
+	}
+	for _, p := range m.Profiles {
+		for name := range p.Vars {
+			seen[name] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// ExpandVars returns a copy of m with vars interpolated into each module's
+// file, requires_path and path entries, and Vars replaced by vars so later
+// stages (module bodies with 'interpolate: true') render against the same
+// values.
+func (m *Manifest) ExpandVars(vars map[string]string) (*Manifest, error) {
+	out := *m
+	out.Vars = vars
+	out.Modules = make([]Module, len(m.Modules))
+	for i, mod := range m.Modules {
+		expanded, err := mod.ExpandVars(vars)
+		if err != nil {
+			return nil, err
+		}
+		out.Modules[i] = expanded
+	}
+	return &out, nil
+}
+
+// ExpandVarsFor is ExpandVars for the modules Module.Evaluate selects for
+// facts; the others are kept as declared, so a variable defined only for
+// another OS (os_vars) does not fail.
+func (m *Manifest) ExpandVarsFor(facts HostFacts, vars map[string]string) (*Manifest, error) {
+	out := *m
+	out.Vars = vars
+	out.Modules = make([]Module, len(m.Modules))
+	for i, mod := range m.Modules {
+		if mod.Evaluate(facts).Included {
+			var err error
+			if mod, err = mod.ExpandVars(vars); err != nil {
+				return nil, err
+			}
+		}
+		out.Modules[i] = mod
+	}
+	return &out, nil
+}
+
+// ExpandVars returns a copy of the module with vars interpolated into its
+// file, requires_path and path entries.
+func (m *Module) ExpandVars(vars map[string]string) (Module, error) {
+	out := *m
+	file, err := Interpolate(out.File, vars)
+	if err != nil {
+		return Module{}, NewValidationError("module '%s' file: %v", m.Name, err)
+	}
+	out.File = file
+
+	if out.RequiresPath, err = interpolateAll(out.RequiresPath, vars); err != nil {
+		return Module{}, NewValidationError("module '%s' requires_path: %v", m.Name, err)
+	}
+
+	if len(out.Path) > 0 {
+		rules := make([]PathRule, len(out.Path))
+		for j, rule := range out.Path {
+			if rule.Prepend, err = interpolateAll(rule.Prepend, vars); err == nil {
+				rule.Append, err = interpolateAll(rule.Append, vars)
+			}
+			if err != nil {
+				return Module{}, NewValidationError("module '%s' path: %v", m.Name, err)
+			}
+			rules[j] = rule
+		}
+		out.Path = rules
+	}
+	return out, nil
+}
+
+// interpolateAll interpolates vars into each string of list, returning a new slice.
+func interpolateAll(list []string, vars map[string]string) ([]string, error) {
+	if list == nil {
+		return nil, nil
+	}
+	out := make([]string, len(list))
+	for i, s := range list {
+		var err error
+		if out[i], err = Interpolate(s, vars); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Interpolate renders s as a Go template against {{ .Vars.name }}.
+// Referencing an undefined variable is an error. Strings without "{{" are
+// returned unchanged.
+func Interpolate(s string, vars map[string]string) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	tmpl, err := template.New("").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, templateData{Vars: vars}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// VarRefs returns the variable names s references as .Vars.name, in order of
+// first use. It fails if s is not a valid template.
+func VarRefs(s string) ([]string, error) {
+	if !strings.Contains(s, "{{") {
+		return nil, nil
+	}
+	tmpl, err := template.New("").Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	walkVarRefs(tmpl.Tree.Root, &refs)
+	return refs, nil
+}
+
+func walkVarRefs(node parse.Node, refs *[]string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkVarRefs(child, refs)
+		}
+	case *parse.ActionNode:
+		walkVarRefs(n.Pipe, refs)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, refs)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, refs)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, refs)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				walkVarRefs(arg, refs)
+			}
+		}
+	case *parse.FieldNode:
+		if len(n.Ident) >= 2 && n.Ident[0] == "Vars" && !slices.Contains(*refs, n.Ident[1]) {
+			*refs = append(*refs, n.Ident[1])
+		}
+	}
+}
+
+func walkBranch(n *parse.BranchNode, refs *[]string) {
+	walkVarRefs(n.Pipe, refs)
+	walkVarRefs(n.List, refs)
+	walkVarRefs(n.ElseList, refs)
+}
@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func varsManifest() *Manifest {
+	return &Manifest{
+		Vars: map[string]string{"brew": "/usr/local", "proxy": "", "gopath": "$HOME/go"},
+		OSVars: map[string]map[string]string{
+			"Mac": {"brew": "/opt/homebrew"},
+		},
+		Profiles: map[string]Profile{
+			"work": {Vars: map[string]string{"proxy": "http://proxy.corp:3128"}},
+		},
+		Modules: []Module{
+			{
+				Name: "brew", File: "{{ .Vars.brew }}/env.sh", RequiresPath: []string{"{{ .Vars.brew }}/bin/brew", "~/.config"},
+				Path: []PathRule{{Prepend: []string{"{{ .Vars.brew }}/bin"}, Append: []string{"{{ .Vars.gopath }}/bin"}}},
+			},
+			{Name: "plain", File: "plain.sh"},
+		},
+	}
+}
+
+func TestManifest_ResolveVars(t *testing.T) {
+	m := varsManifest()
+
+	tests := []struct {
+		name      string
+		targetOS  string
+		profile   string
+		overrides map[string]string
+		want      map[string]string
+	}{
+		{
+			name:     "base vars",
+			targetOS: "Linux",
+			want:     map[string]string{"brew": "/usr/local", "proxy": "", "gopath": "$HOME/go"},
+		},
+		{
+			name:     "os_vars override base, case-insensitively",
+			targetOS: "mac",
+			want:     map[string]string{"brew": "/opt/homebrew", "proxy": "", "gopath": "$HOME/go"},
+		},
+		{
+			name:     "profile overrides os",
+			targetOS: "Mac",
+			profile:  "work",
+			want:     map[string]string{"brew": "/opt/homebrew", "proxy": "http://proxy.corp:3128", "gopath": "$HOME/go"},
+		},
+		{
+			name:      "overrides win",
+			targetOS:  "Mac",
+			profile:   "work",
+			overrides: map[string]string{"brew": "/custom", "extra": "x"},
+			want:      map[string]string{"brew": "/custom", "proxy": "http://proxy.corp:3128", "gopath": "$HOME/go", "extra": "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, m.ResolveVars(tt.targetOS, tt.profile, tt.overrides))
+		})
+	}
+
+	// Resolving must not modify the manifest
+	assert.Equal(t, "/usr/local", m.Vars["brew"])
+}
+
+func TestManifest_DeclaredVars(t *testing.T) {
+	assert.Equal(t, []string{"brew", "gopath", "proxy"}, varsManifest().DeclaredVars())
+}
+
+func TestManifest_ExpandVars(t *testing.T) {
+	m := varsManifest()
+	vars := m.ResolveVars("Mac", "", nil)
+
+	out, err := m.ExpandVars(vars)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/opt/homebrew/env.sh", out.Modules[0].File)
+	assert.Equal(t, []string{"/opt/homebrew/bin/brew", "~/.config"}, out.Modules[0].RequiresPath)
+	assert.Equal(t, []PathRule{{Prepend: []string{"/opt/homebrew/bin"}, Append: []string{"$HOME/go/bin"}}}, out.Modules[0].Path)
+	assert.Equal(t, "plain.sh", out.Modules[1].File)
+	assert.Equal(t, vars, out.Vars)
+
+	// The original is untouched
+	assert.Equal(t, "{{ .Vars.brew }}/env.sh", m.Modules[0].File)
+	assert.Equal(t, "{{ .Vars.brew }}/bin/brew", m.Modules[0].RequiresPath[0])
+	assert.Equal(t, "{{ .Vars.brew }}/bin", m.Modules[0].Path[0].Prepend[0])
+
+	_, err = m.ExpandVars(map[string]string{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "module 'brew' file")
+	assert.Contains(t, err.Error(), `"brew"`)
+}
+
+func TestManifest_ExpandVarsFor(t *testing.T) {
+	m := &Manifest{Modules: []Module{
+		{Name: "brew", File: "{{ .Vars.brew }}/env.sh", OS: []string{"Mac"}},
+		{Name: "git", File: "{{ .Vars.dir }}/git.sh"},
+	}}
+	vars := map[string]string{"dir": "tools"}
+
+	_, err := m.ExpandVars(vars)
+	require.Error(t, err, "ExpandVars expands every module")
+
+	out, err := m.ExpandVarsFor(HostFacts{OS: "Linux"}, vars)
+	require.NoError(t, err)
+	assert.Equal(t, "{{ .Vars.brew }}/env.sh", out.Modules[0].File, "modules for another OS are kept as declared")
+	assert.Equal(t, "tools/git.sh", out.Modules[1].File)
+	assert.Equal(t, vars, out.Vars)
+}
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]string{"proxy": "http://p:3128"}
+
+	got, err := Interpolate(`export HTTP_PROXY="{{ .Vars.proxy }}"`, vars)
+	require.NoError(t, err)
+	assert.Equal(t, `export HTTP_PROXY="http://p:3128"`, got)
+
+	// No template markers: returned verbatim, even with other braces
+	got, err = Interpolate(`echo ${HOME} $((1+2))`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `echo ${HOME} $((1+2))`, got)
+
+	_, err = Interpolate(`{{ .Vars.missing }}`, vars)
+	assert.Error(t, err)
+
+	_, err = Interpolate(`{{ .Vars.proxy `, vars)
+	assert.Error(t, err)
+}
+
+func TestVarRefs(t *testing.T) {
+	refs, err := VarRefs(`{{ .Vars.a }} {{ if .Vars.b }}{{ .Vars.c }}{{ else }}{{ .Vars.a }}{{ end }}{{ with .Vars.d }}{{ . }}{{ end }}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, refs)
+
+	refs, err = VarRefs("plain text")
+	require.NoError(t, err)
+	assert.Empty(t, refs)
+
+	_, err = VarRefs("{{ .Vars.a ")
+	assert.Error(t, err)
+}
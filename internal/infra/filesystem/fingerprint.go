@@ -0,0 +1,39 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/afero"
+)
+
+// Fingerprinter records file sizes and modification times for change
+// polling.
+type Fingerprinter struct {
+	fs afero.Fs
+}
+
+// NewFingerprinter creates a new fingerprinter.
+func NewFingerprinter(fs afero.Fs) *Fingerprinter {
+	return &Fingerprinter{fs: fs}
+}
+
+// Fingerprint returns a stamp per regular file among paths; directories are
+// walked recursively. Paths that do not exist are left out, so a deleted
+// file shows up as a missing key.
+func (f *Fingerprinter) Fingerprint(paths []string) map[string]string {
+	stamps := make(map[string]string)
+	for _, root := range paths {
+		_ = afero.Walk(f.fs, root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				// Unreadable or vanished while walking: skip it
+				return nil
+			}
+			if info.Mode().IsRegular() {
+				stamps[path] = fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+			}
+			return nil
+		})
+	}
+	return stamps
+}
@@ -0,0 +1,27 @@
+package filesystem
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprinter_Fingerprint(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte("modules: []"), 0o644)
+	afero.WriteFile(fs, "modules/a.sh", []byte("echo a"), 0o644)
+	afero.WriteFile(fs, "modules/sub/b.sh", []byte("echo b"), 0o644)
+
+	fp := NewFingerprinter(fs)
+	before := fp.Fingerprint([]string{"manifest.yaml", "modules", "missing.yaml"})
+	assert.Len(t, before, 3)
+	assert.Contains(t, before, "modules/sub/b.sh")
+
+	afero.WriteFile(fs, "modules/a.sh", []byte("echo changed"), 0o644)
+	fs.Remove("modules/sub/b.sh")
+	after := fp.Fingerprint([]string{"manifest.yaml", "modules", "missing.yaml"})
+	assert.Len(t, after, 2)
+	assert.NotEqual(t, before["modules/a.sh"], after["modules/a.sh"])
+	assert.Equal(t, before["manifest.yaml"], after["manifest.yaml"])
+}
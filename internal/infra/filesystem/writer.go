@@ -50,3 +50,8 @@ func (w *Writer) Copy(src, dst string) error {
 func (w *Writer) MkdirAll(path string) error {
 	return w.fs.MkdirAll(path, 0o755)
 }
+
+// Remove deletes a file.
+func (w *Writer) Remove(path string) error {
+	return w.fs.Remove(path)
+}
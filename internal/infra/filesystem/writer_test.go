@@ -114,3 +114,17 @@ func TestWriter_WriteFile_Permissions(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "-rw-r--r--", info.Mode().String())
 }
+
+func TestWriter_Remove(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writer := NewWriter(fs)
+
+	require.NoError(t, writer.WriteFile("dir/file.txt", "content"))
+	require.NoError(t, writer.Remove("dir/file.txt"))
+
+	exists, err := afero.Exists(fs, "dir/file.txt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.Error(t, writer.Remove("dir/missing.txt"))
+}
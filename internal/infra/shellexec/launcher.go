@@ -0,0 +1,58 @@
+// Package shellexec starts shells the way a terminal does, for measuring
+// what a generated configuration does at startup.
+package shellexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// startupTimeout bounds a single shell startup, in case a module waits for
+// input or hangs on the network.
+const startupTimeout = time.Minute
+
+// Launcher runs zsh, bash or fish as an interactive shell that exits as soon
+// as its startup files are loaded.
+type Launcher struct{}
+
+// NewLauncher creates a Launcher.
+func NewLauncher() *Launcher {
+	return &Launcher{}
+}
+
+// Launch runs shell with -i (and -l for a login shell) and 'exit' as its
+// only command. env is the shell's whole environment. The shell's output is
+// returned; an error means it could not be run or exited with a failure.
+func (l *Launcher) Launch(shell string, login bool, env []string) (string, error) {
+	switch shell {
+	case "zsh", "bash", "fish":
+	default:
+		return "", fmt.Errorf("startup profiling not supported for shell %q", shell)
+	}
+
+	args := []string{"-i", "-c", "exit"}
+	if login {
+		args = append([]string{"-l"}, args...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, shell, args...) //nolint:gosec // shell is one of the fixed names above
+	cmd.Env = env
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return out.String(), fmt.Errorf("%s did not finish starting within %s", shell, startupTimeout)
+		}
+		return out.String(), fmt.Errorf("%s %s failed: %w", shell, strings.Join(args, " "), err)
+	}
+	return out.String(), nil
+}
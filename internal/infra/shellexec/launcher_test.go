@@ -0,0 +1,36 @@
+package shellexec
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLauncher_Launch(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	home := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".bashrc"), []byte("echo loaded-rc\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".bash_profile"), []byte("echo loaded-profile\n"), 0o644))
+	env := []string{"HOME=" + home, "PATH=" + os.Getenv("PATH")}
+
+	l := NewLauncher()
+	out, err := l.Launch("bash", false, env)
+	require.NoError(t, err)
+	assert.Contains(t, out, "loaded-rc")
+	assert.NotContains(t, out, "loaded-profile")
+
+	out, err = l.Launch("bash", true, env)
+	require.NoError(t, err)
+	assert.Contains(t, out, "loaded-profile")
+}
+
+func TestLauncher_Unsupported(t *testing.T) {
+	_, err := NewLauncher().Launch("tcsh", false, nil)
+	assert.Error(t, err)
+}
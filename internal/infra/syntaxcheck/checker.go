@@ -0,0 +1,62 @@
+// Package syntaxcheck parses shell scripts with the shell's own parser,
+// without executing them.
+package syntaxcheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// noExecArgs are the flags that make each shell parse its input and exit.
+var noExecArgs = map[string][]string{
+	"zsh":  {"-n"},
+	"bash": {"-n"},
+	"fish": {"--no-execute"},
+}
+
+// Checker runs zsh -n, bash -n or fish --no-execute over a script.
+type Checker struct{}
+
+// NewChecker creates a Checker.
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Available reports whether the interpreter for shell is installed.
+func (c *Checker) Available(shell string) bool {
+	if _, ok := noExecArgs[shell]; !ok {
+		return false
+	}
+	_, err := exec.LookPath(shell)
+	return err == nil
+}
+
+// Check parses script with shell, read from stdin, and returns the shell's
+// diagnostics. An empty result means the script parsed cleanly; an error
+// means the shell could not be run at all.
+func (c *Checker) Check(shell, script string) (string, error) {
+	args, ok := noExecArgs[shell]
+	if !ok {
+		return "", fmt.Errorf("syntax check not supported for shell %q", shell)
+	}
+
+	cmd := exec.Command(shell, args...) //nolint:gosec // shell is one of the fixed names above
+	cmd.Stdin = strings.NewReader(script)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return "", fmt.Errorf("%s %s failed: %w", shell, strings.Join(args, " "), err)
+	}
+	diagnostics := strings.TrimSpace(out.String())
+	if err != nil && diagnostics == "" {
+		diagnostics = fmt.Sprintf("%s exited with status %d", shell, exitErr.ExitCode())
+	}
+	return diagnostics, nil
+}
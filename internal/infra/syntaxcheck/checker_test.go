@@ -0,0 +1,42 @@
+package syntaxcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Check(t *testing.T) {
+	c := NewChecker()
+	if !c.Available("bash") {
+		t.Skip("bash is not installed")
+	}
+
+	t.Run("valid script", func(t *testing.T) {
+		out, err := c.Check("bash", "if true; then\n  echo ok\nfi\n")
+		require.NoError(t, err)
+		assert.Empty(t, out)
+	})
+
+	t.Run("unclosed if", func(t *testing.T) {
+		out, err := c.Check("bash", "echo start\nif true; then\n  echo ok\n")
+		require.NoError(t, err)
+		assert.Contains(t, out, "line 4")
+	})
+
+	t.Run("not executed", func(t *testing.T) {
+		dir := t.TempDir()
+		out, err := c.Check("bash", "touch "+dir+"/ran\n")
+		require.NoError(t, err)
+		assert.Empty(t, out)
+		assert.NoFileExists(t, dir+"/ran")
+	})
+}
+
+func TestChecker_Unsupported(t *testing.T) {
+	c := NewChecker()
+	assert.False(t, c.Available("tcsh"))
+	_, err := c.Check("tcsh", "echo")
+	assert.Error(t, err)
+}
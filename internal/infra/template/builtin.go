@@ -34,9 +34,14 @@ func getPathTemplate() *domain.Template {
 				Required:    true,
 			},
 		},
-		Content: `# Add {{PATH_DIR}} to PATH
+		// For several directories, or appending, prefer the module 'path:'
+		// declaration, which the builder merges into one deduplicated block.
+		Content: `# Add {{PATH_DIR}} to PATH (once)
 if [ -d "{{PATH_DIR}}" ]; then
-    export PATH="{{PATH_DIR}}:$PATH"
+    case ":$PATH:" in
+        *":{{PATH_DIR}}:"*) ;;
+        *) export PATH="{{PATH_DIR}}:$PATH" ;;
+    esac
 fi`,
 	}
 }
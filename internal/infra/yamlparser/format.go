@@ -0,0 +1,266 @@
+package yamlparser
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+)
+
+// canonicalKeyOrder is the key order Format writes for each mapping type
+// (named as in the schema $defs). Keys not listed keep their relative order
+// after the listed ones; free-form maps (vars, profiles, targets, packages) are left
+// in the author's order.
+var canonicalKeyOrder = map[string][]string{
+	"Manifest":       {"version", "shell", "output", "targets", "include", "vars", "os_vars", "profiles", "defaults", "modules"},
+	"ShellConfig":    {"type"},
+	"OutputConfig":   {"directory", "backup", "dir_loader", "path_target"},
+	"CustomTarget":   {"path", "kind", "extension", "comment", "sourced_by"},
+	"ModuleDefaults": {"isolate"},
+	"Profile":        {"hosts", "include", "include_tags", "exclude", "exclude_tags", "vars"},
+	"Module": {
+		"name", "file", "content", "generate", "path", "description", "target", "priority", "os", "when", "tags",
+		"requires", "requires_optional", "requires_bin", "requires_path", "packages",
+		"interpolate", "isolate", "lazy", "extends", "disabled",
+	},
+	"Condition":        {"arch", "distro", "hostname", "env", "shell", "all", "any", "not"},
+	"ModuleGenerator":  {"if", "env", "path", "source", "aliases"},
+	"RuntimeCondition": {"command", "file", "dir", "env"},
+	"PathRule":         {"prepend", "append", "os", "if_exists"},
+}
+
+// overridesDefault lists module keys whose false value is meaningful: it
+// overrides a true manifest default, so Format keeps it.
+var overridesDefault = map[string]bool{
+	"isolate": true,
+}
+
+// moduleDefaults are module values equal to what an omitted key means. The
+// default target depends on the shell; see defaultTarget.
+var moduleDefaults = map[string]string{
+	"priority": "50",
+}
+
+// Format rewrites a manifest in canonical form, keeping comments:
+//
+//   - keys are ordered as in canonicalKeyOrder;
+//   - OS names (module 'os', 'os_vars' keys) use the detected spelling;
+//   - module keys holding their default (priority: 50, false, empty lists,
+//     and the target the manifest's shell builds into by default) are
+//     dropped, except in 'extends' patches where they are meaningful, and
+//     except when a comment is attached;
+//   - indentation is two spaces, with a blank line between top-level
+//     sections and between modules.
+//
+// The input must pass schema checking; a *SchemaError is returned otherwise.
+func Format(data []byte, path string) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	body := documentBody(&root)
+	if body == nil || body.Kind == 0 {
+		return data, nil
+	}
+	if issues := checkNode(&root, manifestSchema, path); len(issues) > 0 {
+		return nil, &SchemaError{Issues: issues}
+	}
+
+	f := &formatter{defs: manifestSchema.Defs, defaultTarget: defaultTarget(body)}
+	f.format(body, manifestSchema, "Manifest")
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return spaceSections(buf.Bytes()), nil
+}
+
+type formatter struct {
+	defs map[string]*Schema
+
+	// defaultTarget is the module target an omitted 'target' means, empty
+	// when it cannot be told from this file alone.
+	defaultTarget string
+}
+
+// defaultTarget returns the main rc file target of the manifest's shell
+// (zshrc, bashrc or config), or "" when dropping an explicit target could
+// change the build or the manifest's meaning: the shell type comes from an
+// included file, or the manifest is a version 1 one, where any explicit
+// target marks it as not legacy (see domain.Manifest.IsLegacy).
+func defaultTarget(body *yaml.Node) string {
+	if version := mappingValue(body, "version"); version == nil || version.Value == "1" {
+		return ""
+	}
+	shell := "zsh"
+	if typ := mappingValue(mappingValue(body, "shell"), "type"); typ != nil {
+		shell = typ.Value
+	} else if mappingValue(body, "include") != nil {
+		return ""
+	}
+	return domain.NewTargetResolver(shell, "").GetDefaultTarget()
+}
+
+// format normalises node, whose schema is s; typeName names s in
+// canonicalKeyOrder ("" for free-form maps and scalars).
+func (f *formatter) format(node *yaml.Node, s *Schema, typeName string) {
+	if s.Ref != "" {
+		typeName = strings.TrimPrefix(s.Ref, "#/$defs/")
+		s = f.defs[typeName]
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if prop, ok := s.Properties[key.Value]; ok {
+				f.format(value, prop, "")
+			} else if extra, ok := s.AdditionalProperties.(*Schema); ok {
+				f.format(value, extra, "")
+			}
+		}
+		switch typeName {
+		case "Manifest":
+			if osVars := mappingValue(node, "os_vars"); osVars != nil && osVars.Kind == yaml.MappingNode {
+				for i := 0; i < len(osVars.Content); i += 2 {
+					osVars.Content[i].Value = domain.CanonicalOSName(osVars.Content[i].Value)
+				}
+			}
+		case "Module":
+			f.normaliseModule(node)
+		}
+		if order, ok := canonicalKeyOrder[typeName]; ok {
+			sortMapping(node, order)
+		}
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for _, item := range node.Content {
+				f.format(item, s.Items, "")
+			}
+		}
+	}
+}
+
+// normaliseModule fixes OS spelling and drops keys holding default values.
+func (f *formatter) normaliseModule(node *yaml.Node) {
+	if osList := mappingValue(node, "os"); osList != nil && osList.Kind == yaml.SequenceNode {
+		for _, item := range osList.Content {
+			item.Value = domain.CanonicalOSName(item.Value)
+		}
+	}
+
+	if extends := mappingValue(node, "extends"); extends != nil && extends.Value == "true" {
+		return // a patch sets exactly the keys it lists
+	}
+
+	kept := node.Content[:0]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		isDefaultTarget := key.Value == "target" && f.defaultTarget != "" && strings.EqualFold(value.Value, f.defaultTarget)
+		if (isDefaultTarget || isDefault(key.Value, value)) && !hasComments(key) && !hasComments(value) {
+			continue
+		}
+		kept = append(kept, key, value)
+	}
+	node.Content = kept
+}
+
+// isDefault reports whether a module key holds the value an omitted key means.
+func isDefault(key string, value *yaml.Node) bool {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		if value.Tag == "!!bool" {
+			return value.Value == "false" && !overridesDefault[key]
+		}
+		def, ok := moduleDefaults[key]
+		return ok && value.Value == def
+	case yaml.SequenceNode, yaml.MappingNode:
+		return len(value.Content) == 0
+	}
+	return false
+}
+
+func hasComments(node *yaml.Node) bool {
+	return node.HeadComment != "" || node.LineComment != "" || node.FootComment != ""
+}
+
+// sortMapping reorders key/value pairs by order; unlisted keys follow in
+// their original relative order.
+func sortMapping(node *yaml.Node, order []string) {
+	type pair struct{ key, value *yaml.Node }
+	pairs := make([]pair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{node.Content[i], node.Content[i+1]})
+	}
+
+	rank := func(key string) int {
+		if i := slices.Index(order, key); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(pairs, func(a, b pair) int {
+		return rank(a.key.Value) - rank(b.key.Value)
+	})
+
+	node.Content = node.Content[:0]
+	for _, p := range pairs {
+		node.Content = append(node.Content, p.key, p.value)
+	}
+}
+
+// isCommentAt reports whether line is a comment indented at most indent spaces.
+func isCommentAt(line string, indent int) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	return strings.HasPrefix(trimmed, "#") && len(line)-len(trimmed) <= indent
+}
+
+// spaceSections inserts a blank line before each top-level key and each
+// item of the top-level modules list (above any comment attached to it),
+// since the YAML encoder does not keep blank lines.
+func spaceSections(data []byte) []byte {
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	var out []string
+	inModules := false
+	firstItem := false
+
+	for _, line := range lines {
+		topLevelKey := line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "-")
+		moduleItem := inModules && strings.HasPrefix(line, "  - ")
+
+		if topLevelKey {
+			inModules = strings.HasPrefix(line, "modules:")
+			firstItem = inModules
+		}
+
+		needBlank := (topLevelKey && len(out) > 0) || (moduleItem && !firstItem)
+		if moduleItem {
+			firstItem = false
+		}
+		if needBlank {
+			// Hoist the blank line above the comments leading up to this
+			// line. Comments attached to it are indented no deeper; deeper
+			// '#' lines belong to a block scalar (inline content) above.
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			at := len(out)
+			for at > 0 && (out[at-1] == "" || isCommentAt(out[at-1], indent)) {
+				at--
+			}
+			if at > 0 && (at == len(out) || out[at] != "") {
+				out = slices.Insert(out, at, "")
+			}
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
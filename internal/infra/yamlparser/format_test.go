@@ -0,0 +1,213 @@
+package yamlparser
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+)
+
+func TestFormat(t *testing.T) {
+	input := `# Header comment
+
+modules:
+  - os: [mac, LINUX]
+    priority: 50
+    # where the file lives
+    file: git.sh
+    name: git
+    target: zshrc
+    requires: []
+    interpolate: false
+  - name: base
+    extends: true
+    priority: 50
+    target: zshrc
+  - name: tools
+    isolate: false
+    file: tools.sh
+    priority: 10 # keep early
+defaults:
+  isolate: true
+version: "2"
+os_vars:
+  mac:
+    brew: /opt/homebrew
+shell:
+  type: zsh
+targets:
+  env:
+    sourced_by: zshrc
+    path: .config/shell/env.sh
+`
+	want := `# Header comment
+
+version: "2"
+
+shell:
+  type: zsh
+
+targets:
+  env:
+    path: .config/shell/env.sh
+    sourced_by: zshrc
+
+os_vars:
+  Mac:
+    brew: /opt/homebrew
+
+defaults:
+  isolate: true
+
+modules:
+  - name: git
+    # where the file lives
+    file: git.sh
+    os: [Mac, Linux]
+
+  - name: base
+    target: zshrc
+    priority: 50
+    extends: true
+
+  - name: tools
+    file: tools.sh
+    priority: 10 # keep early
+    isolate: false
+`
+
+	got, err := Format([]byte(input), "manifest.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+
+	// Formatting is idempotent
+	again, err := Format(got, "manifest.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, string(got), string(again))
+}
+
+func TestFormat_KeepsInlineContentEndingInComments(t *testing.T) {
+	input := `modules:
+  - name: editor
+    content: |
+      export EDITOR=vim
+      # keep vim
+      # everywhere
+  # the next module
+  - name: pager
+    content: export PAGER=less
+`
+	want := `modules:
+  - name: editor
+    content: |
+      export EDITOR=vim
+      # keep vim
+      # everywhere
+
+  # the next module
+  - name: pager
+    content: export PAGER=less
+`
+
+	got, err := Format([]byte(input), "manifest.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+
+	// The module body is unchanged
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "manifest.yaml", got, 0o644))
+	m, err := New(fs).Parse("manifest.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "export EDITOR=vim\n# keep vim\n# everywhere\n", m.Modules[0].Content)
+}
+
+func TestFormat_RejectsSchemaErrors(t *testing.T) {
+	_, err := Format([]byte("modules:\n  - name: a\n    prority: 1\n"), "manifest.yaml")
+
+	var schemaErr *SchemaError
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Contains(t, err.Error(), "manifest.yaml:3:5: unknown field 'prority'")
+}
+
+func TestFormat_Empty(t *testing.T) {
+	got, err := Format([]byte(""), "manifest.yaml")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestFormat_RealExamplePreservesMeaning(t *testing.T) {
+	examplePath := "../../../examples/manifest.yaml"
+	data, err := os.ReadFile(examplePath)
+	if os.IsNotExist(err) {
+		t.Skip("Example manifest not found")
+	}
+	require.NoError(t, err)
+
+	formatted, err := Format(data, examplePath)
+	require.NoError(t, err)
+
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "original.yaml", data, 0o644)
+	afero.WriteFile(fs, "formatted.yaml", formatted, 0o644)
+
+	original, err := New(fs).Parse("original.yaml")
+	require.NoError(t, err)
+	reformatted, err := New(fs).Parse("formatted.yaml")
+	require.NoError(t, err)
+
+	require.Len(t, reformatted.Modules, len(original.Modules))
+	for i := range original.Modules {
+		a, b := original.Modules[i], reformatted.Modules[i]
+		a.Location, b.Location = domain.SourceLocation{}, domain.SourceLocation{}
+		assert.Equal(t, a.Name, b.Name)
+		assert.Equal(t, a.GetTarget(), b.GetTarget(), a.Name)
+		assert.Equal(t, a.GetPriority(), b.GetPriority(), a.Name)
+		assert.Equal(t, a.OS, b.OS, a.Name)
+		assert.Equal(t, a.Requires, b.Requires, a.Name)
+	}
+}
+
+func TestFormat_DefaultTargetDependsOnShell(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		kept   []string
+	}{
+		{name: "zsh", header: "version: \"2\"\n", kept: []string{"bashrc", "config"}},
+		{name: "bash", header: "version: \"2\"\nshell:\n  type: bash\n", kept: []string{"zshrc", "config"}},
+		{name: "fish", header: "version: \"2\"\nshell:\n  type: fish\n", kept: []string{"zshrc", "bashrc"}},
+		{name: "shell from an include", header: "version: \"2\"\ninclude: [base.yaml]\n", kept: []string{"zshrc", "bashrc", "config"}},
+		{name: "version 1", header: "", kept: []string{"zshrc", "bashrc", "config"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.header + `modules:
+  - name: a
+    file: a.sh
+    target: zshrc
+  - name: b
+    file: b.sh
+    target: bashrc
+  - name: c
+    file: c.fish
+    target: config
+`
+			got, err := Format([]byte(input), "manifest.yaml")
+			require.NoError(t, err)
+
+			var kept []string
+			for _, target := range []string{"zshrc", "bashrc", "config"} {
+				if strings.Contains(string(got), "target: "+target+"\n") {
+					kept = append(kept, target)
+				}
+			}
+			assert.Equal(t, tt.kept, kept)
+		})
+	}
+}
@@ -1,7 +1,12 @@
 package yamlparser
 
 import (
+	"bytes"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/spf13/afero"
 	"gopkg.in/yaml.v3"
@@ -9,6 +14,22 @@ import (
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
 )
 
+// manifestSchema drives strict checking of every parsed manifest file.
+var manifestSchema = ManifestSchema()
+
+// SchemaError reports every schema violation found in a manifest file.
+type SchemaError struct {
+	Issues []Issue
+}
+
+func (e *SchemaError) Error() string {
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = issue.Error()
+	}
+	return "invalid manifest:\n  " + strings.Join(lines, "\n  ")
+}
+
 // Parser implements YAML manifest parsing.
 type Parser struct {
 	fs afero.Fs
@@ -20,19 +41,177 @@ func New(fs afero.Fs) *Parser {
 }
 
 // Parse reads and parses a YAML manifest file.
-// Returns a Manifest or an error if parsing fails.
+// Returns a Manifest or an error if parsing fails. Decoding is strict:
+// unknown keys, wrong value types and invalid enum values (such as
+// 'version') fail with a *SchemaError listing each file:line:column.
+// Each module records the file and line it was declared at.
+//
+// Manifests listed under 'include' are resolved relative to the including
+// file (globs allowed, matches in lexical order) and merged depth-first
+// before the including file's own modules; see domain.Manifest.ApplyLayer.
 func (p *Parser) Parse(path string) (*domain.Manifest, error) {
+	merged := &domain.Manifest{}
+	if err := p.load(path, merged, nil); err != nil {
+		return nil, err
+	}
+	merged.Path = path
+	return merged, nil
+}
+
+// load parses one manifest file, applies its includes and then its own
+// modules onto merged, skipping files merged already. stack holds the
+// include chain for cycle detection.
+func (p *Parser) load(path string, merged *domain.Manifest, stack []string) error {
+	clean := filepath.Clean(path)
+	for i, seen := range stack {
+		if seen == clean {
+			chain := append(append([]string{}, stack[i:]...), clean)
+			return fmt.Errorf("include cycle detected: %s", strings.Join(chain, " → "))
+		}
+	}
+	// A file reached again through another branch (a diamond) is applied
+	// once, at its first position; applying it again would undo the
+	// overrides made between the two branches.
+	if slices.ContainsFunc(merged.Sources, func(src string) bool { return filepath.Clean(src) == clean }) {
+		return nil
+	}
+	stack = append(stack, clean)
+
+	layer, err := p.parseFile(path)
+	if err != nil {
+		if len(stack) > 1 {
+			return fmt.Errorf("%s (included from %s): %w", path, stack[len(stack)-2], err)
+		}
+		return err
+	}
+
+	for _, pattern := range layer.Include {
+		includes, err := p.resolveInclude(filepath.Dir(path), pattern)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, inc := range includes {
+			if err := p.load(inc, merged, stack); err != nil {
+				return err
+			}
+		}
+	}
+
+	merged.ApplyLayer(layer)
+	merged.Sources = append(merged.Sources, path)
+	return nil
+}
+
+// resolveInclude expands one include entry. Glob patterns may match nothing;
+// a plain path must exist.
+func (p *Parser) resolveInclude(baseDir, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(baseDir, pattern)
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, err := p.fs.Stat(pattern); err != nil {
+			return nil, fmt.Errorf("included manifest not found: %s", pattern)
+		}
+		return []string{pattern}, nil
+	}
+
+	matches, err := afero.Glob(p.fs, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// parseFile parses a single manifest file without resolving includes.
+func (p *Parser) parseFile(path string) (*domain.Manifest, error) {
 	// Read file
 	data, err := afero.ReadFile(p.fs, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read manifest file: %w", err)
 	}
 
-	// Parse YAML
-	var manifest domain.Manifest
-	if err := yaml.Unmarshal(data, &manifest); err != nil {
+	// Parse YAML into a node tree first so positions are available
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	// Reject unknown keys, wrong types and bad enum values with positions
+	if issues := checkNode(&root, manifestSchema, path); len(issues) > 0 {
+		return nil, &SchemaError{Issues: issues}
+	}
+
+	var manifest domain.Manifest
+	if root.Kind != 0 {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	}
+
+	manifest.Path = path
+	recordModuleLocations(&root, &manifest, path)
+
 	return &manifest, nil
 }
+
+// recordModuleLocations copies the position of each entry of the top-level
+// "modules" sequence, and of inline bodies, onto the decoded modules.
+func recordModuleLocations(root *yaml.Node, manifest *domain.Manifest, path string) {
+	seq := mappingValue(documentBody(root), "modules")
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, item := range seq.Content {
+		if i >= len(manifest.Modules) {
+			break
+		}
+		manifest.Modules[i].Location = domain.SourceLocation{
+			File:   path,
+			Line:   item.Line,
+			Column: item.Column,
+		}
+		manifest.Modules[i].BodyLocation = bodyLocation(item, path)
+	}
+}
+
+// bodyLocation returns where a module entry declares its body inline: the
+// first line of 'content' (the line after the indicator of a | or > block),
+// or the 'generate' mapping. It is zero for modules with a file.
+func bodyLocation(item *yaml.Node, path string) domain.SourceLocation {
+	if node := mappingValue(item, "content"); node != nil {
+		line := node.Line
+		if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			line++
+		}
+		return domain.SourceLocation{File: path, Line: line, Column: node.Column}
+	}
+	if node := mappingValue(item, "generate"); node != nil {
+		return domain.SourceLocation{File: path, Line: node.Line, Column: node.Column}
+	}
+	return domain.SourceLocation{}
+}
+
+// documentBody unwraps a document node to its top-level content.
+func documentBody(node *yaml.Node) *yaml.Node {
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return node.Content[0]
+	}
+	return node
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
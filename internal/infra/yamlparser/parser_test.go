@@ -11,6 +11,54 @@ import (
 	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
 )
 
+func TestParser_Parse_RecordsLocations(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	content := `version: "2"
+modules:
+  - name: first
+    file: first.sh
+
+  - name: second
+    file: second.sh
+`
+	require.NoError(t, afero.WriteFile(fs, "manifest.yaml", []byte(content), 0o644))
+
+	m, err := New(fs).Parse("manifest.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "manifest.yaml", m.Path)
+	require.Len(t, m.Modules, 2)
+	assert.Equal(t, domain.SourceLocation{File: "manifest.yaml", Line: 3, Column: 5}, m.Modules[0].Location)
+	assert.Equal(t, domain.SourceLocation{File: "manifest.yaml", Line: 6, Column: 5}, m.Modules[1].Location)
+}
+
+func TestParser_Parse_RecordsBodyLocations(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	content := `modules:
+  - name: block
+    content: |
+      export EDITOR=vim
+  - name: plain
+    content: alias ll='ls -la'
+  - name: generated
+    generate:
+      path: [~/bin]
+  - name: file
+    file: file.sh
+`
+	require.NoError(t, afero.WriteFile(fs, "manifest.yaml", []byte(content), 0o644))
+
+	m, err := New(fs).Parse("manifest.yaml")
+	require.NoError(t, err)
+	require.Len(t, m.Modules, 4)
+	assert.Equal(t, "export EDITOR=vim\n", m.Modules[0].Content)
+	assert.Equal(t, 4, m.Modules[0].BodyLocation.Line, "first line of the block")
+	assert.Equal(t, 6, m.Modules[1].BodyLocation.Line)
+	assert.Equal(t, []string{"~/bin"}, m.Modules[2].Generate.Path)
+	assert.Equal(t, domain.SourceLocation{File: "manifest.yaml", Line: 9, Column: 7}, m.Modules[2].BodyLocation)
+	assert.True(t, m.Modules[3].BodyLocation.IsZero())
+}
+
 func TestParser_Parse(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -97,6 +145,32 @@ func TestParser_Parse(t *testing.T) {
 			wantErr: true,
 			errMsg:  "failed to parse YAML",
 		},
+		{
+			name:    "unknown module field",
+			content: "modules:\n  - name: test\n    file: test.sh\n    prority: 5\n",
+			wantErr: true,
+			errMsg:  "manifest.yaml:4:5: unknown field 'prority' in modules[0] (did you mean 'priority'?)",
+		},
+		{
+			name:    "wrong value type",
+			content: "modules:\n  - name: test\n    file: test.sh\n    requires: base\n",
+			wantErr: true,
+			errMsg:  "manifest.yaml:4:15: modules[0].requires must be a list, got \"base\"",
+		},
+		{
+			name:    "invalid version",
+			content: "version: \"3\"\nmodules: []\n",
+			wantErr: true,
+			errMsg:  "manifest.yaml:1:10: invalid version \"3\" (allowed: 1, 2)",
+		},
+		{
+			name:    "numeric version",
+			content: "version: 2\nmodules: []\n",
+			wantErr: false,
+			validate: func(t *testing.T, m *domain.Manifest) {
+				assert.Equal(t, "2", m.Version)
+			},
+		},
 		{
 			name:    "empty file",
 			content: "",
@@ -199,3 +273,200 @@ func TestParser_Parse_RealExample(t *testing.T) {
 	assert.True(t, hasOSDetection, "should have os-detection module")
 	assert.True(t, hasBrewPath, "should have brew-path module")
 }
+
+func TestParser_Parse_Includes(t *testing.T) {
+	write := func(t *testing.T, fs afero.Fs, path, content string) {
+		t.Helper()
+		require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0o644))
+	}
+	names := func(m *domain.Manifest) []string {
+		out := make([]string, len(m.Modules))
+		for i, mod := range m.Modules {
+			out[i] = mod.Name
+		}
+		return out
+	}
+
+	t.Run("merges relative includes and globs with override semantics", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		write(t, fs, "cfg/base.yaml", `shell:
+  type: zsh
+modules:
+  - name: path
+    file: path.sh
+  - name: git
+    file: git.sh
+    description: Git aliases
+    priority: 40
+  - name: work-proxy
+    file: proxy.sh
+`)
+		write(t, fs, "cfg/layers/10-person.yaml", `modules:
+  - name: path
+    file: my-path.sh
+`)
+		write(t, fs, "cfg/layers/20-machine.yaml", `modules:
+  - name: git
+    extends: true
+    priority: 10
+  - name: work-proxy
+    disabled: true
+  - name: laptop
+    file: laptop.sh
+`)
+		write(t, fs, "cfg/manifest.yaml", `include:
+  - base.yaml
+  - layers/*.yaml
+modules:
+  - name: local
+    file: local.sh
+`)
+
+		m, err := New(fs).Parse("cfg/manifest.yaml")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"path", "git", "laptop", "local"}, names(m))
+		assert.Equal(t, "zsh", m.Shell.Type)
+		assert.Equal(t, "cfg/manifest.yaml", m.Path)
+		assert.Equal(t, []string{"cfg/base.yaml", "cfg/layers/10-person.yaml", "cfg/layers/20-machine.yaml", "cfg/manifest.yaml"}, m.Sources)
+
+		path, _ := m.FindModule("path")
+		assert.Equal(t, "my-path.sh", path.File)
+		assert.Equal(t, "cfg/layers/10-person.yaml", path.Location.File)
+
+		git, _ := m.FindModule("git")
+		assert.Equal(t, "git.sh", git.File)
+		assert.Equal(t, "Git aliases", git.Description)
+		assert.Equal(t, 10, git.Priority)
+		assert.False(t, git.Extends)
+		assert.Equal(t, "cfg/base.yaml", git.Location.File)
+		require.Len(t, git.PatchedAt, 1)
+		assert.Equal(t, domain.SourceLocation{File: "cfg/layers/20-machine.yaml", Line: 2, Column: 5}, git.PatchedAt[0])
+
+		assert.Empty(t, m.Validate())
+	})
+
+	t.Run("include cycle is reported with the chain", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		write(t, fs, "a.yaml", "include: [b.yaml]\nmodules: []\n")
+		write(t, fs, "b.yaml", "include: [a.yaml]\nmodules: []\n")
+
+		_, err := New(fs).Parse("a.yaml")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "include cycle detected: a.yaml → b.yaml → a.yaml")
+	})
+
+	t.Run("missing include is an error, empty glob is not", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		write(t, fs, "manifest.yaml", "include: [missing.yaml]\nmodules: []\n")
+
+		_, err := New(fs).Parse("manifest.yaml")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "included manifest not found")
+
+		write(t, fs, "glob.yaml", "include: [hosts/*.yaml]\nmodules:\n  - name: a\n    file: a.sh\n")
+		m, err := New(fs).Parse("glob.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, names(m))
+	})
+
+	t.Run("diamond includes are loaded once per path", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		write(t, fs, "common.yaml", "modules:\n  - name: common\n    file: common.sh\n")
+		write(t, fs, "a.yaml", "include: [common.yaml]\nmodules: []\n")
+		write(t, fs, "b.yaml", "include: [common.yaml]\nmodules: []\n")
+		write(t, fs, "manifest.yaml", "include: [a.yaml, b.yaml]\nmodules: []\n")
+
+		m, err := New(fs).Parse("manifest.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"common"}, names(m))
+		assert.Empty(t, m.Validate())
+	})
+
+	t.Run("diamond include does not undo overrides made in between", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		write(t, fs, "common.yaml", `output:
+  backup: true
+  dir_loader: true
+defaults:
+  isolate: true
+modules:
+  - name: proxy
+    file: proxy.sh
+    interpolate: true
+`)
+		write(t, fs, "a.yaml", `include: [common.yaml]
+output:
+  backup: false
+  dir_loader: false
+defaults:
+  isolate: false
+modules:
+  - name: proxy
+    extends: true
+    interpolate: false
+`)
+		write(t, fs, "b.yaml", "include: [common.yaml]\nmodules: []\n")
+		write(t, fs, "manifest.yaml", "include: [a.yaml, b.yaml]\nmodules: []\n")
+
+		m, err := New(fs).Parse("manifest.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"common.yaml", "a.yaml", "b.yaml", "manifest.yaml"}, m.Sources)
+
+		// A later layer turns settings off
+		require.NotNil(t, m.Output.Backup)
+		assert.False(t, *m.Output.Backup)
+		assert.False(t, m.Output.LoadsDirTargets())
+		require.NotNil(t, m.Defaults.Isolate)
+		assert.False(t, *m.Defaults.Isolate)
+		proxy, _ := m.FindModule("proxy")
+		assert.False(t, proxy.IsInterpolated())
+		require.Len(t, proxy.PatchedAt, 1)
+	})
+}
+
+func TestParser_Parse_ShellTypeIsCaseInsensitive(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "manifest.yaml", []byte("shell:\n  type: Zsh\nmodules: []\n"), 0o644)
+
+	m, err := New(fs).Parse("manifest.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "zsh", m.GetShellType())
+
+	afero.WriteFile(fs, "manifest.yaml", []byte("shell:\n  type: tcsh\nmodules: []\n"), 0o644)
+	_, err = New(fs).Parse("manifest.yaml")
+	var schemaErr *SchemaError
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Contains(t, schemaErr.Issues[0].Error(), "invalid shell.type \"tcsh\"")
+}
+
+func TestParser_Parse_SchemaErrorListsAllIssues(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "base.yaml", []byte(`modules:
+  - file: a.sh
+    require: [b]
+    when:
+      not:
+        arhc: [arm64]
+    priority: high
+`), 0o644)
+	afero.WriteFile(fs, "manifest.yaml", []byte("include: [base.yaml]\n"), 0o644)
+
+	_, err := New(fs).Parse("manifest.yaml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "(included from manifest.yaml)")
+
+	var schemaErr *SchemaError
+	require.ErrorAs(t, err, &schemaErr)
+
+	var got []string
+	for _, issue := range schemaErr.Issues {
+		got = append(got, issue.Error())
+	}
+	assert.Equal(t, []string{
+		"base.yaml:3:5: unknown field 'require' in modules[0] (did you mean 'requires'?)",
+		"base.yaml:6:9: unknown field 'arhc' in modules[0].when.not (did you mean 'arch'?)",
+		"base.yaml:7:15: modules[0].priority must be an integer, got \"high\"",
+		"base.yaml:2:5: missing required field 'name' in modules[0]",
+	}, got)
+}
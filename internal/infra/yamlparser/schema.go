@@ -0,0 +1,309 @@
+package yamlparser
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gizzahub/gzh-cli-shellforge/internal/domain"
+)
+
+// SchemaDraft is the JSON Schema dialect of the generated manifest schema.
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used to describe manifest files.
+type Schema struct {
+	Draft                string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // false or *Schema
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// schemaEnums restricts fields to fixed values, keyed by "Type.yamlkey".
+// Versions are listed both as strings and numbers since YAML users write
+// either `version: "2"` or `version: 2`. Values match case-insensitively,
+// since the shell type is lowercased when used (`type: Zsh` is zsh).
+var schemaEnums = map[string][]any{
+	"Manifest.version": {"1", "2", 1, 2},
+	"ShellConfig.type": {"zsh", "bash", "fish"},
+}
+
+// schemaRequired lists required keys per struct type.
+var schemaRequired = map[string][]string{
+	"Module": {"name"},
+}
+
+// ManifestSchema returns the JSON Schema of manifest.yaml (format versions
+// 1 and 2), generated from the yaml tags of domain.Manifest so it cannot
+// drift from what the parser accepts.
+func ManifestSchema() *Schema {
+	g := &schemaGenerator{defs: make(map[string]*Schema)}
+	root := g.object(reflect.TypeFor[domain.Manifest]())
+	root.Draft = SchemaDraft
+	root.Title = "shellforge manifest"
+	root.Description = "Module manifest for shellforge (manifest.yaml)"
+	root.Defs = g.defs
+	return root
+}
+
+type schemaGenerator struct {
+	defs map[string]*Schema
+}
+
+// schemaFor maps a Go type to a schema. Named structs other than the root
+// become $defs entries so recursive types (Condition) terminate.
+func (g *schemaGenerator) schemaFor(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // reserve before recursing
+			g.defs[name] = g.object(t)
+		}
+		return &Schema{Ref: "#/$defs/" + name}
+	}
+	return &Schema{}
+}
+
+// object describes a struct as a closed object from its yaml tags,
+// flattening ",inline" fields.
+func (g *schemaGenerator) object(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+		Required:             schemaRequired[t.Name()],
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "-" {
+			continue
+		}
+		if slices.Contains(strings.Split(opts, ","), "inline") {
+			for name, prop := range g.object(field.Type).Properties {
+				s.Properties[name] = prop
+			}
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		prop := g.schemaFor(field.Type)
+		if enum, ok := schemaEnums[t.Name()+"."+key]; ok {
+			prop = &Schema{Enum: enum}
+		}
+		s.Properties[key] = prop
+	}
+	return s
+}
+
+// Issue is a schema violation at a position in a manifest file.
+type Issue struct {
+	Location domain.SourceLocation
+	Message  string
+}
+
+func (i Issue) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", i.Location.File, i.Location.Line, i.Location.Column, i.Message)
+}
+
+// checkNode validates a YAML document against schema, collecting every
+// unknown key, type mismatch and enum violation with its position.
+func checkNode(root *yaml.Node, schema *Schema, path string) []Issue {
+	c := &nodeChecker{defs: schema.Defs, path: path}
+	if body := documentBody(root); body != nil && body.Kind != 0 {
+		c.check(body, schema, "")
+	}
+	return c.issues
+}
+
+type nodeChecker struct {
+	defs   map[string]*Schema
+	path   string
+	issues []Issue
+}
+
+func (c *nodeChecker) report(node *yaml.Node, format string, args ...any) {
+	c.issues = append(c.issues, Issue{
+		Location: domain.SourceLocation{File: c.path, Line: node.Line, Column: node.Column},
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// check validates node against s; where is the value's path for messages
+// (e.g. "modules[2].when"), empty for the document root.
+func (c *nodeChecker) check(node *yaml.Node, s *Schema, where string) {
+	if s.Ref != "" {
+		s = c.defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return // null decodes to the zero value
+	}
+
+	if len(s.Enum) > 0 {
+		if node.Kind != yaml.ScalarNode || !enumContains(s.Enum, node.Value) {
+			c.report(node, "invalid %s %s (allowed: %s)", where, describeNode(node), enumList(s.Enum))
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			c.report(node, "%s must be a mapping, got %s", describePath(where), describeNode(node))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := key.Value
+			if where != "" {
+				child = where + "." + key.Value
+			}
+			if prop, ok := s.Properties[key.Value]; ok {
+				c.check(value, prop, child)
+				continue
+			}
+			if extra, ok := s.AdditionalProperties.(*Schema); ok {
+				c.check(value, extra, child)
+				continue
+			}
+			msg := fmt.Sprintf("unknown field '%s'", key.Value)
+			if where != "" {
+				msg += " in " + where
+			}
+			if suggestion := closestKey(key.Value, s.Properties); suggestion != "" {
+				msg += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+			}
+			c.report(key, "%s", msg)
+		}
+		for _, name := range s.Required {
+			if mappingValue(node, name) == nil {
+				c.report(node, "missing required field '%s' in %s", name, where)
+			}
+		}
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			c.report(node, "%s must be a list, got %s", where, describeNode(node))
+			return
+		}
+		for i, item := range node.Content {
+			c.check(item, s.Items, fmt.Sprintf("%s[%d]", where, i))
+		}
+	case "string":
+		if node.Kind != yaml.ScalarNode {
+			c.report(node, "%s must be a string, got %s", where, describeNode(node))
+		}
+	case "integer":
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			c.report(node, "%s must be an integer, got %s", where, describeNode(node))
+		}
+	case "boolean":
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			c.report(node, "%s must be true or false, got %s", where, describeNode(node))
+		}
+	}
+}
+
+// describePath names the value at where for error messages.
+func describePath(where string) string {
+	if where == "" {
+		return "manifest"
+	}
+	return where
+}
+
+// describeNode names a node's kind for error messages.
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("%q", node.Value)
+	}
+}
+
+func enumContains(enum []any, value string) bool {
+	for _, v := range enum {
+		if strings.EqualFold(fmt.Sprint(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// enumList renders the distinct enum values for messages.
+func enumList(enum []any) string {
+	var values []string
+	for _, v := range enum {
+		if s := fmt.Sprint(v); !slices.Contains(values, s) {
+			values = append(values, s)
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// closestKey suggests the known key nearest to key, if it is a likely typo.
+func closestKey(key string, properties map[string]*Schema) string {
+	best, bestDist := "", 3
+	for name := range properties {
+		if d := editDistance(key, name); d < bestDist || (d == bestDist && best != "" && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,57 @@
+package yamlparser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestSchema(t *testing.T) {
+	schema := ManifestSchema()
+
+	assert.Equal(t, SchemaDraft, schema.Draft)
+	assert.Equal(t, false, schema.AdditionalProperties)
+	for _, key := range []string{"version", "shell", "output", "modules", "profiles", "include", "vars", "os_vars"} {
+		assert.Contains(t, schema.Properties, key)
+	}
+	// Parser-only fields are not part of the file format
+	assert.NotContains(t, schema.Properties, "path")
+	assert.NotContains(t, schema.Properties, "sources")
+
+	assert.Equal(t, []any{"1", "2", 1, 2}, schema.Properties["version"].Enum)
+
+	module := schema.Defs["Module"]
+	require.NotNil(t, module)
+	assert.Equal(t, []string{"name"}, module.Required)
+	assert.Equal(t, "integer", module.Properties["priority"].Type)
+	assert.Equal(t, "array", module.Properties["requires"].Type)
+	assert.Equal(t, "#/$defs/Condition", module.Properties["when"].Ref)
+	assert.NotContains(t, module.Properties, "location")
+
+	// Recursive condition type resolves through $defs
+	condition := schema.Defs["Condition"]
+	require.NotNil(t, condition)
+	assert.Equal(t, "#/$defs/Condition", condition.Properties["not"].Ref)
+	assert.Equal(t, "#/$defs/Condition", condition.Properties["all"].Items.Ref)
+
+	// Profile flattens the inline ModuleSelector
+	profile := schema.Defs["Profile"]
+	require.NotNil(t, profile)
+	for _, key := range []string{"hosts", "include", "include_tags", "exclude", "exclude_tags", "vars"} {
+		assert.Contains(t, profile.Properties, key)
+	}
+
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"additionalProperties":false`)
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("requires", "requires"))
+	assert.Equal(t, 1, editDistance("require", "requires"))
+	assert.Equal(t, 1, editDistance("prority", "priority"))
+	assert.Equal(t, 2, editDistance("pirority", "priority"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}